@@ -0,0 +1,110 @@
+// Command admin manages user roles from the command line.
+// It grants the first admin, who can then manage roles through the admin API.
+//
+//	admin grant-role <user id> [role]   grant a role, admin by default
+//	admin revoke-role <user id> <role>  take a role away
+//	admin roles <user id>               list the roles and permissions of a user
+//
+// It uses the same configuration as the server. User IDs are shown by GET /auth/me.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"game2048/internal/config"
+	"game2048/internal/database"
+	"game2048/pkg/models"
+)
+
+func main() {
+	if len(os.Args) < 3 {
+		usage()
+	}
+	command, userID := os.Args[1], os.Args[2]
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	db, err := database.NewGormDB(
+		cfg.Database.Host,
+		cfg.Database.Port,
+		cfg.Database.User,
+		cfg.Database.Password,
+		cfg.Database.Name,
+		cfg.Database.SSLMode,
+	)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	user, err := db.GetUser(userID)
+	if err != nil {
+		log.Fatalf("Failed to get user %s: %v", userID, err)
+	}
+
+	switch command {
+	case "grant-role":
+		role := models.RoleAdmin
+		if len(os.Args) > 3 {
+			role = os.Args[3]
+		}
+		if user.IsGuest {
+			log.Fatalf("User %s is a guest, guests cannot be granted roles", userID)
+		}
+		if _, err := db.GetRole(role); err != nil {
+			log.Fatalf("Failed to get role %s: %v", role, err)
+		}
+		if err := db.GrantRole(user.ID, role, ""); err != nil {
+			log.Fatalf("Failed to grant role: %v", err)
+		}
+		fmt.Printf("Granted role %s to %s (%s)\n", role, user.Name, user.ID)
+
+	case "revoke-role":
+		if len(os.Args) < 4 {
+			usage()
+		}
+		if err := db.RevokeRole(user.ID, os.Args[3]); err != nil {
+			log.Fatalf("Failed to revoke role: %v", err)
+		}
+		fmt.Printf("Revoked role %s of %s (%s)\n", os.Args[3], user.Name, user.ID)
+
+	case "roles":
+		roles, err := db.GetUserRoles(user.ID)
+		if err != nil {
+			log.Fatalf("Failed to get roles: %v", err)
+		}
+		permissions, err := db.GetUserPermissions(user.ID)
+		if err != nil {
+			log.Fatalf("Failed to get permissions: %v", err)
+		}
+
+		names := make([]string, len(roles))
+		for i, role := range roles {
+			names[i] = role.Role
+		}
+		granted := make([]string, len(permissions))
+		for i, permission := range permissions {
+			granted[i] = string(permission)
+		}
+		fmt.Printf("%s (%s)\nRoles: %s\nPermissions: %s\n",
+			user.Name, user.ID, strings.Join(names, ", "), strings.Join(granted, ", "))
+
+	default:
+		usage()
+	}
+}
+
+// usage prints the commands and exits
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage:
+  admin grant-role <user id> [role]   grant a role, admin by default
+  admin revoke-role <user id> <role>  take a role away
+  admin roles <user id>               list the roles and permissions of a user`)
+	os.Exit(2)
+}
@@ -11,15 +11,24 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Embed timezone data for LEADERBOARD_TIMEZONE in minimal images
 
+	"game2048/internal/achievements"
 	"game2048/internal/auth"
 	"game2048/internal/cache"
 	"game2048/internal/config"
 	"game2048/internal/database"
+	"game2048/internal/events"
 	"game2048/internal/game"
 	"game2048/internal/handlers"
+	"game2048/internal/jobs"
+	"game2048/internal/render"
+	"game2048/internal/service"
+	"game2048/internal/tournaments"
 	"game2048/internal/version"
+	"game2048/internal/webhooks"
 	"game2048/internal/websocket"
+	"game2048/pkg/models"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -68,7 +77,7 @@ func main() {
 	}
 
 	// Initialize auth service
-	authService, err := auth.NewAuthService(cfg, redisCache)
+	authService, err := auth.NewAuthService(cfg, redisCache, db)
 	if err != nil {
 		log.Fatalf("Failed to initialize auth service: %v", err)
 	}
@@ -76,20 +85,85 @@ func main() {
 	// Initialize game engine
 	gameEngine := game.NewEngine()
 
+	// Initialize the event bus carrying side effects of game and user actions
+	bus := events.NewBus()
+
+	// Initialize game service shared by the WebSocket and REST APIs
+	gameService := service.NewGameService(gameEngine, db, redisCache, bus)
+	bus.Subscribe("leaderboards", events.DefaultQueueSize, gameService.HandleEvent)
+
 	// Initialize WebSocket hub
-	hub := websocket.NewHub(gameEngine, db, authService, redisCache)
+	hub := websocket.NewHub(gameService, db, authService, redisCache, cfg.PeriodCalendar())
 	go hub.Run()
 
+	// Unlock achievements from game events and push them over the WebSocket
+	achievementEngine := achievements.NewEngine(db, cfg.PeriodCalendar(), hub)
+	bus.Subscribe("achievements", events.DefaultQueueSize, achievementEngine.HandleEvent)
+
+	// Queue webhook deliveries from game and season events
+	webhookPublisher := webhooks.NewPublisher(db, cfg.PeriodCalendar())
+	bus.Subscribe("webhooks", events.DefaultQueueSize, webhookPublisher.HandleEvent)
+
+	// Start the leaderboard snapshot job
+	snapshotJob := jobs.NewLeaderboardSnapshotJob(db, cfg)
+	go snapshotJob.Run()
+
+	// Start the season close job
+	seasonJob := jobs.NewSeasonCloseJob(db, gameService, bus, cfg)
+	go seasonJob.Run()
+
+	// Start the webhook delivery job
+	webhookJob := jobs.NewWebhookDeliveryJob(db, cfg)
+	go webhookJob.Run()
+
+	// Start the refresh token cleanup job
+	refreshTokenJob := jobs.NewRefreshTokenCleanupJob(db)
+	go refreshTokenJob.Run()
+
+	// Start the guest cleanup job
+	guestJob := jobs.NewGuestCleanupJob(db, authService.GuestTokenTTL())
+	go guestJob.Run()
+
+	// Start the tournament job, notifying participants over the WebSocket
+	tournamentService := tournaments.NewService(db, gameService, hub)
+	tournamentJob := jobs.NewTournamentJob(tournamentService, cfg)
+	go tournamentJob.Run()
+
 	// Initialize version manager for static files
 	versionManager := version.NewManager("cmd/server/static")
 
+	// Load the font for player names on share cards, the built-in font only covers Latin letters
+	var nameFont *render.NameFont
+	if cfg.Server.ShareCardFont != "" {
+		nameFont, err = render.LoadNameFont(cfg.Server.ShareCardFont)
+		if err != nil {
+			log.Printf("Failed to load share card font, using the built-in font: %v", err)
+		}
+	}
+
 	// Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService, db)
-	leaderboardHandler := handlers.NewLeaderboardHandler(db, redisCache)
+	authHandler := handlers.NewAuthHandler(authService, db, bus, hub, gameService)
+	leaderboardHandler := handlers.NewLeaderboardHandler(db, redisCache, cfg.PeriodCalendar())
+	seasonHandler := handlers.NewSeasonHandler(db, redisCache, bus, cfg.Leaderboard.MaxEntries)
+	socialHandler := handlers.NewSocialHandler(db)
+	gameHandler := handlers.NewGameHandler(gameService)
+	historyHandler := handlers.NewHistoryHandler(db, cfg.PeriodCalendar())
+	profileHandler := handlers.NewProfileHandler(db, cfg.PeriodCalendar())
+	shareHandler := handlers.NewShareHandler(db, redisCache, nameFont)
+	achievementHandler := handlers.NewAchievementHandler(achievementEngine)
+	webhookHandler := handlers.NewWebhookHandler(db)
+	tournamentHandler := handlers.NewTournamentHandler(tournamentService, db)
+	roleHandler := handlers.NewRoleHandler(db)
+	moderationHandler := handlers.NewModerationHandler(authService, db, hub, gameService)
 
 	// Create Gin router
 	router := gin.Default()
 
+	// Only trust forwarded client IPs from our own proxies, rate limits key on the client IP
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// Configure CORS
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = cfg.Server.CORSOrigins
@@ -141,9 +215,15 @@ func main() {
 	authRoutes := router.Group("/auth")
 	{
 		authRoutes.GET("/login", authHandler.Login)
+		authRoutes.GET("/login/:provider", authHandler.Login)
 		authRoutes.GET("/callback", authHandler.Callback)
+		authRoutes.GET("/callback/:provider", authHandler.Callback)
+		authRoutes.POST("/guest", authHandler.Guest)
+		authRoutes.POST("/refresh", authHandler.Refresh)
 		authRoutes.POST("/logout", authHandler.Logout)
+		authRoutes.POST("/logout-all", authHandler.AuthMiddleware(), authHandler.LogoutAll)
 		authRoutes.GET("/me", authHandler.AuthMiddleware(), authHandler.Me)
+		authRoutes.POST("/link/:provider", authHandler.AuthMiddleware(), authHandler.Link)
 	}
 
 	// Public pages
@@ -153,33 +233,141 @@ func main() {
 		})
 	})
 
+	// Optional auth lets players see their own profile while it is hidden
+	router.GET("/u/:id", authHandler.OptionalAuthMiddleware(), profileHandler.ProfilePage)
+	router.GET("/share/:id", shareHandler.SharePage)
+	router.GET("/tournaments/:id", tournamentHandler.TournamentPage)
+
 	// WebSocket endpoint
 	router.GET("/ws", hub.HandleWebSocket)
 
 	// Public API routes (no authentication required)
 	publicAPI := router.Group("/api/public")
 	{
-		publicAPI.GET("/leaderboard", leaderboardHandler.GetLeaderboard)
+		// Optional auth lets signed-in users request friends and group leaderboards
+		publicAPI.GET("/leaderboard", authHandler.OptionalAuthMiddleware(), leaderboardHandler.GetLeaderboard)
+		publicAPI.GET("/leaderboard/history", leaderboardHandler.GetLeaderboardHistory)
+		publicAPI.GET("/leaderboard/history/periods", leaderboardHandler.GetLeaderboardHistoryPeriods)
+		publicAPI.GET("/seasons", seasonHandler.ListSeasons)
+		publicAPI.GET("/users/:id", authHandler.OptionalAuthMiddleware(), profileHandler.GetProfile)
+		publicAPI.GET("/games/:id/card.png", shareHandler.Card)
+		publicAPI.GET("/badge/:file", leaderboardHandler.GetBadge)
+		publicAPI.GET("/tournaments", tournamentHandler.ListTournaments)
+		publicAPI.GET("/tournaments/:id", tournamentHandler.GetTournament)
+		publicAPI.GET("/tournaments/:id/rounds/:round", tournamentHandler.GetRoundStandings)
 	}
 
-	// API routes (protected)
+	// API routes (protected), for sessions only
 	apiRoutes := router.Group("/api")
 	apiRoutes.Use(authHandler.AuthMiddleware())
 	{
-		// Admin endpoints
-		apiRoutes.GET("/admin/refresh-cache", leaderboardHandler.RefreshCache)
+		// Admin endpoints, each requiring a permission granted through the user's roles
+		requirePermission := authHandler.RequirePermission
+		apiRoutes.GET("/admin/refresh-cache", requirePermission(models.PermissionManageLeaderboards), leaderboardHandler.RefreshCache)
+		apiRoutes.POST("/admin/seasons", requirePermission(models.PermissionManageSeasons), seasonHandler.CreateSeason)
+		apiRoutes.PUT("/admin/seasons/:id", requirePermission(models.PermissionManageSeasons), seasonHandler.UpdateSeason)
+		apiRoutes.POST("/admin/seasons/:id/close", requirePermission(models.PermissionManageSeasons), seasonHandler.CloseSeason)
+		apiRoutes.GET("/admin/webhooks", requirePermission(models.PermissionManageWebhooks), webhookHandler.ListWebhooks)
+		apiRoutes.POST("/admin/webhooks", requirePermission(models.PermissionManageWebhooks), webhookHandler.CreateWebhook)
+		apiRoutes.PUT("/admin/webhooks/:id", requirePermission(models.PermissionManageWebhooks), webhookHandler.UpdateWebhook)
+		apiRoutes.DELETE("/admin/webhooks/:id", requirePermission(models.PermissionManageWebhooks), webhookHandler.DeleteWebhook)
+		apiRoutes.GET("/admin/webhooks/deliveries", requirePermission(models.PermissionManageWebhooks), webhookHandler.ListDeliveries)
+		apiRoutes.POST("/admin/webhooks/deliveries/:id/replay", requirePermission(models.PermissionManageWebhooks), webhookHandler.ReplayDelivery)
+		apiRoutes.POST("/admin/tournaments", requirePermission(models.PermissionManageTournaments), tournamentHandler.CreateTournament)
+		apiRoutes.DELETE("/admin/tournaments/:id", requirePermission(models.PermissionManageTournaments), tournamentHandler.DeleteTournament)
+		apiRoutes.GET("/admin/users/:id/identities", requirePermission(models.PermissionManageUsers), authHandler.UserIdentities)
+		apiRoutes.POST("/admin/users/:id/merge", requirePermission(models.PermissionManageUsers), authHandler.MergeUsers)
+		apiRoutes.GET("/admin/moderation", requirePermission(models.PermissionModerateUsers), moderationHandler.ListModeratedUsers)
+		apiRoutes.GET("/admin/users/:id/moderation", requirePermission(models.PermissionModerateUsers), moderationHandler.GetUserModeration)
+		apiRoutes.PUT("/admin/users/:id/moderation", requirePermission(models.PermissionModerateUsers), moderationHandler.ModerateUser)
+		apiRoutes.GET("/admin/roles", requirePermission(models.PermissionManageRoles), roleHandler.ListRoles)
+		apiRoutes.PUT("/admin/roles/:name", requirePermission(models.PermissionManageRoles), roleHandler.SaveRole)
+		apiRoutes.DELETE("/admin/roles/:name", requirePermission(models.PermissionManageRoles), roleHandler.DeleteRole)
+		apiRoutes.GET("/admin/users/:id/roles", requirePermission(models.PermissionManageRoles), roleHandler.GetUserRoles)
+		apiRoutes.PUT("/admin/users/:id/roles/:role", requirePermission(models.PermissionManageRoles), roleHandler.GrantRole)
+		apiRoutes.DELETE("/admin/users/:id/roles/:role", requirePermission(models.PermissionManageRoles), roleHandler.RevokeRole)
+
+		// Settings endpoints
+		apiRoutes.PUT("/users/me/settings", profileHandler.UpdateMySettings)
+
+		// Identity endpoints
+		apiRoutes.GET("/users/me/identities", authHandler.Identities)
+		apiRoutes.DELETE("/users/me/identities/:id", authHandler.UnlinkIdentity)
+
+		// API token endpoints, a token cannot create or revoke tokens
+		apiRoutes.GET("/users/me/tokens", authHandler.ListAPITokens)
+		apiRoutes.POST("/users/me/tokens", authHandler.CreateAPIToken)
+		apiRoutes.DELETE("/users/me/tokens/:id", authHandler.RevokeAPIToken)
+	}
 
-		// Game endpoints could be added here if needed
-		// For now, all game logic is handled via WebSocket
+	// API routes (protected), also open to API tokens with the social scope
+	socialAPI := router.Group("/api")
+	socialAPI.Use(authHandler.AuthMiddleware(models.ScopeSocial))
+	{
+		// Friends endpoints
+		socialAPI.GET("/friends", socialHandler.GetFriends)
+		socialAPI.POST("/friends/:id", socialHandler.Follow)
+		socialAPI.DELETE("/friends/:id", socialHandler.Unfollow)
+
+		// Group endpoints
+		socialAPI.GET("/groups", socialHandler.ListGroups)
+		socialAPI.POST("/groups", socialHandler.CreateGroup)
+		socialAPI.POST("/groups/join", socialHandler.JoinGroup)
+		socialAPI.GET("/groups/:id", socialHandler.GetGroup)
+		socialAPI.PUT("/groups/:id", socialHandler.UpdateGroup)
+		socialAPI.DELETE("/groups/:id", socialHandler.DeleteGroup)
+		socialAPI.POST("/groups/:id/invite-code", socialHandler.RegenerateInviteCode)
+		socialAPI.POST("/groups/:id/leave", socialHandler.LeaveGroup)
+		socialAPI.DELETE("/groups/:id/members/:userID", socialHandler.RemoveGroupMember)
 	}
 
-	// Serve the main game page
-	router.GET("/", authHandler.OptionalAuthMiddleware(), func(c *gin.Context) {
+	// API routes (protected), also open to API tokens with the tournaments scope
+	tournamentAPI := router.Group("/api")
+	tournamentAPI.Use(authHandler.AuthMiddleware(models.ScopeTournaments))
+	{
+		tournamentAPI.POST("/tournaments/:id/register", tournamentHandler.Register)
+		tournamentAPI.DELETE("/tournaments/:id/register", tournamentHandler.Unregister)
+		tournamentAPI.POST("/tournaments/:id/play", tournamentHandler.Play)
+		tournamentAPI.GET("/tournaments/:id/me", tournamentHandler.GetMyStatus)
+	}
+
+	// API routes (protected), also open to API tokens with the games:read scope
+	readAPI := router.Group("/api")
+	readAPI.Use(authHandler.AuthMiddleware(models.ScopeReadGames))
+	{
+		readAPI.GET("/leaderboard/me", leaderboardHandler.GetMyRank)
+	}
+
+	// API routes open to guests (protected), and to API tokens with the games:play scope
+	playerAPI := router.Group("/api")
+	playerAPI.Use(authHandler.PlayerMiddleware(models.ScopePlayGames))
+	{
+		// Game endpoints, mirroring the WebSocket game actions
+		playerAPI.POST("/games", gameHandler.NewGame)
+		playerAPI.GET("/games/current", gameHandler.CurrentGame)
+		playerAPI.GET("/games/:id", gameHandler.GetGame)
+		playerAPI.POST("/games/:id/moves", gameHandler.Move)
+	}
+
+	// API routes open to guests (protected), and to API tokens with the games:read scope
+	historyAPI := router.Group("/api")
+	historyAPI.Use(authHandler.PlayerMiddleware(models.ScopeReadGames))
+	{
+		// Game history endpoints
+		historyAPI.GET("/users/me/games", historyHandler.ListMyGames)
+		historyAPI.GET("/users/me/games/stats", historyHandler.GetMyStats)
+		historyAPI.GET("/users/me/achievements", achievementHandler.ListMyAchievements)
+	}
+
+	// Serve the main game page, to signed-in users and guests
+	router.GET("/", authHandler.OptionalPlayerMiddleware(), func(c *gin.Context) {
 		userID, exists := c.Get("user_id")
 		if !exists {
 			// User not authenticated, show login page
 			c.HTML(http.StatusOK, "login.html", gin.H{
-				"title": "2048 Game - Login",
+				"title":     "2048 Game - Login",
+				"providers": authService.Providers(),
+				"guestPlay": authService.GuestPlayEnabled(),
 			})
 			return
 		}
@@ -194,10 +382,18 @@ func main() {
 			return
 		}
 
-		c.HTML(http.StatusOK, "game.html", gin.H{
-			"title": "2048 Game",
-			"user":  user,
-		})
+		// Guests are offered to sign in, signed in users to link the other providers
+		data := gin.H{
+			"title":     "2048 Game",
+			"user":      user,
+			"providers": authService.Providers(),
+		}
+		if user.IsGuest {
+			// Their page passes the guest token on to the WebSocket
+			data["guestToken"], _ = c.Cookie("guest_token")
+		}
+
+		c.HTML(http.StatusOK, "game.html", data)
 	})
 
 	// Start server with graceful shutdown
@@ -223,4 +419,18 @@ func main() {
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+
+	snapshotJob.Stop()
+	seasonJob.Stop()
+	tournamentJob.Stop()
+	refreshTokenJob.Stop()
+	guestJob.Stop()
+
+	// Let subscribers handle the events still queued
+	if err := bus.Close(ctx); err != nil {
+		log.Printf("Event bus did not drain before shutdown: %v", err)
+	}
+
+	// Pending webhook deliveries stay queued in the database for the next start
+	webhookJob.Stop()
 }
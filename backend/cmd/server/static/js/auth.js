@@ -1,7 +1,9 @@
 // Authentication management
 class Auth {
     constructor() {
+        this.refreshTimer = null;
         this.setupEventListeners();
+        this.scheduleRefresh();
     }
     
     setupEventListeners() {
@@ -9,18 +11,170 @@ class Auth {
         window.logout = () => {
             this.logout();
         };
+        
+        // Handle logging out of all sessions
+        window.logoutAll = () => {
+            this.logout(true);
+        };
+        
+        // Handle linking another sign-in provider to the account
+        window.linkAccount = (provider) => {
+            this.linkAccount(provider);
+        };
+        
+        // Handle the API token panel of signed-in users
+        const tokenPanel = document.getElementById('api-tokens');
+        if (tokenPanel) {
+            tokenPanel.addEventListener('toggle', () => {
+                if (tokenPanel.open) {
+                    this.loadAPITokens();
+                }
+            });
+            document.getElementById('api-token-form').addEventListener('submit', (event) => {
+                event.preventDefault();
+                this.createAPIToken();
+            });
+        }
     }
     
-    async logout() {
+    // Headers of API requests, the stored access token is sent when the cookie is missing
+    authHeaders() {
+        const headers = {};
+        const token = localStorage.getItem('auth_token');
+        if (token) {
+            headers['Authorization'] = `Bearer ${token}`;
+        }
+        return headers;
+    }
+    
+    async loadAPITokens() {
         try {
-            // Call logout endpoint
-            const response = await fetch('/auth/logout', {
+            const response = await fetch('/api/users/me/tokens', {
+                credentials: 'include',
+                headers: this.authHeaders()
+            });
+            if (!response.ok) {
+                this.showError('Failed to load API tokens');
+                return;
+            }
+            
+            const result = await response.json();
+            const list = document.getElementById('api-token-list');
+            list.innerHTML = '';
+            for (const token of result.tokens) {
+                const item = document.createElement('li');
+                const label = document.createElement('span');
+                const expires = token.expires_at ? `expires ${new Date(token.expires_at).toLocaleDateString()}` : 'never expires';
+                const used = token.last_used_at ? `last used ${new Date(token.last_used_at).toLocaleString()}` : 'never used';
+                label.textContent = `${token.name} (${token.prefix}…) ${token.scopes.join(', ')}, ${expires}, ${used}`;
+                const revoke = document.createElement('button');
+                revoke.className = 'logout-btn';
+                revoke.textContent = 'Revoke';
+                revoke.addEventListener('click', () => this.revokeAPIToken(token.id));
+                item.append(label, revoke);
+                list.appendChild(item);
+            }
+        } catch (error) {
+            console.error('API token error:', error);
+            this.showError('Network error while loading API tokens');
+        }
+    }
+    
+    async createAPIToken() {
+        const scopes = Array.from(document.querySelectorAll('#api-token-form input[name="scope"]:checked'))
+            .map((input) => input.value);
+        const body = {
+            name: document.getElementById('api-token-name').value,
+            scopes,
+            expires_in_days: parseInt(document.getElementById('api-token-expiry').value, 10)
+        };
+        
+        try {
+            const response = await fetch('/api/users/me/tokens', {
                 method: 'POST',
-                credentials: 'include'
+                credentials: 'include',
+                headers: { ...this.authHeaders(), 'Content-Type': 'application/json' },
+                body: JSON.stringify(body)
+            });
+            const result = await response.json();
+            if (!response.ok) {
+                this.showError(result.error || 'Failed to create API token');
+                return;
+            }
+            
+            // The token value cannot be shown again
+            const created = document.getElementById('api-token-created');
+            created.textContent = `Copy your new token now: ${result.token}`;
+            created.style.display = 'block';
+            document.getElementById('api-token-name').value = '';
+            this.loadAPITokens();
+        } catch (error) {
+            console.error('API token error:', error);
+            this.showError('Network error while creating API token');
+        }
+    }
+    
+    async revokeAPIToken(id) {
+        try {
+            const response = await fetch(`/api/users/me/tokens/${encodeURIComponent(id)}`, {
+                method: 'DELETE',
+                credentials: 'include',
+                headers: this.authHeaders()
+            });
+            if (!response.ok) {
+                this.showError('Failed to revoke API token');
+                return;
+            }
+            this.loadAPITokens();
+        } catch (error) {
+            console.error('API token error:', error);
+            this.showError('Network error while revoking API token');
+        }
+    }
+    
+    // Linking goes through the provider's login, which redirects back to the game signed in as before
+    async linkAccount(provider) {
+        try {
+            const headers = {};
+            const token = localStorage.getItem('auth_token');
+            if (token) {
+                headers['Authorization'] = `Bearer ${token}`;
+            }
+            const response = await fetch(`/auth/link/${encodeURIComponent(provider)}`, {
+                method: 'POST',
+                credentials: 'include',
+                headers
+            });
+            
+            if (response.ok) {
+                const result = await response.json();
+                window.location.href = result.auth_url;
+            } else {
+                this.showError('Failed to link account');
+            }
+        } catch (error) {
+            console.error('Link error:', error);
+            this.showError('Network error while linking account');
+        }
+    }
+    
+    async logout(allSessions = false) {
+        try {
+            // Call logout endpoint, sending the stored token so it gets revoked too
+            const headers = {};
+            const token = localStorage.getItem('auth_token');
+            if (token) {
+                headers['Authorization'] = `Bearer ${token}`;
+            }
+            const response = await fetch(allSessions ? '/auth/logout-all' : '/auth/logout', {
+                method: 'POST',
+                credentials: 'include',
+                headers
             });
             
             if (response.ok) {
                 // Clear local storage
+                clearTimeout(this.refreshTimer);
                 localStorage.removeItem('auth_token');
                 
                 // Disconnect WebSocket
@@ -40,6 +194,79 @@ class Auth {
         }
     }
     
+    // Seconds until a JWT expires, read from its payload
+    tokenExpiresIn(token) {
+        try {
+            const payload = JSON.parse(atob(token.split('.')[1].replace(/-/g, '+').replace(/_/g, '/')));
+            return payload.exp - Date.now() / 1000;
+        } catch (error) {
+            return 0;
+        }
+    }
+    
+    scheduleRefresh() {
+        clearTimeout(this.refreshTimer);
+        const token = localStorage.getItem('auth_token');
+        if (!token) {
+            return;
+        }
+        
+        // Refresh a minute before the access token expires
+        const delay = Math.max((this.tokenExpiresIn(token) - 60) * 1000, 0);
+        this.refreshTimer = setTimeout(() => this.refresh(), delay);
+    }
+    
+    async refresh() {
+        // Tabs share the stored token, another tab may have refreshed it already
+        const current = localStorage.getItem('auth_token');
+        if (current && this.tokenExpiresIn(current) > 60) {
+            this.onTokenRefreshed(current);
+            return true;
+        }
+        
+        // Only one tab refreshes at a time, presenting a rotated refresh token twice logs the player out
+        const lockedUntil = parseInt(localStorage.getItem('auth_refresh_lock') || '0', 10);
+        if (lockedUntil > Date.now()) {
+            this.refreshTimer = setTimeout(() => this.refresh(), 2000);
+            return false;
+        }
+        localStorage.setItem('auth_refresh_lock', String(Date.now() + 10000));
+        
+        try {
+            // The refresh token is sent as an HTTP-only cookie
+            const response = await fetch('/auth/refresh', {
+                method: 'POST',
+                credentials: 'include'
+            });
+            
+            if (!response.ok) {
+                // The session ended, log in again
+                localStorage.removeItem('auth_token');
+                window.location.href = '/';
+                return false;
+            }
+            
+            const tokens = await response.json();
+            localStorage.setItem('auth_token', tokens.token);
+            this.onTokenRefreshed(tokens.token);
+            return true;
+        } catch (error) {
+            console.error('Token refresh error:', error);
+            this.refreshTimer = setTimeout(() => this.refresh(), 30000);
+            return false;
+        } finally {
+            localStorage.removeItem('auth_refresh_lock');
+        }
+    }
+    
+    onTokenRefreshed(token) {
+        // Renew the credentials of the open WebSocket connection
+        if (window.gameWS) {
+            window.gameWS.reauthenticate(token);
+        }
+        this.scheduleRefresh();
+    }
+    
     showError(message) {
         // Create or update error notification
         let errorDiv = document.getElementById('auth-error-notification');
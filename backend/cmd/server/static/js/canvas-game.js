@@ -196,6 +196,7 @@ class CanvasGame {
 
         this.board = newBoard;
         this.score = gameState.score;
+        this.gameId = gameState.game_id;
         this.victory = gameState.victory;
         this.gameOver = gameState.game_over;
 
@@ -687,6 +688,12 @@ class CanvasGame {
     showGameOverlay() {
         const overlay = document.getElementById('game-overlay');
         const message = document.getElementById('overlay-message');
+        const share = document.getElementById('overlay-share');
+        
+        if (share && this.gameId) {
+            share.href = `/share/${this.gameId}`;
+            share.style.display = 'inline-block';
+        }
         
         if (overlay && message) {
             if (this.victory) {
@@ -71,7 +71,7 @@ class Leaderboard {
                 </div>
                 <div class="user-info">
                     ${entry.user_avatar ? `<img src="${entry.user_avatar}" alt="${entry.user_name}" class="user-avatar">` : ''}
-                    <span class="user-name">${this.escapeHtml(entry.user_name)}</span>
+                    <a href="/u/${encodeURIComponent(entry.user_id)}" class="user-name">${this.escapeHtml(entry.user_name)}</a>
                     ${isCurrentUser ? '<span class="you-badge">You</span>' : ''}
                 </div>
                 <div class="score">
@@ -36,6 +36,29 @@ class GameWebSocket {
             }
         });
         
+        this.onMessage('achievement_unlocked', (data) => {
+            this.showAchievement(data);
+        });
+        
+        this.onMessage('tournament_round_started', (data) => {
+            this.showTournamentRound(data, true);
+        });
+        
+        this.onMessage('tournament_round_ended', (data) => {
+            this.showTournamentRound(data, false);
+        });
+        
+        this.onMessage('auth_expired', () => {
+            // Game actions are rejected until the connection is re-authenticated
+            if (window.auth) {
+                window.auth.refresh();
+            }
+        });
+        
+        this.onMessage('authenticated', (data) => {
+            console.log('WebSocket re-authenticated until', data.expires_at);
+        });
+        
         this.onMessage('error', (data) => {
             console.error('WebSocket error:', data.message);
             this.showError(data.message);
@@ -45,7 +68,7 @@ class GameWebSocket {
     connect() {
         try {
             // Get auth token
-            const token = localStorage.getItem('auth_token') || this.getCookie('auth_token');
+            const token = localStorage.getItem('auth_token') || localStorage.getItem('guest_token') || this.getCookie('auth_token');
             if (!token) {
                 console.error('No auth token found');
                 this.updateConnectionStatus('disconnected', 'Not authenticated');
@@ -104,6 +127,13 @@ class GameWebSocket {
         }
     }
     
+    reauthenticate(token) {
+        // A closed connection picks up the new token when it reconnects
+        if (this.ws && this.ws.readyState === WebSocket.OPEN) {
+            this.send('auth', { token: token });
+        }
+    }
+    
     disconnect() {
         if (this.ws) {
             this.ws.close();
@@ -183,6 +213,65 @@ class GameWebSocket {
         }, 5000);
     }
     
+    showAchievement(achievement) {
+        this.showToast(`🏅 Achievement unlocked: ${achievement.name}`, achievement.description);
+    }
+    
+    showTournamentRound(round, started) {
+        const name = `${round.tournament_name}, round ${round.round}`;
+        if (started) {
+            const end = new Date(round.end_at).toLocaleString();
+            this.showToast(`🏆 ${name} has started`, `Play your attempts before ${end}.`, `/tournaments/${round.tournament_id}`);
+            return;
+        }
+        
+        let result = round.rank ? `You finished #${round.rank}.` : 'You did not finish an attempt.';
+        if (round.advanced === true) {
+            result += ' You advance to the next round!';
+        } else if (round.advanced === false) {
+            result += ' You are out of the tournament.';
+        }
+        this.showToast(`🏆 ${name} has ended`, result, `/tournaments/${round.tournament_id}`);
+    }
+    
+    showToast(titleText, descriptionText, link) {
+        const toast = document.createElement('div');
+        toast.className = 'achievement-toast';
+        toast.style.cssText = `
+            position: fixed;
+            bottom: 20px;
+            right: 20px;
+            background: #edc22e;
+            color: #f9f6f2;
+            padding: 15px 20px;
+            border-radius: 8px;
+            box-shadow: 0 4px 12px rgba(0, 0, 0, 0.2);
+            z-index: 1000;
+            max-width: 300px;
+            font-size: 14px;
+            line-height: 1.4;
+        `;
+        
+        const title = document.createElement('strong');
+        title.textContent = titleText;
+        const description = document.createElement('div');
+        description.textContent = descriptionText;
+        toast.appendChild(title);
+        toast.appendChild(description);
+        if (link) {
+            toast.style.cursor = 'pointer';
+            toast.addEventListener('click', () => {
+                window.location.href = link;
+            });
+        }
+        document.body.appendChild(toast);
+        
+        // Auto-remove after 5 seconds
+        setTimeout(() => {
+            toast.remove();
+        }, 5000);
+    }
+    
     getCookie(name) {
         const value = `; ${document.cookie}`;
         const parts = value.split(`; ${name}=`);
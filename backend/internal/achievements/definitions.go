@@ -0,0 +1,136 @@
+package achievements
+
+import (
+	"strconv"
+
+	"game2048/pkg/models"
+)
+
+// Facts are what achievement conditions are checked against
+type Facts struct {
+	// The game after the event
+	Game models.GameState
+
+	// Directions moved in during the game, only complete if the game was followed since it started
+	Directions map[models.Direction]bool
+	Tracked    bool
+
+	// Career statistics, only loaded when a game finishes
+	GamesFinished int
+	PlayStreak    int
+}
+
+// Definition declares an achievement and the condition unlocking it
+type Definition struct {
+	models.Achievement
+
+	// Career achievements are only checked when a game finishes, with the career statistics loaded
+	Career bool
+
+	Check func(f *Facts) bool
+}
+
+// Definitions lists every achievement, in the order they are shown to players
+var Definitions = []Definition{
+	tileAchievement("tile_512", "Warming Up", 512),
+	tileAchievement("tile_1024", "Halfway There", 1024),
+	tileAchievement("tile_2048", "2048!", 2048),
+	tileAchievement("tile_4096", "Beyond 2048", 4096),
+	tileAchievement("tile_8192", "Grandmaster", 8192),
+	scoreAchievement("score_10000", "High Scorer", 10000),
+	scoreAchievement("score_50000", "Score Hunter", 50000),
+	scoreAchievement("score_100000", "Six Figures", 100000),
+	{
+		Achievement: models.Achievement{
+			ID:          "no_down_2048",
+			Name:        "Never Look Down",
+			Description: "Reach the 2048 tile without ever moving down",
+		},
+		Check: func(f *Facts) bool {
+			return f.Tracked && !f.Directions[models.DirectionDown] && maxTile(f.Game.Board) >= 2048
+		},
+	},
+	{
+		Achievement: models.Achievement{
+			ID:          "victory",
+			Name:        "Champion",
+			Description: "Win a game",
+		},
+		Check: func(f *Facts) bool {
+			return f.Game.Victory
+		},
+	},
+	{
+		Achievement: models.Achievement{
+			ID:          "victory_no_down",
+			Name:        "Upward Only",
+			Description: "Win a game without ever moving down",
+		},
+		Check: func(f *Facts) bool {
+			return f.Game.Victory && f.Tracked && !f.Directions[models.DirectionDown]
+		},
+	},
+	gamesAchievement("games_10", "Regular", 10),
+	gamesAchievement("games_100", "Dedicated", 100),
+	gamesAchievement("games_1000", "Addicted", 1000),
+	streakAchievement("streak_3", "Three in a Row", 3),
+	streakAchievement("streak_7", "Week Streak", 7),
+	streakAchievement("streak_30", "Month Streak", 30),
+}
+
+// maxStreak is the longest play streak any achievement asks for, limiting the days loaded
+const maxStreak = 30
+
+func tileAchievement(id, name string, tile int) Definition {
+	return Definition{
+		Achievement: models.Achievement{
+			ID:          id,
+			Name:        name,
+			Description: "Reach the " + strconv.Itoa(tile) + " tile",
+		},
+		Check: func(f *Facts) bool {
+			return maxTile(f.Game.Board) >= tile
+		},
+	}
+}
+
+func scoreAchievement(id, name string, score int) Definition {
+	return Definition{
+		Achievement: models.Achievement{
+			ID:          id,
+			Name:        name,
+			Description: "Score " + strconv.Itoa(score) + " points in a single game",
+		},
+		Check: func(f *Facts) bool {
+			return f.Game.Score >= score
+		},
+	}
+}
+
+func gamesAchievement(id, name string, games int) Definition {
+	return Definition{
+		Achievement: models.Achievement{
+			ID:          id,
+			Name:        name,
+			Description: "Finish " + strconv.Itoa(games) + " games",
+		},
+		Career: true,
+		Check: func(f *Facts) bool {
+			return f.GamesFinished >= games
+		},
+	}
+}
+
+func streakAchievement(id, name string, days int) Definition {
+	return Definition{
+		Achievement: models.Achievement{
+			ID:          id,
+			Name:        name,
+			Description: "Play on " + strconv.Itoa(days) + " consecutive days",
+		},
+		Career: true,
+		Check: func(f *Facts) bool {
+			return f.PlayStreak >= days
+		},
+	}
+}
@@ -0,0 +1,288 @@
+package achievements
+
+import (
+	"log"
+	"time"
+
+	"game2048/internal/database"
+	"game2048/internal/events"
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// Notifier delivers unlocked achievements to the player
+type Notifier interface {
+	NotifyAchievement(userID string, message models.AchievementUnlockedMessage)
+}
+
+// Engine unlocks achievements from game events on the event bus.
+// Unlocks are idempotent: the database records each achievement once per user
+// and the player is only notified by the unlock that stored it.
+type Engine struct {
+	db       database.Database
+	calendar models.PeriodCalendar
+	notifier Notifier
+
+	// Progress of the game each user is playing, only used by HandleEvent
+	progress map[string]*gameProgress
+}
+
+// gameProgress follows a game between its events
+type gameProgress struct {
+	gameID     uuid.UUID
+	directions map[models.Direction]bool
+
+	// Whether the game was followed since it started, otherwise directions are incomplete
+	tracked bool
+
+	// Achievements stored during the game, they are not checked again
+	attempted map[string]bool
+}
+
+// NewEngine creates a new achievement engine, the notifier is optional
+func NewEngine(db database.Database, calendar models.PeriodCalendar, notifier Notifier) *Engine {
+	return &Engine{
+		db:       db,
+		calendar: calendar,
+		notifier: notifier,
+		progress: make(map[string]*gameProgress),
+	}
+}
+
+// HandleEvent checks the achievements affected by a game event.
+// It is subscribed to the event bus, which delivers events one at a time in publishing order.
+func (e *Engine) HandleEvent(event events.Event) {
+	switch event := event.(type) {
+	case events.GameStarted:
+		e.progress[event.UserID] = newGameProgress(event.Game.ID, true)
+
+	case events.MoveApplied:
+		progress := e.progressOf(event.UserID, event.Game.ID)
+		progress.directions[event.Direction] = true
+		e.checkGame(event.UserID, event.Game, progress)
+
+	case events.GameFinished:
+		// Retry achievements of the game that failed to store on its last moves
+		if progress, ok := e.progress[event.UserID]; ok && progress.gameID == event.Game.ID {
+			e.checkGame(event.UserID, event.Game, progress)
+		}
+		delete(e.progress, event.UserID)
+		e.checkCareer(event.UserID, event.Game.ID)
+
+	case events.EventsDropped:
+		// Dropped moves may have used any direction, no game in progress is followed completely anymore
+		for _, progress := range e.progress {
+			progress.tracked = false
+		}
+	}
+}
+
+// Achievements returns every achievement with the user's unlock status
+func (e *Engine) Achievements(userID string) ([]models.AchievementStatus, error) {
+	unlocked, err := e.db.GetUserAchievements(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.UserAchievement, len(unlocked))
+	for _, achievement := range unlocked {
+		byID[achievement.AchievementID] = achievement
+	}
+
+	statuses := make([]models.AchievementStatus, 0, len(Definitions))
+	for _, definition := range Definitions {
+		status := models.AchievementStatus{Achievement: definition.Achievement}
+		if achievement, ok := byID[definition.ID]; ok {
+			status.Unlocked = true
+			status.GameID = achievement.GameID
+			unlockedAt := achievement.UnlockedAt
+			status.UnlockedAt = &unlockedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// progressOf returns the progress of the user's game, starting to follow it if needed.
+// Games picked up after they started, e.g. after a restart, are not tracked.
+func (e *Engine) progressOf(userID string, gameID uuid.UUID) *gameProgress {
+	progress, ok := e.progress[userID]
+	if !ok || progress.gameID != gameID {
+		progress = newGameProgress(gameID, false)
+		e.progress[userID] = progress
+	}
+	return progress
+}
+
+// checkGame checks the achievements of a single game and unlocks those newly met
+func (e *Engine) checkGame(userID string, game models.GameState, progress *gameProgress) {
+	facts := &Facts{
+		Game:       game,
+		Directions: make(map[models.Direction]bool, len(progress.directions)),
+		Tracked:    progress.tracked,
+	}
+	for direction := range progress.directions {
+		facts.Directions[direction] = true
+	}
+
+	var met []Definition
+	for _, definition := range Definitions {
+		if definition.Career || progress.attempted[definition.ID] {
+			continue
+		}
+		if definition.Check(facts) {
+			met = append(met, definition)
+		}
+	}
+
+	// Achievements that failed to store are checked again on the next move
+	for _, id := range e.unlock(userID, game.ID, met) {
+		progress.attempted[id] = true
+	}
+}
+
+// checkCareer loads the user's career statistics and unlocks the career achievements they meet
+func (e *Engine) checkCareer(userID string, gameID uuid.UUID) {
+	unlocked, err := e.db.GetUserAchievements(userID)
+	if err != nil {
+		log.Printf("Failed to get achievements of user %s: %v", userID, err)
+		return
+	}
+
+	done := make(map[string]bool, len(unlocked))
+	for _, achievement := range unlocked {
+		done[achievement.AchievementID] = true
+	}
+
+	pending := false
+	for _, definition := range Definitions {
+		if definition.Career && !done[definition.ID] {
+			pending = true
+			break
+		}
+	}
+	if !pending {
+		return
+	}
+
+	facts := &Facts{}
+
+	facts.GamesFinished, err = e.db.GetUserGameCount(userID, models.GameHistoryFilter{Status: models.GameStatusFinished})
+	if err != nil {
+		log.Printf("Failed to count games of user %s: %v", userID, err)
+		return
+	}
+
+	loc := e.calendar.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	dates, err := e.db.GetUserPlayDates(userID, loc, maxStreak)
+	if err != nil {
+		log.Printf("Failed to get play dates of user %s: %v", userID, err)
+		return
+	}
+	facts.PlayStreak = playStreak(dates, time.Now().In(loc))
+
+	var met []Definition
+	for _, definition := range Definitions {
+		if definition.Career && !done[definition.ID] && definition.Check(facts) {
+			met = append(met, definition)
+		}
+	}
+
+	e.unlock(userID, gameID, met)
+}
+
+// unlock stores achievements and notifies the player of those not unlocked before.
+// It returns the IDs of the achievements the user has now, whether stored now or before.
+func (e *Engine) unlock(userID string, gameID uuid.UUID, definitions []Definition) []string {
+	var unlocked []string
+	for _, definition := range definitions {
+		achievement := &models.UserAchievement{
+			UserID:        userID,
+			AchievementID: definition.ID,
+			GameID:        &gameID,
+			UnlockedAt:    time.Now(),
+		}
+
+		inserted, err := e.db.UnlockAchievement(achievement)
+		if err != nil {
+			log.Printf("Failed to unlock achievement %s for user %s: %v", definition.ID, userID, err)
+			continue
+		}
+		unlocked = append(unlocked, definition.ID)
+		if !inserted {
+			continue
+		}
+
+		log.Printf("User %s unlocked achievement %s", userID, definition.ID)
+
+		if e.notifier != nil {
+			e.notifier.NotifyAchievement(userID, models.AchievementUnlockedMessage{
+				Achievement: definition.Achievement,
+				GameID:      achievement.GameID,
+				UnlockedAt:  achievement.UnlockedAt,
+			})
+		}
+	}
+
+	return unlocked
+}
+
+func newGameProgress(gameID uuid.UUID, tracked bool) *gameProgress {
+	return &gameProgress{
+		gameID:     gameID,
+		directions: make(map[models.Direction]bool),
+		tracked:    tracked,
+		attempted:  make(map[string]bool),
+	}
+}
+
+// playStreak counts the consecutive days played up to today or yesterday.
+// Dates are calendar days, most recent first, as returned by GetUserPlayDates.
+func playStreak(dates []time.Time, now time.Time) int {
+	if len(dates) == 0 {
+		return 0
+	}
+
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	expected := dateOf(dates[0])
+	if expected.After(today) || today.Sub(expected) > 24*time.Hour {
+		// The last day played is neither today nor yesterday
+		return 0
+	}
+
+	streak := 0
+	for _, date := range dates {
+		if !dateOf(date).Equal(expected) {
+			break
+		}
+		streak++
+		expected = expected.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// dateOf returns the calendar day of a date as midnight UTC
+func dateOf(date time.Time) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// maxTile returns the highest tile on the board
+func maxTile(board models.Board) int {
+	highest := 0
+	for _, row := range board {
+		for _, tile := range row {
+			if tile > highest {
+				highest = tile
+			}
+		}
+	}
+	return highest
+}
@@ -0,0 +1,134 @@
+package achievements
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"game2048/internal/database"
+	"game2048/internal/events"
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeDB stores achievements in memory and fails the first unlocks it is told to,
+// methods the engine does not use panic through the nil embedded interface
+type fakeDB struct {
+	database.Database
+
+	failures int
+	attempts map[string]int
+	unlocked map[string]models.UserAchievement
+}
+
+func newFakeDB(failures int) *fakeDB {
+	return &fakeDB{
+		failures: failures,
+		attempts: make(map[string]int),
+		unlocked: make(map[string]models.UserAchievement),
+	}
+}
+
+func (d *fakeDB) UnlockAchievement(achievement *models.UserAchievement) (bool, error) {
+	d.attempts[achievement.AchievementID]++
+	if d.failures > 0 {
+		d.failures--
+		return false, errors.New("database unavailable")
+	}
+	if _, ok := d.unlocked[achievement.AchievementID]; ok {
+		return false, nil
+	}
+	d.unlocked[achievement.AchievementID] = *achievement
+	return true, nil
+}
+
+func (d *fakeDB) GetUserAchievements(userID string) ([]models.UserAchievement, error) {
+	var achievements []models.UserAchievement
+	for _, achievement := range d.unlocked {
+		achievements = append(achievements, achievement)
+	}
+	return achievements, nil
+}
+
+func (d *fakeDB) GetUserGameCount(userID string, filter models.GameHistoryFilter) (int, error) {
+	return 1, nil
+}
+
+func (d *fakeDB) GetUserPlayDates(userID string, loc *time.Location, limit int) ([]time.Time, error) {
+	return nil, nil
+}
+
+// moveTo publishes a move that leaves the board with a single tile
+func moveTo(e *Engine, gameID uuid.UUID, tile int) {
+	var board models.Board
+	board[0][0] = tile
+	e.HandleEvent(events.MoveApplied{
+		UserID:    "user",
+		Game:      models.GameState{ID: gameID, UserID: "user", Board: board},
+		Direction: models.DirectionLeft,
+	})
+}
+
+func TestFailedUnlockIsRetriedOnNextMove(t *testing.T) {
+	db := newFakeDB(1)
+	e := NewEngine(db, models.PeriodCalendar{}, nil)
+
+	gameID := uuid.New()
+	e.HandleEvent(events.GameStarted{UserID: "user", Game: models.GameState{ID: gameID, UserID: "user"}})
+
+	moveTo(e, gameID, 512)
+	if _, ok := db.unlocked["tile_512"]; ok {
+		t.Fatalf("achievement stored although the database failed")
+	}
+
+	moveTo(e, gameID, 512)
+	if _, ok := db.unlocked["tile_512"]; !ok {
+		t.Fatalf("failed achievement was not retried on the next move")
+	}
+
+	// Stored achievements are not checked again during the game
+	moveTo(e, gameID, 512)
+	if db.attempts["tile_512"] != 2 {
+		t.Fatalf("achievement was attempted %d times, want 2", db.attempts["tile_512"])
+	}
+}
+
+func TestFailedUnlockIsRetriedWhenGameFinishes(t *testing.T) {
+	db := newFakeDB(1)
+	e := NewEngine(db, models.PeriodCalendar{}, nil)
+
+	gameID := uuid.New()
+	e.HandleEvent(events.GameStarted{UserID: "user", Game: models.GameState{ID: gameID, UserID: "user"}})
+	moveTo(e, gameID, 512)
+
+	var board models.Board
+	board[0][0] = 512
+	e.HandleEvent(events.GameFinished{
+		UserID: "user",
+		Game:   models.GameState{ID: gameID, UserID: "user", Board: board, GameOver: true},
+	})
+
+	if _, ok := db.unlocked["tile_512"]; !ok {
+		t.Fatalf("achievement that failed on the last move was not retried when the game finished")
+	}
+}
+
+func TestDroppedEventsStopDirectionTracking(t *testing.T) {
+	db := newFakeDB(0)
+	e := NewEngine(db, models.PeriodCalendar{}, nil)
+
+	gameID := uuid.New()
+	e.HandleEvent(events.GameStarted{UserID: "user", Game: models.GameState{ID: gameID, UserID: "user"}})
+
+	// The dropped events may have included a down move
+	e.HandleEvent(events.EventsDropped{Count: 1})
+	moveTo(e, gameID, 2048)
+
+	if _, ok := db.unlocked["no_down_2048"]; ok {
+		t.Fatalf("no_down_2048 unlocked although moves of the game were dropped")
+	}
+	if _, ok := db.unlocked["tile_2048"]; !ok {
+		t.Fatalf("tile_2048 was not unlocked")
+	}
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"game2048/pkg/models"
+)
+
+// APITokenPrefix starts every personal API token, telling them apart from JWTs
+const APITokenPrefix = "g2048_"
+
+// MaxAPITokens is how many active API tokens a user can have
+const MaxAPITokens = 20
+
+const (
+	// rateLimitWindow is the window API token requests are counted in
+	rateLimitWindow = time.Minute
+	// apiTokenTouchInterval is how often the last use of an API token is written to the database
+	apiTokenTouchInterval = time.Minute
+)
+
+var (
+	// ErrInvalidAPIToken is returned for unknown, revoked and expired API tokens
+	ErrInvalidAPIToken = errors.New("invalid API token")
+	// ErrAPITokenScope is returned when an API token is used without the scope a request needs
+	ErrAPITokenScope = errors.New("API token scope not granted")
+	// ErrAPITokenRateLimited is returned when an API token made too many requests in the current window
+	ErrAPITokenRateLimited = errors.New("API token rate limit exceeded")
+	// ErrTooManyAPITokens is returned when creating a token while the user has MaxAPITokens active tokens
+	ErrTooManyAPITokens = errors.New("too many API tokens")
+)
+
+// IsAPIToken reports whether a request token is a personal API token rather than a JWT
+func IsAPIToken(value string) bool {
+	return strings.HasPrefix(value, APITokenPrefix)
+}
+
+// CreateAPIToken creates a personal API token for a user and returns it with the token value, which is not stored
+func (a *AuthService) CreateAPIToken(userID string, req *models.CreateAPITokenRequest) (*models.CreatedAPIToken, error) {
+	tokens, err := a.db.GetUserAPITokens(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	active := 0
+	for i := range tokens {
+		if tokens[i].Active(now) {
+			active++
+		}
+	}
+	if active >= MaxAPITokens {
+		return nil, ErrTooManyAPITokens
+	}
+
+	random, err := generateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API token: %w", err)
+	}
+	value := APITokenPrefix + random
+
+	token := &models.APIToken{
+		UserID:    userID,
+		Name:      req.Name,
+		TokenHash: hashRefreshToken(value),
+		Prefix:    value[:len(APITokenPrefix)+4],
+		Scopes:    req.Scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := a.db.CreateAPIToken(token); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIToken{APIToken: token, Token: value}, nil
+}
+
+// APITokens returns the API tokens of a user that were not revoked
+func (a *AuthService) APITokens(userID string) ([]models.APIToken, error) {
+	return a.db.GetUserAPITokens(userID)
+}
+
+// RevokeAPIToken revokes an API token of a user, it is rejected from the next request on
+func (a *AuthService) RevokeAPIToken(userID, tokenID string) error {
+	return a.db.RevokeAPIToken(userID, tokenID)
+}
+
+// AuthenticateAPIToken validates an API token for a request that needs one of the scopes.
+// Every successful call counts against the token's rate limit and records when the token was used.
+func (a *AuthService) AuthenticateAPIToken(value string, scopes ...models.TokenScope) (*models.APIToken, error) {
+	token, err := a.db.GetAPIToken(hashRefreshToken(value))
+	if err != nil {
+		return nil, ErrInvalidAPIToken
+	}
+
+	now := time.Now()
+	if !token.Active(now) {
+		return nil, ErrInvalidAPIToken
+	}
+	if !token.HasScope(scopes...) {
+		return nil, ErrAPITokenScope
+	}
+	if !a.AllowAPITokenRequest(token.ID.String()) {
+		return nil, ErrAPITokenRateLimited
+	}
+
+	// Writing every use would cost a database write per request, the minute is precise enough
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenTouchInterval {
+		if err := a.db.TouchAPIToken(token.ID.String(), now); err != nil {
+			log.Printf("Failed to record use of API token %s: %v", token.ID, err)
+		}
+		token.LastUsedAt = &now
+	}
+
+	return token, nil
+}
+
+// APITokenClaims returns the claims a WebSocket connection authenticated with an API token holds.
+// Tokens without an expiry are given a far one, connections are closed on revocation instead.
+func (a *AuthService) APITokenClaims(token *models.APIToken) *TokenClaims {
+	claims := &TokenClaims{
+		UserID:    token.UserID,
+		TokenID:   token.ID.String(),
+		IssuedAt:  token.CreatedAt,
+		ExpiresAt: time.Now().AddDate(100, 0, 0),
+	}
+	if token.ExpiresAt != nil {
+		claims.ExpiresAt = *token.ExpiresAt
+	}
+	return claims
+}
+
+// AllowAPITokenRequest counts a request of an API token and reports whether it is within the rate limit
+func (a *AuthService) AllowAPITokenRequest(tokenID string) bool {
+	limit := int64(a.config.Server.APITokenRateLimit)
+	if limit <= 0 {
+		return true
+	}
+
+	return a.allow("api_token:"+tokenID, limit, rateLimitWindow)
+}
+
+// APITokenRetryAfter returns how long until rate limited API tokens can make requests again
+func (a *AuthService) APITokenRetryAfter() time.Duration {
+	now := time.Now()
+	return now.Truncate(rateLimitWindow).Add(rateLimitWindow).Sub(now)
+}
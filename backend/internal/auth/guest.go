@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"game2048/pkg/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// GuestProvider is the provider of guest users, OAuth2 providers cannot use this name
+const GuestProvider = "guest"
+
+// guestRateLimitWindow is the window guest creations are counted in
+const guestRateLimitWindow = time.Hour
+
+var (
+	// ErrGuestToken is returned when a guest token is used where only signed-in users are allowed
+	ErrGuestToken = errors.New("guest token not accepted")
+	// ErrGuestPlayDisabled is returned when creating a guest while guest play is disabled
+	ErrGuestPlayDisabled = errors.New("guest play is disabled")
+	// ErrGuestRateLimited is returned when a client created too many guests in the current window
+	ErrGuestRateLimited = errors.New("guest rate limit exceeded")
+)
+
+// CreateGuest creates a guest user for a client IP and returns it with its guest token.
+// The guest exists until the token expires, unless it signs in and its games are merged into the account before.
+// Each client IP can only create a limited number of guests per hour.
+func (a *AuthService) CreateGuest(clientIP string) (*models.User, string, error) {
+	if !a.config.Server.GuestPlayEnabled {
+		return nil, "", ErrGuestPlayDisabled
+	}
+
+	if limit := int64(a.config.Server.GuestRateLimit); limit > 0 && !a.allow("guest:"+clientIP, limit, guestRateLimitWindow) {
+		return nil, "", ErrGuestRateLimited
+	}
+
+	id := uuid.New().String()
+	guest := &models.User{
+		ID:         id,
+		Name:       "Guest " + id[:8],
+		Provider:   GuestProvider,
+		ProviderID: id,
+		IsGuest:    true,
+	}
+	if err := a.db.CreateUser(guest); err != nil {
+		return nil, "", err
+	}
+
+	token, err := a.generateGuestToken(guest.ID, guest.CreatedAt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate guest token: %w", err)
+	}
+
+	return guest, token, nil
+}
+
+// generateGuestToken generates the token of a guest, it expires with the guest
+func (a *AuthService) generateGuestToken(guestID string, createdAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": guestID,
+		"jti":     uuid.New().String(),
+		"guest":   true,
+		"exp":     createdAt.Add(a.GuestTokenTTL()).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(a.config.Server.JWTSecret))
+}
+
+// ParseGuestToken validates a guest token and returns its claims.
+// Tokens of guests that were merged into an account or deleted are rejected.
+func (a *AuthService) ParseGuestToken(tokenString string) (*TokenClaims, error) {
+	claims, err := a.ParseJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.Guest {
+		return nil, fmt.Errorf("not a guest token")
+	}
+	return a.checkGuest(claims)
+}
+
+// ParsePlayerToken validates the token of a signed-in user or a guest and returns its claims
+func (a *AuthService) ParsePlayerToken(tokenString string) (*TokenClaims, error) {
+	claims, err := a.ParseJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Guest {
+		return a.checkGuest(claims)
+	}
+	return claims, nil
+}
+
+// checkGuest checks that the guest of a guest token still exists
+func (a *AuthService) checkGuest(claims *TokenClaims) (*TokenClaims, error) {
+	guest, err := a.db.GetUser(claims.UserID)
+	if err != nil || !guest.IsGuest {
+		return nil, fmt.Errorf("guest not found")
+	}
+	return claims, nil
+}
+
+// GuestPlayEnabled reports whether visitors can play as guests
+func (a *AuthService) GuestPlayEnabled() bool {
+	return a.config.Server.GuestPlayEnabled
+}
+
+// GuestRetryAfter returns how long until rate limited clients can create guests again
+func (a *AuthService) GuestRetryAfter() time.Duration {
+	now := time.Now()
+	return now.Truncate(guestRateLimitWindow).Add(guestRateLimitWindow).Sub(now)
+}
+
+// GuestTokenTTL returns how long a guest exists after it was created
+func (a *AuthService) GuestTokenTTL() time.Duration {
+	if a.config.Server.GuestTokenTTL <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(a.config.Server.GuestTokenTTL) * time.Second
+}
@@ -0,0 +1,20 @@
+package auth
+
+import "testing"
+
+func TestCreateGuestIsRateLimitedPerClientIP(t *testing.T) {
+	a := newTestAuthService(t)
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := a.CreateGuest("192.0.2.1"); err != nil {
+			t.Fatalf("CreateGuest %d: %v", i+1, err)
+		}
+	}
+
+	if _, _, err := a.CreateGuest("192.0.2.1"); err != ErrGuestRateLimited {
+		t.Fatalf("CreateGuest over the limit returned %v, want ErrGuestRateLimited", err)
+	}
+	if _, _, err := a.CreateGuest("192.0.2.2"); err != nil {
+		t.Fatalf("CreateGuest from another client IP: %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"game2048/internal/database"
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrIdentityLinked is returned when linking an identity that belongs to another user
+var ErrIdentityLinked = errors.New("identity is linked to another user")
+
+// GetLinkURL generates an authorization URL of an OAuth2 provider for linking its identity to a signed in user.
+// The callback of the login links the identity instead of signing in with it.
+func (a *AuthService) GetLinkURL(ctx context.Context, providerName, userID string) (string, error) {
+	return a.authURL(ctx, providerName, userID)
+}
+
+// SignIn returns the user an identity signs in to, creating the user on the identity's first sign in.
+// The user's profile follows its primary identity, other identities only record when they were used.
+func (a *AuthService) SignIn(info *UserInfo) (*models.User, error) {
+	identity, err := a.db.GetUserIdentity(info.Provider, info.ID)
+	if err != nil && err != database.ErrIdentityNotFound {
+		return nil, err
+	}
+
+	if identity != nil {
+		if err := a.touchIdentity(identity, info); err != nil {
+			return nil, err
+		}
+
+		user, err := a.db.GetUser(identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !identity.Primary {
+			return user, nil
+		}
+
+		user.Email = info.Email
+		user.Name = info.Name
+		user.Avatar = info.Avatar
+		if err := a.db.CreateUser(user); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+		return user, nil
+	}
+
+	// Users created before identities were stored are matched by their provider ID, which keeps their user ID
+	user := &models.User{
+		ID:         uuid.New().String(),
+		Email:      info.Email,
+		Name:       info.Name,
+		Avatar:     info.Avatar,
+		Provider:   info.Provider,
+		ProviderID: info.ID,
+	}
+	if err := a.db.CreateUser(user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if err := a.createIdentity(user.ID, info); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// LinkIdentity links an identity to a user, an identity can only belong to one user
+func (a *AuthService) LinkIdentity(userID string, info *UserInfo) error {
+	user, err := a.db.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := a.ensurePrimaryIdentity(user); err != nil {
+		return err
+	}
+
+	identity, err := a.db.GetUserIdentity(info.Provider, info.ID)
+	if err == nil {
+		if identity.UserID != userID {
+			return ErrIdentityLinked
+		}
+		return a.touchIdentity(identity, info)
+	}
+	if err != database.ErrIdentityNotFound {
+		return err
+	}
+
+	if owner, err := a.db.GetUserByProvider(info.Provider, info.ID); err == nil && owner.ID != userID {
+		return ErrIdentityLinked
+	}
+
+	return a.createIdentity(userID, info)
+}
+
+// Identities returns the identities a user can sign in with
+func (a *AuthService) Identities(userID string) ([]models.UserIdentity, error) {
+	user, err := a.db.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.ensurePrimaryIdentity(user); err != nil {
+		return nil, err
+	}
+
+	return a.db.GetUserIdentities(userID)
+}
+
+// UnlinkIdentity removes an identity from a user, a user always keeps at least one identity to sign in with
+func (a *AuthService) UnlinkIdentity(userID, identityID string) error {
+	user, err := a.db.GetUser(userID)
+	if err != nil {
+		return err
+	}
+	if err := a.ensurePrimaryIdentity(user); err != nil {
+		return err
+	}
+
+	return a.db.DeleteUserIdentity(userID, identityID)
+}
+
+// ensurePrimaryIdentity stores the identity of a user that signed up before identities were stored.
+// Migrations do this for every existing user, databases created by GORM catch up here.
+func (a *AuthService) ensurePrimaryIdentity(user *models.User) error {
+	if user.IsGuest {
+		return nil
+	}
+
+	_, err := a.db.GetUserIdentity(user.Provider, user.ProviderID)
+	if err != database.ErrIdentityNotFound {
+		return err
+	}
+
+	return a.createIdentity(user.ID, &UserInfo{
+		ID:       user.ProviderID,
+		Email:    user.Email,
+		Name:     user.Name,
+		Avatar:   user.Avatar,
+		Provider: user.Provider,
+	})
+}
+
+// createIdentity stores an identity of a user.
+// An identity stored for the same user in the meantime, by a concurrent sign in, counts as created.
+func (a *AuthService) createIdentity(userID string, info *UserInfo) error {
+	err := a.db.CreateUserIdentity(&models.UserIdentity{
+		UserID:     userID,
+		Provider:   info.Provider,
+		ProviderID: info.ID,
+		Email:      info.Email,
+		Name:       info.Name,
+		Avatar:     info.Avatar,
+	})
+	if err == nil {
+		return nil
+	}
+
+	if identity, getErr := a.db.GetUserIdentity(info.Provider, info.ID); getErr == nil {
+		if identity.UserID == userID {
+			return nil
+		}
+		return ErrIdentityLinked
+	}
+	return err
+}
+
+// touchIdentity updates the profile of an identity from its provider and records that it was used
+func (a *AuthService) touchIdentity(identity *models.UserIdentity, info *UserInfo) error {
+	identity.Email = info.Email
+	identity.Name = info.Name
+	identity.Avatar = info.Avatar
+	identity.LastUsedAt = time.Now()
+	return a.db.UpdateUserIdentity(identity)
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// How long fetched signing keys are used before they are fetched again
+	jwksCacheTTL = time.Hour
+	// Minimum time between fetches for an unknown key ID, so tokens with made up key IDs cannot flood the issuer
+	jwksMinRefreshInterval = time.Minute
+)
+
+// jsonWebKey is a key of a JSON Web Key Set
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Alg     string `json:"alg"`
+	Curve   string `json:"crv"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// signingKey is a parsed public key of an issuer
+type signingKey struct {
+	id  string
+	alg string
+	key crypto.PublicKey
+}
+
+// keySet caches the signing keys of an issuer's JWKS endpoint.
+// Keys are fetched again when they become stale or a token is signed with an unknown key, which picks up key rotation.
+type keySet struct {
+	url    string
+	client *http.Client
+
+	mutex     sync.Mutex
+	keys      []signingKey
+	fetchedAt time.Time
+}
+
+// newKeySet creates a key set of a JWKS endpoint, keys are fetched when they are first needed
+func newKeySet(url string, client *http.Client) *keySet {
+	return &keySet{
+		url:    url,
+		client: client,
+	}
+}
+
+// key returns the public key to verify a token signed with an algorithm.
+// Without a key ID, the token must be verifiable with the only key of the algorithm.
+func (k *keySet) key(ctx context.Context, kid, alg string) (interface{}, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if time.Since(k.fetchedAt) >= jwksCacheTTL {
+		if err := k.refresh(ctx); err != nil && len(k.keys) == 0 {
+			return nil, err
+		}
+	}
+
+	if key, ok := k.find(kid, alg); ok {
+		return key, nil
+	}
+
+	// The issuer may have rotated its keys since they were fetched
+	if time.Since(k.fetchedAt) >= jwksMinRefreshInterval {
+		if err := k.refresh(ctx); err != nil {
+			return nil, err
+		}
+		if key, ok := k.find(kid, alg); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no signing key found for key ID %q", kid)
+}
+
+// find looks up a key by ID that can verify the algorithm, the caller holds mutex
+func (k *keySet) find(kid, alg string) (crypto.PublicKey, bool) {
+	var match crypto.PublicKey
+	matches := 0
+	for _, key := range k.keys {
+		if (kid != "" && key.id != kid) || !key.verifies(alg) {
+			continue
+		}
+		match = key.key
+		matches++
+	}
+
+	if matches == 0 || (kid == "" && matches > 1) {
+		return nil, false
+	}
+	return match, true
+}
+
+// refresh fetches the keys of the JWKS endpoint, the caller holds mutex.
+// Keys that cannot be parsed are skipped so one unsupported key does not break the others.
+func (k *keySet) refresh(ctx context.Context) error {
+	// Also counts failed fetches, so an unreachable endpoint is not hit for every token
+	k.fetchedAt = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: %s", resp.Status)
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make([]signingKey, 0, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Printf("Skipping JWKS key %q of %s: %v", jwk.KeyID, k.url, err)
+			continue
+		}
+		keys = append(keys, signingKey{id: jwk.KeyID, alg: jwk.Alg, key: key})
+	}
+
+	k.keys = keys
+	return nil
+}
+
+// verifies checks whether the key can verify a signing algorithm
+func (s signingKey) verifies(alg string) bool {
+	if s.alg != "" && s.alg != alg {
+		return false
+	}
+
+	switch key := s.key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		switch alg {
+		case "ES256":
+			return key.Curve == elliptic.P256()
+		case "ES384":
+			return key.Curve == elliptic.P384()
+		case "ES512":
+			return key.Curve == elliptic.P521()
+		}
+		return false
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	default:
+		return false
+	}
+}
+
+// publicKey parses the public key of an RSA, EC or Ed25519 JSON Web Key
+func (j jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch j.KeyType {
+	case "RSA":
+		n, err := decodeKeyParam(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeKeyParam(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("unsupported exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Curve)
+		}
+		x, err := decodeKeyParam(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeKeyParam(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, fmt.Errorf("point is not on curve %s", j.Curve)
+		}
+		return key, nil
+
+	case "OKP":
+		if j.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Curve)
+		}
+		x, err := decodeKeyParam(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid public key size")
+		}
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.KeyType)
+	}
+}
+
+// decodeKeyParam decodes a base64url encoded key parameter
+func decodeKeyParam(value string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing")
+	}
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
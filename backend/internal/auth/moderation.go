@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"game2048/pkg/models"
+)
+
+var (
+	// ErrAccountSuspended is returned for users suspended by a moderator until their suspension ends
+	ErrAccountSuspended = errors.New("account suspended")
+	// ErrAccountBanned is returned for users banned by a moderator
+	ErrAccountBanned = errors.New("account banned")
+)
+
+// CheckAccount returns the user a token was issued to.
+// Suspended and banned users are returned with ErrAccountSuspended or ErrAccountBanned, shadow-banned users pass.
+func (a *AuthService) CheckAccount(userID string) (*models.User, error) {
+	user, err := a.db.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch user.Moderation(time.Now()) {
+	case models.ModerationSuspended:
+		return user, ErrAccountSuspended
+	case models.ModerationBanned:
+		return user, ErrAccountBanned
+	}
+	return user, nil
+}
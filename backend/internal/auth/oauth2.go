@@ -2,28 +2,30 @@ package auth
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"game2048/internal/cache"
 	"game2048/internal/config"
-	"game2048/pkg/models"
+	"game2048/internal/database"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 )
 
-// OAuth2Provider represents an OAuth2 provider
+// OAuth2Provider represents an OAuth2 provider.
+// The nonce binds an OpenID Connect ID token to the login it was requested for, other providers ignore it.
+// The options carry the PKCE challenge and verifier.
 type OAuth2Provider interface {
-	GetAuthURL(state string) string
-	ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error)
-	GetUserInfo(ctx context.Context, token *oauth2.Token) (*UserInfo, error)
+	GetAuthURL(ctx context.Context, state, nonce string, opts ...oauth2.AuthCodeOption) (string, error)
+	ExchangeCode(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error)
+	GetUserInfo(ctx context.Context, token *oauth2.Token, nonce string) (*UserInfo, error)
 }
 
 // UserInfo represents user information from OAuth2 provider
@@ -35,108 +37,225 @@ type UserInfo struct {
 	Provider string `json:"provider"`
 }
 
+// TokenClaims are the claims of a validated JWT
+type TokenClaims struct {
+	UserID    string
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// Guest tokens identify guest players, they are only accepted where guests can play
+	Guest bool
+}
+
+// ErrUnknownProvider is returned for a login with a provider that is not configured
+var ErrUnknownProvider = errors.New("unknown OAuth2 provider")
+
+// ProviderInfo describes an enabled OAuth2 provider, one login button is shown per provider
+type ProviderInfo struct {
+	Name        string
+	DisplayName string
+	Type        string
+}
+
 // AuthService handles authentication
 type AuthService struct {
-	config   *config.Config
-	provider OAuth2Provider
-	cache    cache.Cache          // Redis cache for state management
-	states   map[string]time.Time // Fallback for when Redis is not available
+	config    *config.Config
+	providers map[string]OAuth2Provider // Enabled providers by name
+	pkce      map[string]bool           // Providers that use PKCE, by name
+	infos     []ProviderInfo            // Enabled providers in configuration order
+	db        database.Database         // Refresh tokens
+	cache     cache.Cache               // Redis cache for state management
+
+	// Fallback for when Redis is not available
+	stateMutex sync.Mutex
+	states     map[string]stateEntry
+
+	// Fallback for token revocations when Redis is not available, keyed by token ID and by user ID
+	revocationMutex sync.Mutex
+	revokedTokens   map[string]time.Time
+	revokedBefore   map[string]time.Time
+
+	// Fallback for API token and guest rate limits when Redis is not available, keyed by what is limited
+	rateLimitMutex sync.Mutex
+	rateLimits     map[string]rateWindow
 }
 
-// NewAuthService creates a new authentication service
-func NewAuthService(cfg *config.Config, redisCache cache.Cache) (*AuthService, error) {
-	var provider OAuth2Provider
-	var err error
+// NewAuthService creates a new authentication service with a provider for every configured OAuth2 provider
+func NewAuthService(cfg *config.Config, redisCache cache.Cache, db database.Database) (*AuthService, error) {
+	providers := make(map[string]OAuth2Provider, len(cfg.OAuth2))
+	pkce := make(map[string]bool, len(cfg.OAuth2))
+	infos := make([]ProviderInfo, 0, len(cfg.OAuth2))
 
-	// Only support custom provider
-	provider, err = NewCustomProvider(cfg)
+	for _, providerCfg := range cfg.OAuth2 {
+		provider, err := NewProvider(providerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OAuth2 provider %s: %w", providerCfg.Name, err)
+		}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to create OAuth2 provider: %w", err)
+		providers[providerCfg.Name] = provider
+		pkce[providerCfg.Name] = providerCfg.PKCE
+		infos = append(infos, ProviderInfo{
+			Name:        providerCfg.Name,
+			DisplayName: providerCfg.DisplayName,
+			Type:        providerCfg.Type,
+		})
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no OAuth2 provider configured")
 	}
 
 	return &AuthService{
-		config:   cfg,
-		provider: provider,
-		cache:    redisCache,
-		states:   make(map[string]time.Time), // Fallback when Redis is not available
+		config:    cfg,
+		providers: providers,
+		pkce:      pkce,
+		infos:     infos,
+		db:        db,
+		cache:     redisCache,
+		states:    make(map[string]stateEntry), // Fallback when Redis is not available
+
+		revokedTokens: make(map[string]time.Time),
+		revokedBefore: make(map[string]time.Time),
+		rateLimits:    make(map[string]rateWindow),
 	}, nil
 }
 
-// GetAuthURL generates an OAuth2 authorization URL
-func (a *AuthService) GetAuthURL() (string, error) {
+// NewProvider creates the OAuth2 provider of a provider configuration
+func NewProvider(cfg config.OAuth2Config) (OAuth2Provider, error) {
+	switch cfg.Type {
+	case "custom", "linuxdo", "github":
+		return NewCustomProvider(cfg)
+	case "oidc":
+		return NewOIDCProvider(cfg)
+	default:
+		return nil, fmt.Errorf("unknown provider type %q", cfg.Type)
+	}
+}
+
+// Providers returns the enabled OAuth2 providers in configuration order
+func (a *AuthService) Providers() []ProviderInfo {
+	return a.infos
+}
+
+// DefaultProvider returns the name of the first configured provider, used by the login route without a provider
+func (a *AuthService) DefaultProvider() string {
+	return a.infos[0].Name
+}
+
+// GetAuthURL generates an authorization URL of an OAuth2 provider
+func (a *AuthService) GetAuthURL(ctx context.Context, providerName string) (string, error) {
+	return a.authURL(ctx, providerName, "")
+}
+
+// authURL generates an authorization URL of an OAuth2 provider, for linking the identity to a user if one is given
+func (a *AuthService) authURL(ctx context.Context, providerName, linkUserID string) (string, error) {
+	provider, ok := a.providers[providerName]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
 	state, err := a.generateState()
 	if err != nil {
 		return "", fmt.Errorf("failed to generate state: %w", err)
 	}
 
-	// Store state with expiration (5 minutes)
-	if a.cache != nil {
-		// Use Redis cache
-		if err := a.cache.SetOAuth2State(state, 5*time.Minute); err != nil {
-			// Fallback to in-memory storage
-			a.states[state] = time.Now().Add(5 * time.Minute)
-		}
-	} else {
-		// Fallback to in-memory storage
-		a.states[state] = time.Now().Add(5 * time.Minute)
+	nonce, err := a.generateState()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	stateData := oauth2State{Provider: providerName, Nonce: nonce, LinkUserID: linkUserID}
+	var opts []oauth2.AuthCodeOption
+	if a.pkce[providerName] {
+		stateData.CodeVerifier = oauth2.GenerateVerifier()
+		opts = append(opts, oauth2.S256ChallengeOption(stateData.CodeVerifier))
 	}
 
-	return a.provider.GetAuthURL(state), nil
+	authURL, err := provider.GetAuthURL(ctx, state, nonce, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	// Store state with expiration (5 minutes), remembering which provider, nonce and code verifier it was issued with
+	a.storeState(state, stateData, 5*time.Minute)
+
+	return authURL, nil
 }
 
-// HandleCallback handles the OAuth2 callback
-func (a *AuthService) HandleCallback(ctx context.Context, code, state string) (*models.User, string, error) {
+// CallbackResult is the identity a provider authenticated in its callback
+type CallbackResult struct {
+	Identity *UserInfo
+	// User that started linking the identity, empty for a login
+	LinkUserID string
+}
+
+// HandleCallback handles the OAuth2 callback of a provider.
+// Without a provider name, the provider is the one the state was issued for.
+func (a *AuthService) HandleCallback(ctx context.Context, providerName, code, state string) (*CallbackResult, error) {
 	// Validate state
-	if !a.validateState(state) {
-		return nil, "", fmt.Errorf("invalid state parameter")
+	stateData, ok := a.validateState(state)
+	if !ok || (providerName != "" && stateData.Provider != providerName) {
+		return nil, fmt.Errorf("invalid state parameter")
 	}
 
-	// Exchange code for token
-	token, err := a.provider.ExchangeCode(ctx, code)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to exchange code: %w", err)
+	provider, ok := a.providers[stateData.Provider]
+	if !ok {
+		return nil, ErrUnknownProvider
 	}
 
-	// Get user info
-	userInfo, err := a.provider.GetUserInfo(ctx, token)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to get user info: %w", err)
+	// Exchange code for token, proving with the code verifier that this server started the login
+	var opts []oauth2.AuthCodeOption
+	if stateData.CodeVerifier != "" {
+		opts = append(opts, oauth2.VerifierOption(stateData.CodeVerifier))
 	}
-
-	// Create user model
-	user := &models.User{
-		ID:         uuid.New().String(),
-		Email:      userInfo.Email,
-		Name:       userInfo.Name,
-		Avatar:     userInfo.Avatar,
-		Provider:   userInfo.Provider,
-		ProviderID: userInfo.ID,
+	token, err := provider.ExchangeCode(ctx, code, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
 
-	// Generate JWT token
-	jwtToken, err := a.GenerateJWT(user.ID)
+	// Get user info
+	userInfo, err := provider.GetUserInfo(ctx, token, stateData.Nonce)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to generate JWT: %w", err)
+		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
-	return user, jwtToken, nil
+	return &CallbackResult{
+		Identity:   userInfo,
+		LinkUserID: stateData.LinkUserID,
+	}, nil
 }
 
-// GenerateJWT generates a JWT token for the user
+// GenerateJWT generates a JWT token for the user.
+// Every token carries a unique ID (jti) so it can be revoked on its own.
 func (a *AuthService) GenerateJWT(userID string) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
-		"iat":     time.Now().Unix(),
+		"jti":     uuid.New().String(),
+		"exp":     now.Add(a.AccessTokenTTL()).Unix(),
+		"iat":     now.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(a.config.Server.JWTSecret))
 }
 
-// ValidateJWT validates a JWT token and returns the user ID
+// ValidateJWT validates a JWT token of a signed-in user and returns the user ID, guest tokens are rejected
 func (a *AuthService) ValidateJWT(tokenString string) (string, error) {
+	claims, err := a.ParseJWT(tokenString)
+	if err != nil {
+		return "", err
+	}
+	if claims.Guest {
+		return "", ErrGuestToken
+	}
+	return claims.UserID, nil
+}
+
+// ParseJWT validates a JWT token and returns its claims.
+// Tokens without an ID and revoked tokens are rejected.
+func (a *AuthService) ParseJWT(tokenString string) (*TokenClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -145,78 +264,158 @@ func (a *AuthService) ValidateJWT(tokenString string) (string, error) {
 	})
 
 	if err != nil {
-		return "", fmt.Errorf("failed to parse token: %w", err)
+		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if userID, ok := claims["user_id"].(string); ok {
-			return userID, nil
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	claims := &TokenClaims{}
+	if claims.UserID, ok = mapClaims["user_id"].(string); !ok {
+		return nil, fmt.Errorf("user_id not found in token")
+	}
+	if claims.TokenID, ok = mapClaims["jti"].(string); !ok || claims.TokenID == "" {
+		return nil, fmt.Errorf("jti not found in token")
+	}
+	if issuedAt, err := mapClaims.GetIssuedAt(); err == nil && issuedAt != nil {
+		claims.IssuedAt = issuedAt.Time
+	}
+	if expiresAt, err := mapClaims.GetExpirationTime(); err == nil && expiresAt != nil {
+		claims.ExpiresAt = expiresAt.Time
+	}
+	claims.Guest, _ = mapClaims["guest"].(bool)
+
+	if a.isRevoked(claims) {
+		return nil, fmt.Errorf("token has been revoked")
+	}
+
+	return claims, nil
+}
+
+// RevokeJWT revokes a token until it expires
+func (a *AuthService) RevokeJWT(claims *TokenClaims) error {
+	expiration := time.Until(claims.ExpiresAt)
+	if expiration <= 0 {
+		return nil
+	}
+
+	if a.cache != nil {
+		if err := a.cache.BlacklistJWT(claims.TokenID, expiration); err == nil {
+			return nil
 		}
-		return "", fmt.Errorf("user_id not found in token")
 	}
 
-	return "", fmt.Errorf("invalid token")
+	// Fallback to in-memory storage
+	a.revocationMutex.Lock()
+	defer a.revocationMutex.Unlock()
+	a.pruneRevocations()
+	a.revokedTokens[claims.TokenID] = claims.ExpiresAt
+	return nil
 }
 
-// generateState generates a random state string
-func (a *AuthService) generateState() (string, error) {
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
-		return "", err
+// RevokeAllJWTs revokes every access and refresh token issued to the user until now, logging out all of their sessions
+func (a *AuthService) RevokeAllJWTs(userID string) error {
+	now := time.Now()
+
+	if err := a.db.RevokeUserRefreshTokens(userID); err != nil {
+		return err
+	}
+
+	if a.cache != nil {
+		// Tokens issued before the cutoff expire within a token lifetime, the cutoff is not needed after that
+		if err := a.cache.Set(revokedBeforeKey(userID), now.Unix(), a.AccessTokenTTL()); err == nil {
+			return nil
+		}
 	}
-	return base64.URLEncoding.EncodeToString(b), nil
+
+	// Fallback to in-memory storage
+	a.revocationMutex.Lock()
+	defer a.revocationMutex.Unlock()
+	a.pruneRevocations()
+	a.revokedBefore[userID] = now
+	return nil
 }
 
-// validateState validates the state parameter
-func (a *AuthService) validateState(state string) bool {
+// isRevoked checks whether a token was revoked on its own or by logging out all of the user's sessions.
+// Issue times only have second precision, so tokens issued in the second of the cutoff are revoked as well.
+func (a *AuthService) isRevoked(claims *TokenClaims) bool {
 	// Try Redis cache first
 	if a.cache != nil {
-		if a.cache.ValidateOAuth2State(state) {
+		if a.cache.IsJWTBlacklisted(claims.TokenID) {
+			return true
+		}
+		var cutoff int64
+		if err := a.cache.Get(revokedBeforeKey(claims.UserID), &cutoff); err == nil && claims.IssuedAt.Unix() <= cutoff {
 			return true
 		}
 	}
 
 	// Fallback to in-memory storage
-	expiration, exists := a.states[state]
-	if !exists {
-		return false
+	a.revocationMutex.Lock()
+	defer a.revocationMutex.Unlock()
+	if _, ok := a.revokedTokens[claims.TokenID]; ok {
+		return true
+	}
+	if cutoff, ok := a.revokedBefore[claims.UserID]; ok && claims.IssuedAt.Unix() <= cutoff.Unix() {
+		return true
 	}
+	return false
+}
+
+// pruneRevocations removes in-memory revocations of tokens that have expired anyway, the caller holds revocationMutex
+func (a *AuthService) pruneRevocations() {
+	now := time.Now()
+	for tokenID, expiresAt := range a.revokedTokens {
+		if now.After(expiresAt) {
+			delete(a.revokedTokens, tokenID)
+		}
+	}
+	for userID, cutoff := range a.revokedBefore {
+		if now.After(cutoff.Add(a.AccessTokenTTL())) {
+			delete(a.revokedBefore, userID)
+		}
+	}
+}
 
-	// Check if state has expired
-	if time.Now().After(expiration) {
-		delete(a.states, state)
-		return false
+// AccessTokenTTL returns how long an access token stays valid after it was issued
+func (a *AuthService) AccessTokenTTL() time.Duration {
+	if a.config.Server.AccessTokenTTL <= 0 {
+		return 15 * time.Minute
 	}
+	return time.Duration(a.config.Server.AccessTokenTTL) * time.Second
+}
 
-	// Remove used state
-	delete(a.states, state)
-	return true
+// revokedBeforeKey returns the cache key of the time before which a user's tokens are revoked
+func revokedBeforeKey(userID string) string {
+	return fmt.Sprintf("jwt:revoked_before:%s", userID)
 }
 
 // CustomProvider implements OAuth2Provider for custom OAuth2 services
 type CustomProvider struct {
 	config *oauth2.Config
-	cfg    *config.Config
+	cfg    config.OAuth2Config
 }
 
 // NewCustomProvider creates a new custom OAuth2 provider
-func NewCustomProvider(cfg *config.Config) (*CustomProvider, error) {
-	if cfg.OAuth2.ClientID == "" || cfg.OAuth2.ClientSecret == "" {
+func NewCustomProvider(cfg config.OAuth2Config) (*CustomProvider, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
 		return nil, fmt.Errorf("OAuth2 client ID and secret must be configured")
 	}
 
-	if cfg.OAuth2.AuthURL == "" || cfg.OAuth2.TokenURL == "" {
+	if cfg.AuthURL == "" || cfg.TokenURL == "" {
 		return nil, fmt.Errorf("OAuth2 auth URL and token URL must be configured")
 	}
 
 	oauth2Config := &oauth2.Config{
-		ClientID:     cfg.OAuth2.ClientID,
-		ClientSecret: cfg.OAuth2.ClientSecret,
-		RedirectURL:  cfg.OAuth2.RedirectURL,
-		Scopes:       cfg.OAuth2.Scopes,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  cfg.OAuth2.AuthURL,
-			TokenURL: cfg.OAuth2.TokenURL,
+			AuthURL:  cfg.AuthURL,
+			TokenURL: cfg.TokenURL,
 		},
 	}
 
@@ -227,23 +426,24 @@ func NewCustomProvider(cfg *config.Config) (*CustomProvider, error) {
 }
 
 // GetAuthURL returns the custom OAuth2 authorization URL
-func (c *CustomProvider) GetAuthURL(state string) string {
-	return c.config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+func (c *CustomProvider) GetAuthURL(ctx context.Context, state, nonce string, opts ...oauth2.AuthCodeOption) (string, error) {
+	opts = append([]oauth2.AuthCodeOption{oauth2.AccessTypeOffline}, opts...)
+	return c.config.AuthCodeURL(state, opts...), nil
 }
 
 // ExchangeCode exchanges the authorization code for a token
-func (c *CustomProvider) ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error) {
-	return c.config.Exchange(ctx, code)
+func (c *CustomProvider) ExchangeCode(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+	return c.config.Exchange(ctx, code, opts...)
 }
 
 // GetUserInfo gets user information from custom OAuth2 provider
-func (c *CustomProvider) GetUserInfo(ctx context.Context, token *oauth2.Token) (*UserInfo, error) {
-	if c.cfg.OAuth2.UserInfoURL == "" {
+func (c *CustomProvider) GetUserInfo(ctx context.Context, token *oauth2.Token, nonce string) (*UserInfo, error) {
+	if c.cfg.UserInfoURL == "" {
 		return nil, fmt.Errorf("user info URL not configured")
 	}
 
 	client := c.config.Client(ctx, token)
-	resp, err := client.Get(c.cfg.OAuth2.UserInfoURL)
+	resp, err := client.Get(c.cfg.UserInfoURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -258,25 +458,30 @@ func (c *CustomProvider) GetUserInfo(ctx context.Context, token *oauth2.Token) (
 		return nil, fmt.Errorf("failed to decode user info: %w", err)
 	}
 
+	return mapUserInfo(c.cfg, userResponse)
+}
+
+// mapUserInfo extracts user information from a provider response based on the provider's field mappings
+func mapUserInfo(cfg config.OAuth2Config, userResponse map[string]interface{}) (*UserInfo, error) {
 	// Extract user information based on field mappings
 	userInfo := &UserInfo{
-		Provider: c.cfg.OAuth2.Provider,
+		Provider: cfg.Name,
 	}
 
 	// Extract user ID
-	if id, ok := c.extractField(userResponse, c.cfg.OAuth2.UserIDField); ok {
+	if id, ok := extractField(userResponse, cfg.UserIDField); ok {
 		userInfo.ID = fmt.Sprintf("%v", id)
 	} else {
-		return nil, fmt.Errorf("user ID field '%s' not found in response", c.cfg.OAuth2.UserIDField)
+		return nil, fmt.Errorf("user ID field '%s' not found in response", cfg.UserIDField)
 	}
 
 	// Extract email
-	if email, ok := c.extractField(userResponse, c.cfg.OAuth2.UserEmailField); ok {
+	if email, ok := extractField(userResponse, cfg.UserEmailField); ok {
 		userInfo.Email = fmt.Sprintf("%v", email)
 	}
 
 	// Extract name
-	if name, ok := c.extractField(userResponse, c.cfg.OAuth2.UserNameField); ok {
+	if name, ok := extractField(userResponse, cfg.UserNameField); ok {
 		userInfo.Name = fmt.Sprintf("%v", name)
 	} else {
 		// Fallback to email or ID if name is not available
@@ -288,7 +493,7 @@ func (c *CustomProvider) GetUserInfo(ctx context.Context, token *oauth2.Token) (
 	}
 
 	// Extract avatar
-	if avatar, ok := c.extractField(userResponse, c.cfg.OAuth2.UserAvatarField); ok {
+	if avatar, ok := extractField(userResponse, cfg.UserAvatarField); ok {
 		userInfo.Avatar = fmt.Sprintf("%v", avatar)
 	}
 
@@ -296,7 +501,7 @@ func (c *CustomProvider) GetUserInfo(ctx context.Context, token *oauth2.Token) (
 }
 
 // extractField extracts a field from the user response, supporting nested fields with dot notation
-func (c *CustomProvider) extractField(data map[string]interface{}, fieldPath string) (interface{}, bool) {
+func extractField(data map[string]interface{}, fieldPath string) (interface{}, bool) {
 	if fieldPath == "" {
 		return nil, false
 	}
@@ -307,8 +512,8 @@ func (c *CustomProvider) extractField(data map[string]interface{}, fieldPath str
 
 	for i, field := range fields {
 		if i == len(fields)-1 {
-			// Last field, return the value
-			if value, exists := current[field]; exists {
+			// Last field, return the value, null values count as missing
+			if value, exists := current[field]; exists && value != nil {
 				return value, true
 			}
 			return nil, false
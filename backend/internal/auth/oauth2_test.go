@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"game2048/internal/config"
+	"game2048/internal/database"
+	"game2048/pkg/models"
+)
+
+// fakeAuthDB creates users and revokes refresh tokens without storing anything,
+// methods the service does not use panic through the nil embedded interface
+type fakeAuthDB struct {
+	database.Database
+}
+
+func (fakeAuthDB) CreateUser(user *models.User) error {
+	user.CreatedAt = time.Now()
+	return nil
+}
+
+func (fakeAuthDB) RevokeUserRefreshTokens(userID string) error {
+	return nil
+}
+
+// newTestAuthService creates a service with a single custom provider and no cache
+func newTestAuthService(t *testing.T) *AuthService {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Server.JWTSecret = "test-secret"
+	cfg.Server.GuestPlayEnabled = true
+	cfg.Server.GuestRateLimit = 2
+	cfg.OAuth2 = []config.OAuth2Config{{
+		Name:         "test",
+		Type:         "custom",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		AuthURL:      "https://idp.example/auth",
+		TokenURL:     "https://idp.example/token",
+	}}
+
+	a, err := NewAuthService(cfg, nil, fakeAuthDB{})
+	if err != nil {
+		t.Fatalf("NewAuthService: %v", err)
+	}
+	return a
+}
+
+func TestRevokeAllJWTsRevokesTokensIssuedInTheSameSecond(t *testing.T) {
+	a := newTestAuthService(t)
+
+	token, err := a.GenerateJWT("user")
+	if err != nil {
+		t.Fatalf("GenerateJWT: %v", err)
+	}
+	if err := a.RevokeAllJWTs("user"); err != nil {
+		t.Fatalf("RevokeAllJWTs: %v", err)
+	}
+
+	if _, err := a.ValidateJWT(token); err == nil {
+		t.Fatalf("token issued right before logging out all sessions is still valid")
+	}
+}
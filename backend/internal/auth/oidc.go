@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"game2048/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// How long a discovery document is used before it is fetched again
+	discoveryCacheTTL = 24 * time.Hour
+	// Allowed clock skew when checking ID token times
+	idTokenLeeway = time.Minute
+)
+
+// idTokenSigningMethods are the ID token signing algorithms that are accepted, symmetric and unsigned tokens are not
+var idTokenSigningMethods = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+}
+
+// oidcDiscovery is the part of an OpenID Connect discovery document that is used
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider implements OAuth2Provider for OpenID Connect identity providers.
+// The endpoints are discovered from the issuer, and users are taken from the verified ID token.
+type OIDCProvider struct {
+	cfg    config.OAuth2Config
+	client *http.Client
+
+	mutex        sync.Mutex
+	discovery    *oidcDiscovery
+	discoveredAt time.Time
+	keys         *keySet
+}
+
+// NewOIDCProvider creates a new OpenID Connect provider.
+// Discovery happens on the first login so an unreachable issuer does not prevent the server from starting.
+func NewOIDCProvider(cfg config.OAuth2Config) (*OIDCProvider, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, fmt.Errorf("OAuth2 client ID and secret must be configured")
+	}
+
+	if cfg.IssuerURL == "" {
+		return nil, fmt.Errorf("OpenID Connect issuer URL must be configured")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	return &OIDCProvider{
+		cfg:    cfg,
+		client: client,
+	}, nil
+}
+
+// GetAuthURL returns the authorization URL of the issuer, requesting an ID token with the nonce
+func (o *OIDCProvider) GetAuthURL(ctx context.Context, state, nonce string, opts ...oauth2.AuthCodeOption) (string, error) {
+	oauth2Config, _, err := o.oauth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	opts = append([]oauth2.AuthCodeOption{oauth2.SetAuthURLParam("nonce", nonce)}, opts...)
+	return oauth2Config.AuthCodeURL(state, opts...), nil
+}
+
+// ExchangeCode exchanges the authorization code for a token
+func (o *OIDCProvider) ExchangeCode(ctx context.Context, code string, opts ...oauth2.AuthCodeOption) (*oauth2.Token, error) {
+	oauth2Config, _, err := o.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return oauth2Config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, o.client), code, opts...)
+}
+
+// GetUserInfo verifies the ID token of a token response and maps its claims to the user.
+// Claims missing from the ID token are taken from the userinfo endpoint when the issuer has one.
+func (o *OIDCProvider) GetUserInfo(ctx context.Context, token *oauth2.Token, nonce string) (*UserInfo, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, fmt.Errorf("no ID token in token response")
+	}
+
+	claims, err := o.VerifyIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.missingClaims(claims) {
+		if err := o.mergeUserInfo(ctx, token, claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return mapUserInfo(o.cfg, claims)
+}
+
+// VerifyIDToken verifies the signature of an ID token against the issuer's keys
+// and checks its issuer, audience, expiry and nonce, returning its claims
+func (o *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	discovery, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(idTokenSigningMethods),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(o.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(idTokenLeeway),
+	)
+
+	o.mutex.Lock()
+	keys := o.keys
+	o.mutex.Unlock()
+
+	claims := jwt.MapClaims{}
+	_, err = parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return keys.key(ctx, kid, token.Method.Alg())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	// A token issued to several audiences must name this client as the party it was issued to
+	if audience, err := claims.GetAudience(); err == nil && len(audience) > 1 {
+		if azp, _ := claims["azp"].(string); azp != o.cfg.ClientID {
+			return nil, fmt.Errorf("invalid ID token: authorized party mismatch")
+		}
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); nonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+
+	if subject, _ := claims["sub"].(string); subject == "" {
+		return nil, fmt.Errorf("invalid ID token: subject missing")
+	}
+
+	return claims, nil
+}
+
+// missingClaims checks whether a mapped email, name or avatar claim is missing from the claims
+func (o *OIDCProvider) missingClaims(claims map[string]interface{}) bool {
+	for _, field := range []string{o.cfg.UserEmailField, o.cfg.UserNameField, o.cfg.UserAvatarField} {
+		if _, ok := extractField(claims, field); field != "" && !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeUserInfo adds the claims of the userinfo endpoint that the ID token does not have.
+// The userinfo response must be about the same subject as the ID token.
+func (o *OIDCProvider) mergeUserInfo(ctx context.Context, token *oauth2.Token, claims map[string]interface{}) error {
+	oauth2Config, discovery, err := o.oauth2Config(ctx)
+	if err != nil {
+		return err
+	}
+
+	userInfoURL := o.cfg.UserInfoURL
+	if userInfoURL == "" {
+		userInfoURL = discovery.UserInfoEndpoint
+	}
+	if userInfoURL == "" {
+		return nil
+	}
+
+	client := oauth2Config.Client(context.WithValue(ctx, oauth2.HTTPClient, o.client), token)
+	resp, err := client.Get(userInfoURL)
+	if err != nil {
+		return fmt.Errorf("failed to get user info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get user info: %s", resp.Status)
+	}
+
+	var userResponse map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&userResponse); err != nil {
+		return fmt.Errorf("failed to decode user info: %w", err)
+	}
+
+	if subject, _ := userResponse["sub"].(string); subject != claims["sub"] {
+		return fmt.Errorf("user info subject does not match ID token")
+	}
+
+	for name, value := range userResponse {
+		if _, exists := claims[name]; !exists {
+			claims[name] = value
+		}
+	}
+	return nil
+}
+
+// oauth2Config returns the OAuth2 configuration with the discovered endpoints, configured endpoints take precedence
+func (o *OIDCProvider) oauth2Config(ctx context.Context) (*oauth2.Config, *oidcDiscovery, error) {
+	discovery, err := o.discover(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authURL := o.cfg.AuthURL
+	if authURL == "" {
+		authURL = discovery.AuthorizationEndpoint
+	}
+	tokenURL := o.cfg.TokenURL
+	if tokenURL == "" {
+		tokenURL = discovery.TokenEndpoint
+	}
+
+	return &oauth2.Config{
+		ClientID:     o.cfg.ClientID,
+		ClientSecret: o.cfg.ClientSecret,
+		RedirectURL:  o.cfg.RedirectURL,
+		Scopes:       o.cfg.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  authURL,
+			TokenURL: tokenURL,
+		},
+	}, discovery, nil
+}
+
+// discover returns the issuer's discovery document, fetching it when it is not cached or has become stale.
+// A stale document keeps being used while the issuer cannot be reached.
+func (o *OIDCProvider) discover(ctx context.Context) (*oidcDiscovery, error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.discovery != nil && time.Since(o.discoveredAt) < discoveryCacheTTL {
+		return o.discovery, nil
+	}
+
+	discovery, err := o.fetchDiscovery(ctx)
+	if err != nil {
+		if o.discovery != nil {
+			return o.discovery, nil
+		}
+		return nil, err
+	}
+
+	if o.keys == nil || o.keys.url != discovery.JWKSURI {
+		o.keys = newKeySet(discovery.JWKSURI, o.client)
+	}
+	o.discovery = discovery
+	o.discoveredAt = time.Now()
+	return discovery, nil
+}
+
+// fetchDiscovery fetches and checks the issuer's discovery document
+func (o *OIDCProvider) fetchDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	issuer := strings.TrimSuffix(o.cfg.IssuerURL, "/")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery request: %w", err)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch discovery document: %s", resp.Status)
+	}
+
+	var discovery oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("failed to decode discovery document: %w", err)
+	}
+
+	// The issuer must be the one that was configured, or ID tokens of another issuer would be accepted
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery document issuer %q does not match %q", discovery.Issuer, o.cfg.IssuerURL)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document is missing endpoints")
+	}
+
+	return &discovery, nil
+}
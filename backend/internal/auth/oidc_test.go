@@ -0,0 +1,316 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"game2048/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+// testIssuer is a stand-in OpenID Connect identity provider serving discovery, a JWKS and userinfo
+type testIssuer struct {
+	server *httptest.Server
+
+	mutex       sync.Mutex
+	keys        map[string]*rsa.PrivateKey
+	jwksFetches int
+	userInfo    map[string]interface{}
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+
+	issuer := &testIssuer{keys: make(map[string]*rsa.PrivateKey)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"userinfo_endpoint":      issuer.server.URL + "/userinfo",
+			"jwks_uri":               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mutex.Lock()
+		defer issuer.mutex.Unlock()
+
+		issuer.jwksFetches++
+		keys := []map[string]string{}
+		for kid, key := range issuer.keys {
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		issuer.mutex.Lock()
+		defer issuer.mutex.Unlock()
+		json.NewEncoder(w).Encode(issuer.userInfo)
+	})
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// addKey generates a signing key and publishes it in the JWKS under the key ID
+func (i *testIssuer) addKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	i.keys[kid] = key
+	return key
+}
+
+// removeKey stops publishing a key
+func (i *testIssuer) removeKey(kid string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	delete(i.keys, kid)
+}
+
+// fetches returns how often the JWKS was fetched
+func (i *testIssuer) fetches() int {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.jwksFetches
+}
+
+// claims returns valid ID token claims for the test client
+func (i *testIssuer) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   i.server.URL,
+		"aud":   "client",
+		"sub":   "subject",
+		"nonce": nonce,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"email": "player@example.com",
+		"name":  "Player",
+	}
+}
+
+// sign signs ID token claims with a key under its key ID
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("signing ID token: %v", err)
+	}
+	return signed
+}
+
+// newTestOIDCProvider creates a provider of the issuer with the OpenID Connect field mappings
+func newTestOIDCProvider(t *testing.T, issuer *testIssuer) *OIDCProvider {
+	t.Helper()
+
+	provider, err := NewOIDCProvider(config.OAuth2Config{
+		Name:            "oidc",
+		Type:            "oidc",
+		ClientID:        "client",
+		ClientSecret:    "secret",
+		IssuerURL:       issuer.server.URL,
+		UserIDField:     "sub",
+		UserEmailField:  "email",
+		UserNameField:   "name",
+		UserAvatarField: "picture",
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCProvider: %v", err)
+	}
+	return provider
+}
+
+func TestVerifyIDTokenAcceptsValidToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+	key := issuer.addKey(t, "key-1")
+	provider := newTestOIDCProvider(t, issuer)
+
+	claims, err := provider.VerifyIDToken(context.Background(), sign(t, key, "key-1", issuer.claims("nonce")), "nonce")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims["sub"] != "subject" || claims["email"] != "player@example.com" {
+		t.Fatalf("unexpected claims %v", claims)
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidClaims(t *testing.T) {
+	issuer := newTestIssuer(t)
+	key := issuer.addKey(t, "key-1")
+	provider := newTestOIDCProvider(t, issuer)
+
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+		nonce  string
+		want   string
+	}{
+		{
+			name:   "wrong issuer",
+			modify: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example" },
+			nonce:  "nonce",
+			want:   "issuer",
+		},
+		{
+			name:   "wrong audience",
+			modify: func(claims jwt.MapClaims) { claims["aud"] = "other-client" },
+			nonce:  "nonce",
+			want:   "audience",
+		},
+		{
+			name:   "wrong nonce",
+			modify: func(claims jwt.MapClaims) {},
+			nonce:  "other-nonce",
+			want:   "nonce",
+		},
+		{
+			name:   "missing nonce",
+			modify: func(claims jwt.MapClaims) { delete(claims, "nonce") },
+			nonce:  "nonce",
+			want:   "nonce",
+		},
+		{
+			name: "expired",
+			modify: func(claims jwt.MapClaims) {
+				claims["iat"] = time.Now().Add(-2 * time.Hour).Unix()
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			},
+			nonce: "nonce",
+			want:  "expired",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims := issuer.claims("nonce")
+			test.modify(claims)
+
+			_, err := provider.VerifyIDToken(context.Background(), sign(t, key, "key-1", claims), test.nonce)
+			if err == nil {
+				t.Fatalf("VerifyIDToken accepted a token with %s", test.name)
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("VerifyIDToken returned %q, want an error about the %s", err, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRefreshesKeysAfterRotation(t *testing.T) {
+	issuer := newTestIssuer(t)
+	oldKey := issuer.addKey(t, "key-1")
+	provider := newTestOIDCProvider(t, issuer)
+
+	if _, err := provider.VerifyIDToken(context.Background(), sign(t, oldKey, "key-1", issuer.claims("nonce")), "nonce"); err != nil {
+		t.Fatalf("VerifyIDToken with the first key: %v", err)
+	}
+	if fetches := issuer.fetches(); fetches != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", fetches)
+	}
+
+	newKey := issuer.addKey(t, "key-2")
+	issuer.removeKey("key-1")
+	rotated := sign(t, newKey, "key-2", issuer.claims("nonce"))
+
+	// Unknown key IDs right after a fetch do not hit the issuer again
+	if _, err := provider.VerifyIDToken(context.Background(), rotated, "nonce"); err == nil {
+		t.Fatalf("VerifyIDToken found a key that was not fetched yet")
+	}
+	if fetches := issuer.fetches(); fetches != 1 {
+		t.Fatalf("JWKS fetched %d times within the minimum refresh interval, want 1", fetches)
+	}
+
+	provider.keys.mutex.Lock()
+	provider.keys.fetchedAt = time.Now().Add(-jwksMinRefreshInterval)
+	provider.keys.mutex.Unlock()
+
+	if _, err := provider.VerifyIDToken(context.Background(), rotated, "nonce"); err != nil {
+		t.Fatalf("VerifyIDToken with the rotated key: %v", err)
+	}
+	if fetches := issuer.fetches(); fetches != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", fetches)
+	}
+
+	if _, err := provider.VerifyIDToken(context.Background(), sign(t, oldKey, "key-1", issuer.claims("nonce")), "nonce"); err == nil {
+		t.Fatalf("VerifyIDToken accepted a token signed with a retired key")
+	}
+}
+
+func TestGetUserInfoMergesUserInfoOfSameSubject(t *testing.T) {
+	issuer := newTestIssuer(t)
+	key := issuer.addKey(t, "key-1")
+	provider := newTestOIDCProvider(t, issuer)
+
+	claims := issuer.claims("nonce")
+	delete(claims, "email")
+	issuer.userInfo = map[string]interface{}{"sub": "subject", "email": "player@example.com", "picture": "https://example.com/avatar.png"}
+
+	token := (&oauth2.Token{AccessToken: "access-token"}).WithExtra(map[string]interface{}{
+		"id_token": sign(t, key, "key-1", claims),
+	})
+
+	userInfo, err := provider.GetUserInfo(context.Background(), token, "nonce")
+	if err != nil {
+		t.Fatalf("GetUserInfo: %v", err)
+	}
+	if userInfo.ID != "subject" || userInfo.Email != "player@example.com" || userInfo.Name != "Player" ||
+		userInfo.Avatar != "https://example.com/avatar.png" {
+		t.Fatalf("unexpected user info %+v", userInfo)
+	}
+}
+
+func TestGetUserInfoRejectsUserInfoOfOtherSubject(t *testing.T) {
+	issuer := newTestIssuer(t)
+	key := issuer.addKey(t, "key-1")
+	provider := newTestOIDCProvider(t, issuer)
+
+	claims := issuer.claims("nonce")
+	delete(claims, "email")
+	issuer.userInfo = map[string]interface{}{"sub": "someone-else", "email": "other@example.com"}
+
+	token := (&oauth2.Token{AccessToken: "access-token"}).WithExtra(map[string]interface{}{
+		"id_token": sign(t, key, "key-1", claims),
+	})
+
+	if _, err := provider.GetUserInfo(context.Background(), token, "nonce"); err == nil ||
+		!strings.Contains(err.Error(), "subject does not match") {
+		t.Fatalf("GetUserInfo returned %v, want a subject mismatch", err)
+	}
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+)
+
+// rateWindow counts the requests of a rate limit key in a window when Redis is not available
+type rateWindow struct {
+	start time.Time
+	end   time.Time
+	count int64
+}
+
+// allow counts a request under a rate limit key and reports whether the key made at most limit requests
+// in the current window. Windows are aligned to multiples of their length.
+func (a *AuthService) allow(key string, limit int64, window time.Duration) bool {
+	now := time.Now()
+	start := now.Truncate(window)
+
+	if a.cache != nil {
+		cacheKey := fmt.Sprintf("ratelimit:%s:%d", key, start.Unix())
+		if count, err := a.cache.Increment(cacheKey, window); err == nil {
+			return count <= limit
+		}
+	}
+
+	// Fallback to in-memory storage
+	a.rateLimitMutex.Lock()
+	defer a.rateLimitMutex.Unlock()
+	for k, w := range a.rateLimits {
+		if !now.Before(w.end) {
+			delete(a.rateLimits, k)
+		}
+	}
+	w := a.rateLimits[key]
+	if !w.start.Equal(start) {
+		w = rateWindow{start: start, end: start.Add(window)}
+	}
+	w.count++
+	a.rateLimits[key] = w
+	return w.count <= limit
+}
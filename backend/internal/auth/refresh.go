@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+// IssueTokens issues an access token and a refresh token starting a new refresh token family, on login
+func (a *AuthService) IssueTokens(userID string) (*models.AuthTokens, error) {
+	return a.issueTokens(userID, uuid.New(), nil)
+}
+
+// Refresh exchanges a refresh token for a new access token and rotates the refresh token.
+// Presenting a refresh token that was already rotated revokes every token of its family,
+// as either the player or an attacker holds a stolen copy.
+func (a *AuthService) Refresh(refreshToken string) (*models.AuthTokens, error) {
+	stored, err := a.db.GetRefreshToken(hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RotatedAt != nil {
+		a.revokeFamily(stored)
+		return nil, ErrRefreshTokenReused
+	}
+
+	return a.issueTokens(stored.UserID, stored.FamilyID, stored)
+}
+
+// RevokeRefreshToken revokes the family of a refresh token on logout, unknown tokens are ignored
+func (a *AuthService) RevokeRefreshToken(refreshToken string) error {
+	stored, err := a.db.GetRefreshToken(hashRefreshToken(refreshToken))
+	if err != nil {
+		return nil
+	}
+
+	return a.db.RevokeRefreshTokenFamily(stored.FamilyID.String())
+}
+
+// RefreshTokenTTL returns how long a refresh token stays valid after it was issued
+func (a *AuthService) RefreshTokenTTL() time.Duration {
+	if a.config.Server.RefreshTokenTTL <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(a.config.Server.RefreshTokenTTL) * time.Second
+}
+
+// issueTokens issues an access token and a refresh token of the given family, rotating the previous refresh token if any
+func (a *AuthService) issueTokens(userID string, familyID uuid.UUID, previous *models.RefreshToken) (*models.AuthTokens, error) {
+	value, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	next := &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(value),
+		ExpiresAt: time.Now().Add(a.RefreshTokenTTL()),
+	}
+
+	if previous == nil {
+		if err := a.db.CreateRefreshToken(next); err != nil {
+			return nil, err
+		}
+	} else {
+		rotated, err := a.db.RotateRefreshToken(previous.ID.String(), next)
+		if err != nil {
+			return nil, err
+		}
+		// Another request rotated the token first, so it was presented twice
+		if !rotated {
+			a.revokeFamily(previous)
+			return nil, ErrRefreshTokenReused
+		}
+	}
+
+	accessToken, err := a.GenerateJWT(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthTokens{
+		AccessToken:      accessToken,
+		RefreshToken:     value,
+		ExpiresIn:        int(a.AccessTokenTTL().Seconds()),
+		RefreshExpiresIn: int(a.RefreshTokenTTL().Seconds()),
+	}, nil
+}
+
+// revokeFamily revokes the family of a reused refresh token
+func (a *AuthService) revokeFamily(token *models.RefreshToken) {
+	log.Printf("Refresh token reuse detected for user %s, revoking token family %s", token.UserID, token.FamilyID)
+	if err := a.db.RevokeRefreshTokenFamily(token.FamilyID.String()); err != nil {
+		log.Printf("Failed to revoke refresh token family %s: %v", token.FamilyID, err)
+	}
+}
+
+// generateRefreshToken generates a random refresh token value
+func generateRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashRefreshToken returns the stored form of a refresh token
+func hashRefreshToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
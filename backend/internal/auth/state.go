@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// oauth2State is stored with an OAuth2 state parameter until the provider redirects back
+type oauth2State struct {
+	// Name of the provider the login was started with
+	Provider string `json:"provider"`
+	// Nonce the provider has to put in its ID token
+	Nonce string `json:"nonce,omitempty"`
+	// PKCE code verifier sent with the code exchange, empty when the provider does not use PKCE
+	CodeVerifier string `json:"code_verifier,omitempty"`
+	// User the authenticated identity is linked to, empty for a login
+	LinkUserID string `json:"link_user_id,omitempty"`
+}
+
+// stateEntry is an OAuth2 state stored in memory when Redis is not available
+type stateEntry struct {
+	data      oauth2State
+	expiresAt time.Time
+}
+
+// generateState generates a random state string
+func (a *AuthService) generateState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// storeState stores a state parameter with its data until it expires
+func (a *AuthService) storeState(state string, data oauth2State, expiration time.Duration) {
+	if a.cache != nil {
+		// Use Redis cache
+		if encoded, err := json.Marshal(data); err == nil {
+			if err := a.cache.SetOAuth2State(state, string(encoded), expiration); err == nil {
+				return
+			}
+		}
+	}
+
+	// Fallback to in-memory storage
+	a.stateMutex.Lock()
+	defer a.stateMutex.Unlock()
+	for key, entry := range a.states {
+		if time.Now().After(entry.expiresAt) {
+			delete(a.states, key)
+		}
+	}
+	a.states[state] = stateEntry{data: data, expiresAt: time.Now().Add(expiration)}
+}
+
+// validateState validates and consumes the state parameter, returning the data stored with it
+func (a *AuthService) validateState(state string) (oauth2State, bool) {
+	// Try Redis cache first
+	if a.cache != nil {
+		if encoded, ok := a.cache.ValidateOAuth2State(state); ok {
+			var data oauth2State
+			if err := json.Unmarshal([]byte(encoded), &data); err != nil {
+				return oauth2State{}, false
+			}
+			return data, true
+		}
+	}
+
+	// Fallback to in-memory storage
+	a.stateMutex.Lock()
+	defer a.stateMutex.Unlock()
+	entry, exists := a.states[state]
+	if !exists {
+		return oauth2State{}, false
+	}
+
+	// Remove used state
+	delete(a.states, state)
+
+	// Check if state has expired
+	if time.Now().After(entry.expiresAt) {
+		return oauth2State{}, false
+	}
+
+	return entry.data, true
+}
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -27,14 +29,22 @@ type Cache interface {
 	DeleteSession(key string) error
 
 	// OAuth2 state management
-	SetOAuth2State(state string, expiration time.Duration) error
-	ValidateOAuth2State(state string) bool
+	SetOAuth2State(state, data string, expiration time.Duration) error
+	ValidateOAuth2State(state string) (string, bool)
 
 	// Leaderboard caching
-	SetLeaderboard(leaderboardType models.LeaderboardType, entries []models.LeaderboardEntry, expiration time.Duration) error
-	GetLeaderboard(leaderboardType models.LeaderboardType) ([]models.LeaderboardEntry, error)
+	SetLeaderboard(query models.LeaderboardQuery, entries []models.LeaderboardEntry, expiration time.Duration) error
+	GetLeaderboard(query models.LeaderboardQuery) ([]models.LeaderboardEntry, error)
+	SetLeaderboardTotal(query models.LeaderboardQuery, total int, expiration time.Duration) error
+	GetLeaderboardTotal(query models.LeaderboardQuery) (int, error)
+	SetUserRank(query models.LeaderboardQuery, userID string, entry *models.LeaderboardEntry, expiration time.Duration) error
+	GetUserRank(query models.LeaderboardQuery, userID string) (*models.LeaderboardEntry, error)
 	InvalidateLeaderboard(leaderboardType models.LeaderboardType) error
 
+	// Share card caching
+	SetShareCard(gameID, playerName string, card []byte, expiration time.Duration) error
+	GetShareCard(gameID, playerName string) ([]byte, error)
+
 	// Game session caching
 	SetGameSession(userID string, game *models.GameState, expiration time.Duration) error
 	GetGameSession(userID string) (*models.GameState, error)
@@ -49,6 +59,7 @@ type Cache interface {
 	Get(key string, dest interface{}) error
 	Delete(key string) error
 	Exists(key string) bool
+	Increment(key string, expiration time.Duration) (int64, error)
 	Close() error
 }
 
@@ -118,6 +129,22 @@ func (r *RedisCache) Exists(key string) bool {
 	return result > 0
 }
 
+// Increment increments a counter and returns its new value, a new counter expires after the expiration
+func (r *RedisCache) Increment(key string, expiration time.Duration) (int64, error) {
+	count, err := r.client.Incr(r.ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment counter: %w", err)
+	}
+
+	if count == 1 {
+		if err := r.client.Expire(r.ctx, key, expiration).Err(); err != nil {
+			return 0, fmt.Errorf("failed to set counter expiration: %w", err)
+		}
+	}
+
+	return count, nil
+}
+
 // SetSession stores a session value
 func (r *RedisCache) SetSession(key string, value interface{}, expiration time.Duration) error {
 	sessionKey := fmt.Sprintf("session:%s", key)
@@ -136,52 +163,113 @@ func (r *RedisCache) DeleteSession(key string) error {
 	return r.Delete(sessionKey)
 }
 
-// SetOAuth2State stores an OAuth2 state
-func (r *RedisCache) SetOAuth2State(state string, expiration time.Duration) error {
+// SetOAuth2State stores an OAuth2 state with the data needed to complete the login
+func (r *RedisCache) SetOAuth2State(state, data string, expiration time.Duration) error {
 	stateKey := fmt.Sprintf("oauth2:state:%s", state)
-	return r.client.Set(r.ctx, stateKey, "valid", expiration).Err()
+	return r.client.Set(r.ctx, stateKey, data, expiration).Err()
 }
 
-// ValidateOAuth2State validates and removes an OAuth2 state
-func (r *RedisCache) ValidateOAuth2State(state string) bool {
+// ValidateOAuth2State validates and removes an OAuth2 state, returning its data
+func (r *RedisCache) ValidateOAuth2State(state string) (string, bool) {
 	stateKey := fmt.Sprintf("oauth2:state:%s", state)
 
-	// Use a Lua script to atomically check and delete
+	// Use a Lua script to atomically get and delete
 	script := `
-		if redis.call("exists", KEYS[1]) == 1 then
+		local data = redis.call("get", KEYS[1])
+		if data then
 			redis.call("del", KEYS[1])
-			return 1
-		else
-			return 0
 		end
+		return data
 	`
 
 	result, err := r.client.Eval(r.ctx, script, []string{stateKey}).Result()
 	if err != nil {
-		return false
+		return "", false
 	}
 
-	return result.(int64) == 1
+	data, ok := result.(string)
+	return data, ok
 }
 
 // SetLeaderboard caches leaderboard entries
-func (r *RedisCache) SetLeaderboard(leaderboardType models.LeaderboardType, entries []models.LeaderboardEntry, expiration time.Duration) error {
-	leaderboardKey := fmt.Sprintf("leaderboard:%s", string(leaderboardType))
+func (r *RedisCache) SetLeaderboard(query models.LeaderboardQuery, entries []models.LeaderboardEntry, expiration time.Duration) error {
+	leaderboardKey := fmt.Sprintf("leaderboard:%s", query.CacheKey())
 	return r.Set(leaderboardKey, entries, expiration)
 }
 
 // GetLeaderboard retrieves cached leaderboard entries
-func (r *RedisCache) GetLeaderboard(leaderboardType models.LeaderboardType) ([]models.LeaderboardEntry, error) {
-	leaderboardKey := fmt.Sprintf("leaderboard:%s", string(leaderboardType))
+func (r *RedisCache) GetLeaderboard(query models.LeaderboardQuery) ([]models.LeaderboardEntry, error) {
+	leaderboardKey := fmt.Sprintf("leaderboard:%s", query.CacheKey())
 	var entries []models.LeaderboardEntry
 	err := r.Get(leaderboardKey, &entries)
 	return entries, err
 }
 
-// InvalidateLeaderboard removes cached leaderboard
+// SetLeaderboardTotal caches the number of ranked users on a leaderboard
+func (r *RedisCache) SetLeaderboardTotal(query models.LeaderboardQuery, total int, expiration time.Duration) error {
+	totalKey := fmt.Sprintf("leaderboard:%s:total", query.CacheKey())
+	return r.Set(totalKey, total, expiration)
+}
+
+// GetLeaderboardTotal retrieves the cached number of ranked users on a leaderboard
+func (r *RedisCache) GetLeaderboardTotal(query models.LeaderboardQuery) (int, error) {
+	totalKey := fmt.Sprintf("leaderboard:%s:total", query.CacheKey())
+	var total int
+	err := r.Get(totalKey, &total)
+	return total, err
+}
+
+// SetUserRank caches a user's leaderboard entry; a nil entry records that the user is not ranked
+func (r *RedisCache) SetUserRank(query models.LeaderboardQuery, userID string, entry *models.LeaderboardEntry, expiration time.Duration) error {
+	rankKey := fmt.Sprintf("leaderboard:%s:rank:%s", query.CacheKey(), userID)
+	return r.Set(rankKey, entry, expiration)
+}
+
+// GetUserRank retrieves a cached leaderboard entry of a user, which is nil if the user is not ranked
+func (r *RedisCache) GetUserRank(query models.LeaderboardQuery, userID string) (*models.LeaderboardEntry, error) {
+	rankKey := fmt.Sprintf("leaderboard:%s:rank:%s", query.CacheKey(), userID)
+	var entry *models.LeaderboardEntry
+	err := r.Get(rankKey, &entry)
+	return entry, err
+}
+
+// InvalidateLeaderboard removes every cached period of a leaderboard type
 func (r *RedisCache) InvalidateLeaderboard(leaderboardType models.LeaderboardType) error {
-	leaderboardKey := fmt.Sprintf("leaderboard:%s", string(leaderboardType))
-	return r.Delete(leaderboardKey)
+	keys := []string{fmt.Sprintf("leaderboard:%s", string(leaderboardType))}
+
+	pattern := fmt.Sprintf("leaderboard:%s:*", string(leaderboardType))
+	iter := r.client.Scan(r.ctx, 0, pattern, 100).Iterator()
+	for iter.Next(r.ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan leaderboard keys: %w", err)
+	}
+
+	return r.client.Del(r.ctx, keys...).Err()
+}
+
+// SetShareCard caches the encoded share card of a game as drawn with a player name
+func (r *RedisCache) SetShareCard(gameID, playerName string, card []byte, expiration time.Duration) error {
+	return r.client.Set(r.ctx, shareCardKey(gameID, playerName), card, expiration).Err()
+}
+
+// GetShareCard retrieves a cached share card of a game as drawn with a player name
+func (r *RedisCache) GetShareCard(gameID, playerName string) ([]byte, error) {
+	card, err := r.client.Get(r.ctx, shareCardKey(gameID, playerName)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, fmt.Errorf("key not found")
+		}
+		return nil, fmt.Errorf("failed to get share card: %w", err)
+	}
+	return card, nil
+}
+
+// shareCardKey keys share cards by game and a hash of the player name, renaming draws a new card
+func shareCardKey(gameID, playerName string) string {
+	sum := sha256.Sum256([]byte(playerName))
+	return fmt.Sprintf("share:card:%s:%s", gameID, hex.EncodeToString(sum[:8]))
 }
 
 // SetGameSession caches a game session
@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"game2048/pkg/models"
 
 	"github.com/joho/godotenv"
 )
@@ -15,9 +19,11 @@ type Config struct {
 	Server      ServerConfig
 	Database    DatabaseConfig
 	Redis       RedisConfig
-	OAuth2      OAuth2Config
+	OAuth2      []OAuth2Config
 	Game        GameConfig
 	Leaderboard LeaderboardConfig
+	Webhook     WebhookConfig
+	Tournament  TournamentConfig
 }
 
 // ServerConfig holds server-related configuration
@@ -25,6 +31,14 @@ type ServerConfig struct {
 	Host                string
 	Port                string
 	JWTSecret           string
+	AccessTokenTTL      int // Lifetime of access tokens, in seconds
+	RefreshTokenTTL     int // Lifetime of refresh tokens since their last rotation, in seconds
+	GuestPlayEnabled    bool
+	GuestTokenTTL       int      // Lifetime of guest tokens, unclaimed guests are deleted after it, in seconds
+	GuestRateLimit      int      // Guests a client IP can create per hour, 0 disables the limit
+	TrustedProxies      []string // Proxies whose X-Forwarded-For and X-Real-IP headers give the client IP, none by default
+	APITokenRateLimit   int      // Requests per minute allowed per personal API token, 0 disables the limit
+	ShareCardFont       string   // TrueType or OpenType font for player names on share cards, empty uses the built-in font
 	GinMode             string
 	StaticFilesEmbedded bool
 	EnableMetrics       bool
@@ -52,14 +66,25 @@ type RedisConfig struct {
 	DB       int
 }
 
-// OAuth2Config holds OAuth2-related configuration
+// OAuth2Config holds the configuration of one OAuth2 provider
 type OAuth2Config struct {
-	Provider     string
+	// Name used in the login and callback routes, e.g. /auth/login/github
+	Name string
+	// Label of the provider's login button
+	DisplayName string
+	// Preset the endpoint and field mapping defaults come from: custom, linuxdo, github or oidc
+	Type string
+
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// Use PKCE (S256) in the authorization code flow, some providers require it even for confidential clients
+	PKCE bool
+
+	// OpenID Connect issuer, the endpoints are discovered from its .well-known/openid-configuration
+	IssuerURL string
 
-	// Custom OAuth2 endpoints
+	// Custom OAuth2 endpoints, for OpenID Connect providers they override the discovered ones
 	AuthURL     string
 	TokenURL    string
 	UserInfoURL string
@@ -83,6 +108,37 @@ type GameConfig struct {
 type LeaderboardConfig struct {
 	CacheTTL   int
 	MaxEntries int
+
+	// Period boundaries for daily, weekly and monthly leaderboards
+	Timezone  *time.Location
+	WeekStart time.Weekday
+
+	// Snapshot job settings, in seconds
+	SnapshotInterval int
+	SnapshotDelay    int
+}
+
+// WebhookConfig holds outbound webhook delivery configuration
+type WebhookConfig struct {
+	// How often due deliveries are sent, in seconds
+	PollInterval int
+
+	// Webhooks sent to at once, deliveries to the same webhook are sent one at a time
+	Workers int
+
+	// Attempts before a delivery is dead-lettered, and the delay before the first retry in seconds.
+	// The delay doubles with every further attempt.
+	MaxAttempts    int
+	RetryBaseDelay int
+
+	// Request timeout, in seconds
+	Timeout int
+}
+
+// TournamentConfig holds tournament-related configuration
+type TournamentConfig struct {
+	// How often rounds are started and ended, in seconds
+	Interval int
 }
 
 // Load loads configuration from environment variables
@@ -107,11 +163,29 @@ func Load() (*Config, error) {
 		log.Println("No .env file found, using environment variables and defaults")
 	}
 
+	timezone, err := time.LoadLocation(getEnv("LEADERBOARD_TIMEZONE", "UTC"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LEADERBOARD_TIMEZONE: %w", err)
+	}
+
+	weekStart, err := parseWeekday(getEnv("LEADERBOARD_WEEK_START", "monday"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LEADERBOARD_WEEK_START: %w", err)
+	}
+
 	config := &Config{
 		Server: ServerConfig{
 			Host:                getEnv("SERVER_HOST", "0.0.0.0"),
 			Port:                getEnv("SERVER_PORT", "6060"),
 			JWTSecret:           getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
+			AccessTokenTTL:      getEnvInt("ACCESS_TOKEN_TTL", 900),
+			RefreshTokenTTL:     getEnvInt("REFRESH_TOKEN_TTL", 2592000),
+			GuestPlayEnabled:    getEnvBool("GUEST_PLAY_ENABLED", true),
+			GuestTokenTTL:       getEnvInt("GUEST_TOKEN_TTL", 2592000),
+			GuestRateLimit:      getEnvInt("GUEST_RATE_LIMIT", 20),
+			TrustedProxies:      getEnvSlice("TRUSTED_PROXIES", nil),
+			APITokenRateLimit:   getEnvInt("API_TOKEN_RATE_LIMIT", 600),
+			ShareCardFont:       getEnv("SHARE_CARD_FONT", ""),
 			GinMode:             getEnv("GIN_MODE", "release"),
 			StaticFilesEmbedded: getEnvBool("STATIC_FILES_EMBEDDED", true),
 			EnableMetrics:       getEnvBool("ENABLE_METRICS", true),
@@ -134,24 +208,7 @@ func Load() (*Config, error) {
 			Password: getEnv("REDIS_PASSWORD", ""),
 			DB:       getEnvInt("REDIS_DB", 0),
 		},
-		OAuth2: OAuth2Config{
-			Provider:     getEnv("OAUTH2_PROVIDER", "custom"),
-			ClientID:     getEnv("OAUTH2_CLIENT_ID", ""),
-			ClientSecret: getEnv("OAUTH2_CLIENT_SECRET", ""),
-			RedirectURL:  getEnv("OAUTH2_REDIRECT_URL", "http://localhost:6060/auth/callback"),
-
-			// Custom OAuth2 endpoints
-			AuthURL:     getEnv("OAUTH2_AUTH_URL", ""),
-			TokenURL:    getEnv("OAUTH2_TOKEN_URL", ""),
-			UserInfoURL: getEnv("OAUTH2_USERINFO_URL", ""),
-			Scopes:      getEnvSlice("OAUTH2_SCOPES", []string{"openid", "profile", "email"}),
-
-			// User info field mappings
-			UserIDField:     getEnv("OAUTH2_USER_ID_FIELD", "id"),
-			UserEmailField:  getEnv("OAUTH2_USER_EMAIL_FIELD", "email"),
-			UserNameField:   getEnv("OAUTH2_USER_NAME_FIELD", "name"),
-			UserAvatarField: getEnv("OAUTH2_USER_AVATAR_FIELD", "avatar"),
-		},
+		OAuth2: loadOAuth2Providers(),
 		Game: GameConfig{
 			VictoryTile:        getEnvInt("VICTORY_TILE", 16384), // Two 8192 tiles merged
 			MaxConcurrentGames: getEnvInt("MAX_CONCURRENT_GAMES", 1000),
@@ -160,6 +217,22 @@ func Load() (*Config, error) {
 		Leaderboard: LeaderboardConfig{
 			CacheTTL:   getEnvInt("LEADERBOARD_CACHE_TTL", 300),
 			MaxEntries: getEnvInt("MAX_LEADERBOARD_ENTRIES", 100),
+
+			Timezone:  timezone,
+			WeekStart: weekStart,
+
+			SnapshotInterval: getEnvInt("LEADERBOARD_SNAPSHOT_INTERVAL", 600),
+			SnapshotDelay:    getEnvInt("LEADERBOARD_SNAPSHOT_DELAY", 300),
+		},
+		Webhook: WebhookConfig{
+			PollInterval:   getEnvInt("WEBHOOK_POLL_INTERVAL", 5),
+			Workers:        getEnvInt("WEBHOOK_WORKERS", 4),
+			MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBaseDelay: getEnvInt("WEBHOOK_RETRY_BASE_DELAY", 30),
+			Timeout:        getEnvInt("WEBHOOK_TIMEOUT", 10),
+		},
+		Tournament: TournamentConfig{
+			Interval: getEnvInt("TOURNAMENT_INTERVAL", 60),
 		},
 	}
 
@@ -177,8 +250,31 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("JWT_SECRET must be set to a secure value")
 	}
 
-	if c.OAuth2.ClientID == "" || c.OAuth2.ClientSecret == "" {
-		return fmt.Errorf("OAuth2 client ID and secret must be set")
+	if len(c.OAuth2) == 0 {
+		return fmt.Errorf("at least one OAuth2 provider must be configured")
+	}
+	names := make(map[string]bool, len(c.OAuth2))
+	for _, provider := range c.OAuth2 {
+		if !validProviderName.MatchString(provider.Name) {
+			return fmt.Errorf("OAuth2 provider name %q must only contain lowercase letters, digits, - and _", provider.Name)
+		}
+		if provider.Name == "guest" {
+			return fmt.Errorf("OAuth2 provider name %q is reserved for guest players", provider.Name)
+		}
+		if names[provider.Name] {
+			return fmt.Errorf("OAuth2 provider %q is configured twice", provider.Name)
+		}
+		names[provider.Name] = true
+
+		if _, ok := oauth2Presets[provider.Type]; !ok {
+			return fmt.Errorf("OAuth2 provider %q has unknown type %q", provider.Name, provider.Type)
+		}
+		if provider.ClientID == "" || provider.ClientSecret == "" {
+			return fmt.Errorf("OAuth2 client ID and secret must be set for provider %q", provider.Name)
+		}
+		if provider.Type == "oidc" && provider.IssuerURL == "" {
+			return fmt.Errorf("OpenID Connect issuer URL must be set for provider %q", provider.Name)
+		}
 	}
 
 	if c.Database.Host == "" || c.Database.Name == "" || c.Database.User == "" {
@@ -209,11 +305,116 @@ func (c *Config) GetRedisURL() string {
 		c.Redis.Host, c.Redis.Port, c.Redis.DB)
 }
 
+// PeriodCalendar returns the calendar used for leaderboard period boundaries
+func (c *Config) PeriodCalendar() models.PeriodCalendar {
+	return models.PeriodCalendar{
+		Location:  c.Leaderboard.Timezone,
+		WeekStart: c.Leaderboard.WeekStart,
+	}
+}
+
 // GetServerAddress returns the server address
 func (c *Config) GetServerAddress() string {
 	return fmt.Sprintf("%s:%s", c.Server.Host, c.Server.Port)
 }
 
+// validProviderName matches OAuth2 provider names usable in routes and environment variable names
+var validProviderName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// oauth2Presets are the endpoint and field mapping defaults of each OAuth2 provider type.
+// Any of them can be overridden with the provider's environment variables.
+var oauth2Presets = map[string]OAuth2Config{
+	"custom": {
+		Scopes:          []string{"openid", "profile", "email"},
+		UserIDField:     "id",
+		UserEmailField:  "email",
+		UserNameField:   "name",
+		UserAvatarField: "avatar",
+	},
+	"linuxdo": {
+		DisplayName:     "LINUX DO",
+		AuthURL:         "https://connect.linux.do/oauth2/authorize",
+		TokenURL:        "https://connect.linux.do/oauth2/token",
+		UserInfoURL:     "https://connect.linux.do/api/user",
+		Scopes:          []string{"openid", "profile", "email"},
+		UserIDField:     "id",
+		UserEmailField:  "email",
+		UserNameField:   "username",
+		UserAvatarField: "avatar_url",
+	},
+	"github": {
+		DisplayName:     "GitHub",
+		AuthURL:         "https://github.com/login/oauth/authorize",
+		TokenURL:        "https://github.com/login/oauth/access_token",
+		UserInfoURL:     "https://api.github.com/user",
+		Scopes:          []string{"read:user", "user:email"},
+		UserIDField:     "id",
+		UserEmailField:  "email",
+		UserNameField:   "login",
+		UserAvatarField: "avatar_url",
+	},
+	"oidc": {
+		DisplayName:     "OpenID Connect",
+		Scopes:          []string{"openid", "profile", "email"},
+		UserIDField:     "sub",
+		UserEmailField:  "email",
+		UserNameField:   "name",
+		UserAvatarField: "picture",
+	},
+}
+
+// loadOAuth2Providers reads the providers listed in OAUTH2_PROVIDERS, each configured with OAUTH2_<NAME>_* variables.
+// The type of a provider defaults to its name. Without a list, a single custom provider is configured
+// from the OAUTH2_* variables and named after OAUTH2_PROVIDER.
+func loadOAuth2Providers() []OAuth2Config {
+	names := getEnvSlice("OAUTH2_PROVIDERS", nil)
+	if len(names) == 0 {
+		name := getEnv("OAUTH2_PROVIDER", "custom")
+		return []OAuth2Config{loadOAuth2Provider(name, "custom", "OAUTH2_", "http://localhost:6060/auth/callback")}
+	}
+
+	providers := make([]OAuth2Config, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		prefix := "OAUTH2_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providerType := getEnv(prefix+"TYPE", name)
+		providers = append(providers, loadOAuth2Provider(name, providerType, prefix, "http://localhost:6060/auth/callback/"+name))
+	}
+	return providers
+}
+
+// loadOAuth2Provider reads one provider's variables, falling back to the defaults of its type
+func loadOAuth2Provider(name, providerType, prefix, redirectURL string) OAuth2Config {
+	preset := oauth2Presets[providerType]
+	displayName := preset.DisplayName
+	if displayName == "" {
+		displayName = name
+	}
+
+	return OAuth2Config{
+		Name:         name,
+		DisplayName:  getEnv(prefix+"DISPLAY_NAME", displayName),
+		Type:         providerType,
+		ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+		ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+		RedirectURL:  getEnv(prefix+"REDIRECT_URL", redirectURL),
+		PKCE:         getEnvBool(prefix+"PKCE", false),
+
+		// Provider endpoints
+		IssuerURL:   getEnv(prefix+"ISSUER_URL", preset.IssuerURL),
+		AuthURL:     getEnv(prefix+"AUTH_URL", preset.AuthURL),
+		TokenURL:    getEnv(prefix+"TOKEN_URL", preset.TokenURL),
+		UserInfoURL: getEnv(prefix+"USERINFO_URL", preset.UserInfoURL),
+		Scopes:      getEnvSlice(prefix+"SCOPES", preset.Scopes),
+
+		// User info field mappings
+		UserIDField:     getEnv(prefix+"USER_ID_FIELD", preset.UserIDField),
+		UserEmailField:  getEnv(prefix+"USER_EMAIL_FIELD", preset.UserEmailField),
+		UserNameField:   getEnv(prefix+"USER_NAME_FIELD", preset.UserNameField),
+		UserAvatarField: getEnv(prefix+"USER_AVATAR_FIELD", preset.UserAvatarField),
+	}
+}
+
 // Helper functions for environment variable parsing
 
 func getEnv(key, defaultValue string) string {
@@ -247,3 +448,12 @@ func getEnvSlice(key string, defaultValue []string) []string {
 	}
 	return defaultValue
 }
+
+func parseWeekday(value string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(value, day.String()) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("unknown weekday %q", value)
+}
@@ -1,14 +1,18 @@
 package database
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"game2048/pkg/models"
 
+	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -71,15 +75,40 @@ func NewGormDB(host, port, user, password, dbname, sslmode string) (*GormDB, err
 	return gormDB, nil
 }
 
-// AutoMigrate runs database migrations
+// AutoMigrate runs database migrations and creates the built-in roles
 func (g *GormDB) AutoMigrate() error {
-	return g.db.AutoMigrate(
+	err := g.db.AutoMigrate(
 		&models.GormUser{},
 		&models.GormGame{},
 		&models.GormDailyLeaderboard{},
 		&models.GormWeeklyLeaderboard{},
 		&models.GormMonthlyLeaderboard{},
+		&models.GormSeason{},
+		&models.GormSeasonStanding{},
+		&models.GormUserBadge{},
+		&models.GormUserAchievement{},
+		&models.GormFollow{},
+		&models.GormGroup{},
+		&models.GormGroupMember{},
+		&models.GormWebhook{},
+		&models.GormWebhookDelivery{},
+		&models.GormTournament{},
+		&models.GormTournamentRound{},
+		&models.GormTournamentParticipant{},
+		&models.GormTournamentAttempt{},
+		&models.GormRefreshToken{},
+		&models.GormUserIdentity{},
+		&models.GormRole{},
+		&models.GormRolePermission{},
+		&models.GormUserRole{},
+		&models.GormAPIToken{},
+		&models.GormModerationAction{},
 	)
+	if err != nil {
+		return err
+	}
+
+	return g.seedRoles()
 }
 
 // Close closes the database connection
@@ -143,10 +172,279 @@ func (g *GormDB) GetUserByProvider(provider, providerID string) (*models.User, e
 	return gormUser.ToUser(), nil
 }
 
+// UpdateUserSettings updates the settings a user can change
+func (g *GormDB) UpdateUserSettings(userID string, settings models.UserSettings) error {
+	result := g.db.Model(&models.GormUser{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"profile_hidden": settings.ProfileHidden,
+			"updated_at":     time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user settings: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// MergeGuestUser moves a guest's games and achievements to a user and deletes the guest.
+// Achievements the user already has keep their original unlock.
+func (g *GormDB) MergeGuestUser(guestID, userID string) error {
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the guest so that concurrent merges cannot both move its games
+		var guest models.GormUser
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_guest = ?", guestID, true).
+			First(&guest)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.Model(&models.GormGame{}).Where("user_id = ?", guestID).Update("user_id", userID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Exec(`
+			INSERT INTO user_achievements (user_id, achievement_id, game_id, unlocked_at)
+			SELECT ?, achievement_id, game_id, unlocked_at FROM user_achievements WHERE user_id = ?
+			ON CONFLICT (user_id, achievement_id) DO NOTHING`, userID, guestID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", guestID).Delete(&models.GormUserAchievement{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", guestID).Delete(&models.GormUser{}).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("guest not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to merge guest: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredGuests deletes guests created before the given time together with their games and returns how many were deleted
+func (g *GormDB) DeleteExpiredGuests(createdBefore time.Time) (int64, error) {
+	var deleted int64
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&models.GormUser{}).Select("id").Where("is_guest = ? AND created_at < ?", true, createdBefore)
+
+		if err := tx.Where("user_id IN (?)", expired).Delete(&models.GormGame{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id IN (?)", expired).Delete(&models.GormUserAchievement{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("is_guest = ? AND created_at < ?", true, createdBefore).Delete(&models.GormUser{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired guests: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// MergeUsers moves the games, identities and everything else of a source user to a target user and deletes the source
+func (g *GormDB) MergeUsers(sourceID, targetID string) error {
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		// Lock both users in a fixed order so that concurrent merges cannot deadlock
+		var users []models.GormUser
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []string{sourceID, targetID}).
+			Order("id").
+			Find(&users)
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(users) != 2 {
+			return gorm.ErrRecordNotFound
+		}
+
+		source, target := users[0].ToUser(), users[1].ToUser()
+		if source.ID != sourceID {
+			source, target = target, source
+		}
+
+		for _, statement := range mergeUserStatements(source, target, time.Now()) {
+			if err := tx.Exec(statement.query, statement.args...).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to merge users: %w", err)
+	}
+
+	return nil
+}
+
+// CreateUserIdentity links a provider identity to a user
+func (g *GormDB) CreateUserIdentity(identity *models.UserIdentity) error {
+	if identity.ID == uuid.Nil {
+		identity.ID = uuid.New()
+	}
+	if identity.LastUsedAt.IsZero() {
+		identity.LastUsedAt = time.Now()
+	}
+
+	gormIdentity := &models.GormUserIdentity{}
+	gormIdentity.FromUserIdentity(identity)
+
+	result := g.db.Create(gormIdentity)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create user identity: %w", result.Error)
+	}
+
+	identity.CreatedAt = gormIdentity.CreatedAt
+	return nil
+}
+
+// UpdateUserIdentity updates the profile of an identity and when it was last used
+func (g *GormDB) UpdateUserIdentity(identity *models.UserIdentity) error {
+	result := g.db.Model(&models.GormUserIdentity{}).
+		Where("id = ?", identity.ID).
+		Updates(map[string]interface{}{
+			"email":        identity.Email,
+			"name":         identity.Name,
+			"avatar":       identity.Avatar,
+			"last_used_at": identity.LastUsedAt,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user identity: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrIdentityNotFound
+	}
+
+	return nil
+}
+
+// GetUserIdentity retrieves an identity by provider and provider ID
+func (g *GormDB) GetUserIdentity(provider, providerID string) (*models.UserIdentity, error) {
+	var gormIdentity models.GormUserIdentity
+	result := g.db.Where("provider = ? AND provider_id = ?", provider, providerID).First(&gormIdentity)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, fmt.Errorf("failed to get user identity: %w", result.Error)
+	}
+
+	identity := gormIdentity.ToUserIdentity()
+	user, err := g.GetUser(identity.UserID)
+	if err != nil {
+		return nil, err
+	}
+	identity.Primary = user.Provider == identity.Provider && user.ProviderID == identity.ProviderID
+
+	return identity, nil
+}
+
+// GetUserIdentities retrieves the identities of a user, oldest first
+func (g *GormDB) GetUserIdentities(userID string) ([]models.UserIdentity, error) {
+	user, err := g.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var gormIdentities []models.GormUserIdentity
+	result := g.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&gormIdentities)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get user identities: %w", result.Error)
+	}
+
+	identities := make([]models.UserIdentity, len(gormIdentities))
+	for i, gi := range gormIdentities {
+		identities[i] = *gi.ToUserIdentity()
+		identities[i].Primary = user.Provider == gi.Provider && user.ProviderID == gi.ProviderID
+	}
+
+	return identities, nil
+}
+
+// DeleteUserIdentity unlinks an identity from a user, the last identity of a user cannot be unlinked.
+// When the primary identity is unlinked, the most recently used remaining identity becomes primary.
+func (g *GormDB) DeleteUserIdentity(userID, identityID string) error {
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the user so that concurrent unlinks cannot remove every identity
+		var user models.GormUser
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userID).First(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var identity models.GormUserIdentity
+		if err := tx.Where("id = ? AND user_id = ?", identityID, userID).First(&identity).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.GormUserIdentity{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count <= 1 {
+			return ErrLastIdentity
+		}
+
+		if err := tx.Delete(&identity).Error; err != nil {
+			return err
+		}
+
+		if user.Provider != identity.Provider || user.ProviderID != identity.ProviderID {
+			return nil
+		}
+
+		var next models.GormUserIdentity
+		if err := tx.Where("user_id = ?", userID).Order("last_used_at DESC").First(&next).Error; err != nil {
+			return err
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"provider":    next.Provider,
+			"provider_id": next.ProviderID,
+			"email":       next.Email,
+			"name":        next.Name,
+			"avatar":      next.Avatar,
+			"updated_at":  time.Now(),
+		}).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		return ErrIdentityNotFound
+	}
+	if err == ErrLastIdentity {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete user identity: %w", err)
+	}
+
+	return nil
+}
+
 // CreateGame creates a new game
 func (g *GormDB) CreateGame(game *models.GameState) error {
 	gormGame := &models.GormGame{}
 	gormGame.FromGameState(game)
+	if gormGame.Mode == "" {
+		gormGame.Mode = models.GameModeClassic
+	}
 
 	result := g.db.Create(gormGame)
 	if result.Error != nil {
@@ -199,6 +497,20 @@ func (g *GormDB) GetGame(gameID, userID string) (*models.GameState, error) {
 	return gormGame.ToGameState(), nil
 }
 
+// GetFinishedGame retrieves a finished game of any user, for public sharing
+func (g *GormDB) GetFinishedGame(gameID string) (*models.GameState, error) {
+	var gormGame models.GormGame
+	result := g.db.Where("id = ? AND (game_over = ? OR victory = ?)", gameID, true, true).First(&gormGame)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("game not found")
+		}
+		return nil, fmt.Errorf("failed to get game: %w", result.Error)
+	}
+
+	return gormGame.ToGameState(), nil
+}
+
 // GetUserActiveGame retrieves the user's active (non-finished) game
 func (g *GormDB) GetUserActiveGame(userID string) (*models.GameState, error) {
 	var gormGame models.GormGame
@@ -216,46 +528,144 @@ func (g *GormDB) GetUserActiveGame(userID string) (*models.GameState, error) {
 	return gormGame.ToGameState(), nil
 }
 
-// GetLeaderboard retrieves leaderboard entries
-func (g *GormDB) GetLeaderboard(leaderboardType models.LeaderboardType, limit int) ([]models.LeaderboardEntry, error) {
-	var entries []models.GormLeaderboardEntry
+// GetUserGames retrieves a page of a user's games matching the filter, most recent first
+func (g *GormDB) GetUserGames(userID string, filter models.GameHistoryFilter, limit, offset int) ([]models.GameSummary, error) {
+	now := time.Now()
+	conditions, args := gameHistoryConditions(userID, filter, now)
+
+	games := []models.GameSummary{}
+	result := g.db.Table("games").
+		Select("id, mode, score, "+bestTileExpr+" as best_tile, game_over, victory, created_at, updated_at").
+		Where(conditions, args...).
+		Order("created_at DESC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&games)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get user games: %w", result.Error)
+	}
+
+	for i := range games {
+		games[i].Status = models.GameStatusOf(games[i].GameOver, games[i].Victory, games[i].UpdatedAt, now)
+	}
+
+	return games, nil
+}
+
+// GetUserGameCount returns the number of a user's games matching the filter
+func (g *GormDB) GetUserGameCount(userID string, filter models.GameHistoryFilter) (int, error) {
+	conditions, args := gameHistoryConditions(userID, filter, time.Now())
+
+	var total int64
+	if err := g.db.Table("games").Where(conditions, args...).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count user games: %w", err)
+	}
+
+	return int(total), nil
+}
+
+// GetUserGameStats computes statistics over a user's games matching the filter.
+// The score trend is grouped by day in the given timezone.
+func (g *GormDB) GetUserGameStats(userID string, filter models.GameHistoryFilter, loc *time.Location) (*models.GameStats, error) {
+	conditions, args := gameHistoryConditions(userID, filter, time.Now())
+
+	stats := &models.GameStats{}
+	row := g.db.Raw(gameStatsQuery(conditions), args...).Row()
+	if err := row.Scan(&stats.GamesPlayed, &stats.Wins, &stats.AverageScore, &stats.MedianScore, &stats.BestScore); err != nil {
+		return nil, fmt.Errorf("failed to get game stats: %w", err)
+	}
+	if stats.GamesPlayed > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.GamesPlayed)
+	}
+
+	stats.BestTiles = []models.TileCount{}
+	if err := g.db.Raw(bestTilesQuery(conditions), args...).Scan(&stats.BestTiles).Error; err != nil {
+		return nil, fmt.Errorf("failed to get best tile distribution: %w", err)
+	}
+
+	stats.ScoreTrend = []models.ScoreTrendPoint{}
+	trendArgs := append([]interface{}{loc.String()}, args...)
+	if err := g.db.Raw(scoreTrendQuery(conditions), trendArgs...).Scan(&stats.ScoreTrend).Error; err != nil {
+		return nil, fmt.Errorf("failed to get score trend: %w", err)
+	}
+
+	return stats, nil
+}
+
+// rankedLeaderboard builds a query returning each user's best finished game together with its rank
+func (g *GormDB) rankedLeaderboard(query models.LeaderboardQuery) *gorm.DB {
+	finished := g.finishedGames()
+
+	// Period bounds are computed in the leaderboard timezone, not the database session timezone
+	if query.Period != nil {
+		finished = finished.Where("created_at >= ? AND created_at < ?", query.Period.Start, query.Period.End)
+	}
+
+	// Friends and group leaderboards rank only their own users
+	if query.FriendsOf != "" {
+		finished = finished.Where("user_id = ? OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = ?)",
+			query.FriendsOf, query.FriendsOf)
+	}
+	if query.GroupID != "" {
+		finished = finished.Where("user_id IN (SELECT user_id FROM group_members WHERE group_id = ?)", query.GroupID)
+	}
 
-	// Build subquery to get max score per user
-	subquery := g.db.Table("games").
-		Select("user_id, MAX(score) as max_score").
-		Where("game_over = ? OR victory = ?", true, true)
-
-	switch leaderboardType {
-	case models.LeaderboardDaily:
-		subquery = subquery.Where("created_at >= CURRENT_DATE")
-	case models.LeaderboardWeekly:
-		subquery = subquery.Where("created_at >= DATE_TRUNC('week', CURRENT_DATE)")
-	case models.LeaderboardMonthly:
-		subquery = subquery.Where("created_at >= DATE_TRUNC('month', CURRENT_DATE)")
-	case models.LeaderboardAll:
-		// No additional filter for all-time leaderboard
-	default:
-		return nil, fmt.Errorf("invalid leaderboard type")
-	}
-
-	subquery = subquery.Group("user_id")
-
-	// Main query to get full game details for the max score games
-	query := g.db.Table("games g").
-		Select("g.user_id, u.name as user_name, u.avatar as user_avatar, g.score, g.id as game_id, g.created_at, ROW_NUMBER() OVER (ORDER BY g.score DESC) as rank").
-		Joins("JOIN users u ON g.user_id = u.id").
-		Joins("JOIN (?) max_scores ON g.user_id = max_scores.user_id AND g.score = max_scores.max_score", subquery).
-		Where("g.game_over = ? OR g.victory = ?", true, true).
-		Order("g.score DESC").
-		Limit(limit)
-
-	result := query.Scan(&entries)
+	// Tournament attempts are played on fixed seeds and only ranked on their round
+	if query.TournamentID != "" {
+		finished = finished.Where("id IN (SELECT game_id FROM tournament_attempts WHERE tournament_id = ? AND round = ?)",
+			query.TournamentID, query.TournamentRound)
+	} else {
+		finished = finished.Where("mode <> ?", models.GameModeTournament)
+	}
+
+	return g.rankBestScores(finished, query.Viewer)
+}
+
+// finishedGames builds the base query over all finished games
+func (g *GormDB) finishedGames() *gorm.DB {
+	return g.db.Table("games").Where("game_over = ? OR victory = ?", true, true)
+}
+
+// rankBestScores picks each user's best game from the given games and ranks users by it.
+// Hidden users are left out, except a shadow-banned viewer.
+func (g *GormDB) rankBestScores(games *gorm.DB, viewer string) *gorm.DB {
+	// Pick one game per user: the highest score, earliest game wins ties
+	best := games.
+		Select("user_id, MAX(score) as score, " +
+			"(ARRAY_AGG(id ORDER BY score DESC, created_at ASC))[1] as game_id, " +
+			"(ARRAY_AGG(created_at ORDER BY score DESC, created_at ASC))[1] as created_at").
+		Group("user_id")
+
+	// Rank over the whole board so that paging and per-user lookups agree on positions
+	ranked := g.db.Table("(?) b", best).
+		Select("b.user_id, u.name as user_name, u.avatar as user_avatar, b.score, b.game_id, b.created_at, "+
+			"ROW_NUMBER() OVER (ORDER BY b.score DESC, b.created_at ASC, b.user_id ASC) as rank").
+		Joins("JOIN users u ON b.user_id = u.id").
+		Where("u.is_guest = ?", false)
+
+	if viewer == "" {
+		return ranked.Where("u.moderation_status NOT IN ?", hiddenModerationStatuses)
+	}
+	return ranked.Where("(u.moderation_status NOT IN ? OR (u.id = ? AND u.moderation_status = ?))",
+		hiddenModerationStatuses, viewer, models.ModerationShadowBanned)
+}
+
+// GetLeaderboard retrieves a page of leaderboard entries
+func (g *GormDB) GetLeaderboard(query models.LeaderboardQuery, limit, offset int) ([]models.LeaderboardEntry, error) {
+	ranked := g.rankedLeaderboard(query)
+
+	var entries []models.GormLeaderboardEntry
+	result := g.db.Table("(?) r", ranked).
+		Order("r.rank ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&entries)
 	if result.Error != nil {
 		return nil, fmt.Errorf("failed to query leaderboard: %w", result.Error)
 	}
 
 	// Convert to regular LeaderboardEntry
-	var leaderboardEntries []models.LeaderboardEntry
+	leaderboardEntries := make([]models.LeaderboardEntry, 0, len(entries))
 	for _, entry := range entries {
 		leaderboardEntries = append(leaderboardEntries, *entry.ToLeaderboardEntry())
 	}
@@ -263,7 +673,1701 @@ func (g *GormDB) GetLeaderboard(leaderboardType models.LeaderboardType, limit in
 	return leaderboardEntries, nil
 }
 
-// GetDB returns the underlying GORM database instance
-func (g *GormDB) GetDB() *gorm.DB {
-	return g.db
+// GetLeaderboardCount returns the number of users ranked on a leaderboard
+func (g *GormDB) GetLeaderboardCount(query models.LeaderboardQuery) (int, error) {
+	ranked := g.rankedLeaderboard(query)
+
+	var total int64
+	if err := g.db.Table("(?) r", ranked).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count leaderboard entries: %w", err)
+	}
+
+	return int(total), nil
+}
+
+// GetUserRank retrieves a user's entry on a leaderboard, or nil if the user is not ranked
+func (g *GormDB) GetUserRank(query models.LeaderboardQuery, userID string) (*models.LeaderboardEntry, error) {
+	ranked := g.rankedLeaderboard(query)
+
+	var entries []models.GormLeaderboardEntry
+	result := g.db.Table("(?) r", ranked).
+		Where("r.user_id = ?", userID).
+		Limit(1).
+		Scan(&entries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get user rank: %w", result.Error)
+	}
+
+	if len(entries) == 0 {
+		return nil, nil // User has no finished games on this leaderboard
+	}
+
+	return entries[0].ToLeaderboardEntry(), nil
+}
+
+// SnapshotLeaderboard freezes the top entries of a finished period into its snapshot table.
+// Any existing snapshot for the same period is replaced.
+func (g *GormDB) SnapshotLeaderboard(leaderboardType models.LeaderboardType, period models.LeaderboardPeriod, limit int) error {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return err
+	}
+
+	ranked := g.rankedLeaderboard(models.LeaderboardQuery{Type: leaderboardType, Period: &period})
+
+	var entries []models.GormLeaderboardEntry
+	result := g.db.Table("(?) r", ranked).
+		Order("r.rank ASC").
+		Limit(limit).
+		Scan(&entries)
+	if result.Error != nil {
+		return fmt.Errorf("failed to query leaderboard for snapshot: %w", result.Error)
+	}
+
+	periodDate := snapshotDate(period.Start)
+	now := time.Now()
+
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM "+table+" WHERE "+column+" = ?", periodDate).Error; err != nil {
+			return fmt.Errorf("failed to clear leaderboard snapshot: %w", err)
+		}
+
+		if len(entries) == 0 {
+			return nil
+		}
+
+		rows := make([]map[string]interface{}, 0, len(entries))
+		for _, entry := range entries {
+			rows = append(rows, map[string]interface{}{
+				"user_id":     entry.UserID,
+				"user_name":   entry.UserName,
+				"user_avatar": entry.UserAvatar,
+				"score":       entry.Score,
+				"rank":        entry.Rank,
+				"game_id":     entry.GameID,
+				column:        periodDate,
+				"created_at":  now,
+			})
+		}
+
+		if err := tx.Table(table).Create(rows).Error; err != nil {
+			return fmt.Errorf("failed to write leaderboard snapshot: %w", err)
+		}
+		return nil
+	})
+}
+
+// HasLeaderboardSnapshot checks whether a period has already been frozen
+func (g *GormDB) HasLeaderboardSnapshot(leaderboardType models.LeaderboardType, periodStart time.Time) (bool, error) {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return false, err
+	}
+
+	var count int64
+	result := g.db.Table(table).Where(column+" = ?", snapshotDate(periodStart)).Limit(1).Count(&count)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to check leaderboard snapshot: %w", result.Error)
+	}
+
+	return count > 0, nil
+}
+
+// GetLeaderboardSnapshot retrieves a page of a frozen leaderboard.
+// Users hidden since the snapshot was taken are left out and the others move up, an unban restores them.
+func (g *GormDB) GetLeaderboardSnapshot(leaderboardType models.LeaderboardType, periodStart time.Time, limit, offset int) ([]models.LeaderboardEntry, error) {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []models.GormLeaderboardEntry
+	result := g.visibleFrozenEntries(table).
+		Joins("LEFT JOIN games g ON g.id = s.game_id").
+		Where("s."+column+" = ?", snapshotDate(periodStart)).
+		Order("s.rank ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&entries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query leaderboard snapshot: %w", result.Error)
+	}
+
+	leaderboardEntries := make([]models.LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		leaderboardEntries = append(leaderboardEntries, *entry.ToLeaderboardEntry())
+	}
+
+	return leaderboardEntries, nil
+}
+
+// GetLeaderboardSnapshotCount returns the number of entries in a frozen leaderboard
+func (g *GormDB) GetLeaderboardSnapshotCount(leaderboardType models.LeaderboardType, periodStart time.Time) (int, error) {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	if err := g.visibleFrozenRows(table).Where("s."+column+" = ?", snapshotDate(periodStart)).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count leaderboard snapshot entries: %w", err)
+	}
+
+	return int(total), nil
+}
+
+// visibleFrozenRows selects the rows of a frozen leaderboard table, aliased s, whose users are not hidden
+func (g *GormDB) visibleFrozenRows(table string) *gorm.DB {
+	return g.db.Table(table+" s").
+		Joins("JOIN users u ON u.id = s.user_id").
+		Where("u.moderation_status NOT IN ?", hiddenModerationStatuses)
+}
+
+// visibleFrozenEntries selects the entries of visibleFrozenRows, ranked again without the hidden users
+func (g *GormDB) visibleFrozenEntries(table string) *gorm.DB {
+	return g.visibleFrozenRows(table).
+		Select("s.user_id, s.user_name, s.user_avatar, s.score, s.game_id, COALESCE(g.created_at, s.created_at) as created_at, " +
+			"ROW_NUMBER() OVER (ORDER BY s.rank ASC) as rank")
+}
+
+// GetLeaderboardSnapshotPeriods lists the start dates of frozen periods, most recent first
+func (g *GormDB) GetLeaderboardSnapshotPeriods(leaderboardType models.LeaderboardType, limit int) ([]time.Time, error) {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return nil, err
+	}
+
+	var periods []time.Time
+	result := g.db.Table(table).
+		Distinct(column).
+		Order(column+" DESC").
+		Limit(limit).
+		Pluck(column, &periods)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list leaderboard snapshot periods: %w", result.Error)
+	}
+
+	return periods, nil
+}
+
+// CreateSeason creates a new season
+func (g *GormDB) CreateSeason(season *models.Season) error {
+	if season.ID == uuid.Nil {
+		season.ID = uuid.New()
+	}
+
+	gormSeason := &models.GormSeason{}
+	gormSeason.FromSeason(season)
+
+	if err := g.db.Create(gormSeason).Error; err != nil {
+		return fmt.Errorf("failed to create season: %w", err)
+	}
+
+	*season = *gormSeason.ToSeason()
+	return nil
+}
+
+// UpdateSeason updates the name, dates and rewards of a season
+func (g *GormDB) UpdateSeason(season *models.Season) error {
+	result := g.db.Model(&models.GormSeason{}).
+		Where("id = ?", season.ID).
+		Updates(map[string]interface{}{
+			"name":         season.Name,
+			"start_at":     season.StartAt,
+			"end_at":       season.EndAt,
+			"reward_top_n": season.RewardTopN,
+			"updated_at":   time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update season: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("season not found")
+	}
+
+	season.UpdatedAt = time.Now()
+	return nil
+}
+
+// GetSeason retrieves a season by ID
+func (g *GormDB) GetSeason(seasonID string) (*models.Season, error) {
+	if _, err := uuid.Parse(seasonID); err != nil {
+		return nil, ErrSeasonNotFound
+	}
+
+	var gormSeason models.GormSeason
+	result := g.db.Where("id = ?", seasonID).First(&gormSeason)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, ErrSeasonNotFound
+		}
+		return nil, fmt.Errorf("failed to get season: %w", result.Error)
+	}
+
+	return gormSeason.ToSeason(), nil
+}
+
+// GetActiveSeason retrieves the season running at the given time, or nil if there is none
+func (g *GormDB) GetActiveSeason(at time.Time) (*models.Season, error) {
+	var gormSeason models.GormSeason
+	result := g.db.Where("start_at <= ? AND end_at > ?", at, at).
+		Order("start_at DESC").
+		First(&gormSeason)
+
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, nil // No season is running
+		}
+		return nil, fmt.Errorf("failed to get active season: %w", result.Error)
+	}
+
+	return gormSeason.ToSeason(), nil
+}
+
+// ListSeasons retrieves all seasons, most recent first
+func (g *GormDB) ListSeasons() ([]models.Season, error) {
+	var gormSeasons []models.GormSeason
+	if err := g.db.Order("start_at DESC").Find(&gormSeasons).Error; err != nil {
+		return nil, fmt.Errorf("failed to list seasons: %w", err)
+	}
+
+	seasons := make([]models.Season, 0, len(gormSeasons))
+	for _, gormSeason := range gormSeasons {
+		seasons = append(seasons, *gormSeason.ToSeason())
+	}
+
+	return seasons, nil
+}
+
+// GetSeasonsToClose retrieves seasons that ended before the given time but are not closed yet
+func (g *GormDB) GetSeasonsToClose(endedBefore time.Time) ([]models.Season, error) {
+	var gormSeasons []models.GormSeason
+	result := g.db.Where("end_at <= ? AND closed_at IS NULL", endedBefore).
+		Order("end_at ASC").
+		Find(&gormSeasons)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get seasons to close: %w", result.Error)
+	}
+
+	seasons := make([]models.Season, 0, len(gormSeasons))
+	for _, gormSeason := range gormSeasons {
+		seasons = append(seasons, *gormSeason.ToSeason())
+	}
+
+	return seasons, nil
+}
+
+// errSeasonAlreadyClosed rolls back a season close that lost the race to a concurrent one
+var errSeasonAlreadyClosed = errors.New("season already closed")
+
+// CloseSeason freezes the top entries of a season into its final standings,
+// awards badges to the top finishers and marks the season closed.
+// It reports whether this call closed the season, closing an already closed season is a no-op.
+func (g *GormDB) CloseSeason(seasonID string, limit int) (bool, error) {
+	season, err := g.GetSeason(seasonID)
+	if err != nil {
+		return false, err
+	}
+
+	if season.IsClosed() {
+		return false, nil
+	}
+
+	var entries []models.GormLeaderboardEntry
+	result := g.db.Table("(?) r", g.rankedLeaderboard(season.Query())).
+		Order("r.rank ASC").
+		Limit(limit).
+		Scan(&entries)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to query season standings: %w", result.Error)
+	}
+
+	now := time.Now()
+
+	err = g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("season_id = ?", season.ID).Delete(&models.GormSeasonStanding{}).Error; err != nil {
+			return fmt.Errorf("failed to clear season standings: %w", err)
+		}
+		if err := tx.Where("season_id = ?", season.ID).Delete(&models.GormUserBadge{}).Error; err != nil {
+			return fmt.Errorf("failed to clear season badges: %w", err)
+		}
+
+		var standings []models.GormSeasonStanding
+		var badges []models.GormUserBadge
+		for _, entry := range entries {
+			standings = append(standings, models.GormSeasonStanding{
+				SeasonID:   season.ID,
+				UserID:     entry.UserID,
+				UserName:   entry.UserName,
+				UserAvatar: entry.UserAvatar,
+				Score:      entry.Score,
+				Rank:       entry.Rank,
+				GameID:     entry.GameID,
+				CreatedAt:  now,
+			})
+
+			if entry.Rank <= season.RewardTopN {
+				badges = append(badges, models.GormUserBadge{
+					ID:        uuid.New(),
+					UserID:    entry.UserID,
+					SeasonID:  season.ID,
+					Title:     season.BadgeTitle(entry.Rank),
+					Rank:      entry.Rank,
+					AwardedAt: now,
+				})
+			}
+		}
+
+		if len(standings) > 0 {
+			if err := tx.Create(&standings).Error; err != nil {
+				return fmt.Errorf("failed to write season standings: %w", err)
+			}
+		}
+		if len(badges) > 0 {
+			if err := tx.Create(&badges).Error; err != nil {
+				return fmt.Errorf("failed to award season badges: %w", err)
+			}
+		}
+
+		result := tx.Model(&models.GormSeason{}).
+			Where("id = ? AND closed_at IS NULL", season.ID).
+			Update("closed_at", now)
+		if result.Error != nil {
+			return fmt.Errorf("failed to close season: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errSeasonAlreadyClosed
+		}
+
+		return nil
+	})
+	if errors.Is(err, errSeasonAlreadyClosed) {
+		// Closed concurrently, the standings written by the other close are kept
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetSeasonStandings retrieves a page of a closed season's final standings.
+// Users hidden since the season closed are left out and the others move up, an unban restores them.
+func (g *GormDB) GetSeasonStandings(seasonID string, limit, offset int) ([]models.LeaderboardEntry, error) {
+	var entries []models.GormLeaderboardEntry
+	result := g.visibleFrozenEntries("season_standings").
+		Joins("LEFT JOIN games g ON g.id = s.game_id").
+		Where("s.season_id = ?", seasonID).
+		Order("s.rank ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&entries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to query season standings: %w", result.Error)
+	}
+
+	leaderboardEntries := make([]models.LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		leaderboardEntries = append(leaderboardEntries, *entry.ToLeaderboardEntry())
+	}
+
+	return leaderboardEntries, nil
+}
+
+// GetSeasonStandingsCount returns the number of entries in a closed season's final standings
+func (g *GormDB) GetSeasonStandingsCount(seasonID string) (int, error) {
+	var total int64
+	if err := g.visibleFrozenRows("season_standings").Where("s.season_id = ?", seasonID).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("failed to count season standings: %w", err)
+	}
+
+	return int(total), nil
+}
+
+// GetUserBadges retrieves all badges awarded to a user, most recent first
+func (g *GormDB) GetUserBadges(userID string) ([]models.UserBadge, error) {
+	var gormBadges []models.GormUserBadge
+	result := g.db.Where("user_id = ?", userID).
+		Order("awarded_at DESC").
+		Find(&gormBadges)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get user badges: %w", result.Error)
+	}
+
+	badges := make([]models.UserBadge, 0, len(gormBadges))
+	for _, gormBadge := range gormBadges {
+		badges = append(badges, *gormBadge.ToUserBadge())
+	}
+
+	return badges, nil
+}
+
+// UnlockAchievement records an unlocked achievement.
+// It returns false if the user had already unlocked it, so concurrent unlocks report success only once.
+func (g *GormDB) UnlockAchievement(achievement *models.UserAchievement) (bool, error) {
+	gormAchievement := &models.GormUserAchievement{
+		UserID:        achievement.UserID,
+		AchievementID: achievement.AchievementID,
+		GameID:        achievement.GameID,
+		UnlockedAt:    achievement.UnlockedAt,
+	}
+
+	result := g.db.Clauses(clause.OnConflict{DoNothing: true}).Create(gormAchievement)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to unlock achievement: %w", result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// GetUserAchievements retrieves the achievements unlocked by a user, most recent first
+func (g *GormDB) GetUserAchievements(userID string) ([]models.UserAchievement, error) {
+	var gormAchievements []models.GormUserAchievement
+	result := g.db.Where("user_id = ?", userID).
+		Order("unlocked_at DESC").
+		Find(&gormAchievements)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get user achievements: %w", result.Error)
+	}
+
+	achievements := make([]models.UserAchievement, 0, len(gormAchievements))
+	for _, gormAchievement := range gormAchievements {
+		achievements = append(achievements, *gormAchievement.ToUserAchievement())
+	}
+
+	return achievements, nil
+}
+
+// GetUserPlayDates retrieves the most recent days, in the given timezone, on which the user played a stored game
+func (g *GormDB) GetUserPlayDates(userID string, loc *time.Location, limit int) ([]time.Time, error) {
+	var dates []time.Time
+	result := g.db.Raw(playDatesQuery, loc.String(), userID, limit).Scan(&dates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get play dates: %w", result.Error)
+	}
+
+	return dates, nil
+}
+
+// FollowUser makes a user follow another user, following twice is a no-op
+func (g *GormDB) FollowUser(followerID, followeeID string) error {
+	follow := &models.GormFollow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}
+
+	if err := g.db.Clauses(clause.OnConflict{DoNothing: true}).Create(follow).Error; err != nil {
+		return fmt.Errorf("failed to follow user: %w", err)
+	}
+
+	return nil
+}
+
+// UnfollowUser removes a follow relationship
+func (g *GormDB) UnfollowUser(followerID, followeeID string) error {
+	result := g.db.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&models.GormFollow{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unfollow user: %w", result.Error)
+	}
+
+	return nil
+}
+
+// GetFollowing retrieves the users a user follows, most recent first
+func (g *GormDB) GetFollowing(userID string) ([]models.Friend, error) {
+	friends := []models.Friend{}
+	result := g.db.Table("follows f").
+		Select("u.id as user_id, u.name as user_name, u.avatar as user_avatar, f.created_at as followed_at").
+		Joins("JOIN users u ON u.id = f.followee_id").
+		Where("f.follower_id = ?", userID).
+		Order("f.created_at DESC").
+		Scan(&friends)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get following: %w", result.Error)
+	}
+
+	return friends, nil
+}
+
+// GetFollowers retrieves the users following a user, most recent first
+func (g *GormDB) GetFollowers(userID string) ([]models.Friend, error) {
+	friends := []models.Friend{}
+	result := g.db.Table("follows f").
+		Select("u.id as user_id, u.name as user_name, u.avatar as user_avatar, f.created_at as followed_at").
+		Joins("JOIN users u ON u.id = f.follower_id").
+		Where("f.followee_id = ?", userID).
+		Order("f.created_at DESC").
+		Scan(&friends)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get followers: %w", result.Error)
+	}
+
+	return friends, nil
+}
+
+// groupsWithMemberCount builds the base query over groups including their member count
+func (g *GormDB) groupsWithMemberCount() *gorm.DB {
+	return g.db.Model(&models.GormGroup{}).
+		Select("groups.*, (SELECT COUNT(*) FROM group_members m WHERE m.group_id = groups.id) as member_count")
+}
+
+// CreateGroup creates a new group with its owner as the first member
+func (g *GormDB) CreateGroup(group *models.Group) error {
+	if group.ID == uuid.Nil {
+		group.ID = uuid.New()
+	}
+
+	gormGroup := &models.GormGroup{}
+	gormGroup.FromGroup(group)
+
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(gormGroup).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.GormGroupMember{GroupID: gormGroup.ID, UserID: gormGroup.OwnerID}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create group: %w", err)
+	}
+
+	gormGroup.MemberCount = 1
+	*group = *gormGroup.ToGroup()
+	return nil
+}
+
+// UpdateGroup updates the name and invite code of a group
+func (g *GormDB) UpdateGroup(group *models.Group) error {
+	result := g.db.Model(&models.GormGroup{}).
+		Where("id = ?", group.ID).
+		Updates(map[string]interface{}{
+			"name":        group.Name,
+			"invite_code": group.InviteCode,
+			"updated_at":  time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update group: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("group not found")
+	}
+
+	group.UpdatedAt = time.Now()
+	return nil
+}
+
+// DeleteGroup deletes a group and all its memberships
+func (g *GormDB) DeleteGroup(groupID string) error {
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", groupID).Delete(&models.GormGroupMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", groupID).Delete(&models.GormGroup{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return nil
+}
+
+// GetGroup retrieves a group by ID
+func (g *GormDB) GetGroup(groupID string) (*models.Group, error) {
+	var gormGroup models.GormGroup
+	result := g.groupsWithMemberCount().Where("groups.id = ?", groupID).First(&gormGroup)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, fmt.Errorf("failed to get group: %w", result.Error)
+	}
+
+	return gormGroup.ToGroup(), nil
+}
+
+// GetGroupByInviteCode retrieves a group by its invite code
+func (g *GormDB) GetGroupByInviteCode(inviteCode string) (*models.Group, error) {
+	var gormGroup models.GormGroup
+	result := g.groupsWithMemberCount().Where("groups.invite_code = ?", inviteCode).First(&gormGroup)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, fmt.Errorf("failed to get group: %w", result.Error)
+	}
+
+	return gormGroup.ToGroup(), nil
+}
+
+// GetUserGroups retrieves the groups a user is a member of, by name
+func (g *GormDB) GetUserGroups(userID string) ([]models.Group, error) {
+	var gormGroups []models.GormGroup
+	result := g.groupsWithMemberCount().
+		Where("groups.id IN (SELECT group_id FROM group_members WHERE user_id = ?)", userID).
+		Order("groups.name ASC").
+		Find(&gormGroups)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get user groups: %w", result.Error)
+	}
+
+	groups := make([]models.Group, 0, len(gormGroups))
+	for _, gormGroup := range gormGroups {
+		groups = append(groups, *gormGroup.ToGroup())
+	}
+
+	return groups, nil
+}
+
+// AddGroupMember adds a user to a group, joining twice is a no-op
+func (g *GormDB) AddGroupMember(groupID, userID string) error {
+	id, err := uuid.Parse(groupID)
+	if err != nil {
+		return fmt.Errorf("invalid group ID: %w", err)
+	}
+
+	member := &models.GormGroupMember{GroupID: id, UserID: userID}
+	if err := g.db.Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error; err != nil {
+		return fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveGroupMember removes a user from a group
+func (g *GormDB) RemoveGroupMember(groupID, userID string) error {
+	result := g.db.Where("group_id = ? AND user_id = ?", groupID, userID).
+		Delete(&models.GormGroupMember{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove group member: %w", result.Error)
+	}
+
+	return nil
+}
+
+// IsGroupMember reports whether a user is a member of a group
+func (g *GormDB) IsGroupMember(groupID, userID string) (bool, error) {
+	var count int64
+	result := g.db.Model(&models.GormGroupMember{}).
+		Where("group_id = ? AND user_id = ?", groupID, userID).
+		Count(&count)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to check group membership: %w", result.Error)
+	}
+
+	return count > 0, nil
+}
+
+// GetGroupMembers retrieves a page of a group's members in the order they joined
+func (g *GormDB) GetGroupMembers(groupID string, limit, offset int) ([]models.GroupMember, error) {
+	members := []models.GroupMember{}
+	result := g.db.Table("group_members m").
+		Select("u.id as user_id, u.name as user_name, u.avatar as user_avatar, m.joined_at").
+		Joins("JOIN users u ON u.id = m.user_id").
+		Where("m.group_id = ?", groupID).
+		Order("m.joined_at ASC, m.user_id ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&members)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get group members: %w", result.Error)
+	}
+
+	return members, nil
+}
+
+// CreateWebhook creates a new webhook
+func (g *GormDB) CreateWebhook(webhook *models.Webhook) error {
+	if webhook.ID == uuid.Nil {
+		webhook.ID = uuid.New()
+	}
+
+	gormWebhook := &models.GormWebhook{}
+	gormWebhook.FromWebhook(webhook)
+
+	if err := g.db.Create(gormWebhook).Error; err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	*webhook = *gormWebhook.ToWebhook()
+	return nil
+}
+
+// UpdateWebhook updates the URL, events and active flag of a webhook
+func (g *GormDB) UpdateWebhook(webhook *models.Webhook) error {
+	webhook.UpdatedAt = time.Now()
+
+	result := g.db.Model(&models.GormWebhook{}).
+		Where("id = ?", webhook.ID).
+		Updates(map[string]interface{}{
+			"url":        webhook.URL,
+			"events":     models.JoinWebhookEvents(webhook.Events),
+			"active":     webhook.Active,
+			"updated_at": webhook.UpdatedAt,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes a webhook and its deliveries
+func (g *GormDB) DeleteWebhook(webhookID string) error {
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhookID).Delete(&models.GormWebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", webhookID).Delete(&models.GormWebhook{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebhook retrieves a webhook by ID
+func (g *GormDB) GetWebhook(webhookID string) (*models.Webhook, error) {
+	var gormWebhook models.GormWebhook
+	result := g.db.Where("id = ?", webhookID).First(&gormWebhook)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", result.Error)
+	}
+
+	return gormWebhook.ToWebhook(), nil
+}
+
+// ListWebhooks retrieves all webhooks, oldest first
+func (g *GormDB) ListWebhooks() ([]models.Webhook, error) {
+	var gormWebhooks []models.GormWebhook
+	if err := g.db.Order("created_at ASC").Find(&gormWebhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	webhooks := make([]models.Webhook, 0, len(gormWebhooks))
+	for _, gormWebhook := range gormWebhooks {
+		webhooks = append(webhooks, *gormWebhook.ToWebhook())
+	}
+
+	return webhooks, nil
+}
+
+// CreateWebhookDelivery queues a delivery of an event to a webhook
+func (g *GormDB) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	if delivery.ID == uuid.Nil {
+		delivery.ID = uuid.New()
+	}
+
+	gormDelivery := &models.GormWebhookDelivery{}
+	gormDelivery.FromWebhookDelivery(delivery)
+
+	if err := g.db.Create(gormDelivery).Error; err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	*delivery = *gormDelivery.ToWebhookDelivery()
+	return nil
+}
+
+// UpdateWebhookDelivery saves the status and attempt bookkeeping of a delivery
+func (g *GormDB) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	result := g.db.Model(&models.GormWebhookDelivery{}).
+		Where("id = ?", delivery.ID).
+		Updates(map[string]interface{}{
+			"status":           string(delivery.Status),
+			"attempts":         delivery.Attempts,
+			"last_error":       delivery.LastError,
+			"last_status_code": delivery.LastStatusCode,
+			"next_attempt_at":  delivery.NextAttemptAt,
+			"delivered_at":     delivery.DeliveredAt,
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("webhook delivery not found")
+	}
+
+	return nil
+}
+
+// GetWebhookDelivery retrieves a webhook delivery by ID
+func (g *GormDB) GetWebhookDelivery(deliveryID string) (*models.WebhookDelivery, error) {
+	var gormDelivery models.GormWebhookDelivery
+	result := g.db.Where("id = ?", deliveryID).First(&gormDelivery)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("webhook delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", result.Error)
+	}
+
+	return gormDelivery.ToWebhookDelivery(), nil
+}
+
+// GetWebhookDeliveries retrieves a page of deliveries, most recent first.
+// Empty webhook ID and status match every webhook and status.
+func (g *GormDB) GetWebhookDeliveries(webhookID string, status models.WebhookDeliveryStatus, limit, offset int) ([]models.WebhookDelivery, error) {
+	query := g.db.Model(&models.GormWebhookDelivery{})
+	if webhookID != "" {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", string(status))
+	}
+
+	var gormDeliveries []models.GormWebhookDelivery
+	result := query.Order("created_at DESC, id ASC").Limit(limit).Offset(offset).Find(&gormDeliveries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", result.Error)
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(gormDeliveries))
+	for _, gormDelivery := range gormDeliveries {
+		deliveries = append(deliveries, *gormDelivery.ToWebhookDelivery())
+	}
+
+	return deliveries, nil
+}
+
+// GetDueWebhookDeliveries retrieves pending deliveries whose next attempt is due, oldest first
+func (g *GormDB) GetDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var gormDeliveries []models.GormWebhookDelivery
+	result := g.db.Where("status = ? AND next_attempt_at <= ?", string(models.WebhookDeliveryPending), now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&gormDeliveries)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get due webhook deliveries: %w", result.Error)
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(gormDeliveries))
+	for _, gormDelivery := range gormDeliveries {
+		deliveries = append(deliveries, *gormDelivery.ToWebhookDelivery())
+	}
+
+	return deliveries, nil
+}
+
+// GetPreviousBestScore returns a user's best score over their finished games other than the given one.
+// It reports false if the user has no other finished game. Tournament attempts are not counted.
+func (g *GormDB) GetPreviousBestScore(userID, gameID string) (int, bool, error) {
+	var best sql.NullInt64
+	result := g.finishedGames().
+		Select("MAX(score)").
+		Where("user_id = ? AND id <> ? AND mode <> ?", userID, gameID, models.GameModeTournament).
+		Scan(&best)
+	if result.Error != nil {
+		return 0, false, fmt.Errorf("failed to get previous best score: %w", result.Error)
+	}
+
+	return int(best.Int64), best.Valid, nil
+}
+
+// tournamentsWithParticipantCount builds a tournaments query that fills in participant counts
+func (g *GormDB) tournamentsWithParticipantCount() *gorm.DB {
+	return g.db.Model(&models.GormTournament{}).
+		Select("tournaments.*, (SELECT COUNT(*) FROM tournament_participants p WHERE p.tournament_id = tournaments.id) as participant_count")
+}
+
+// withTournamentRounds converts tournaments and loads their rounds in order
+func (g *GormDB) withTournamentRounds(gormTournaments []models.GormTournament) ([]models.Tournament, error) {
+	tournaments := make([]models.Tournament, 0, len(gormTournaments))
+	if len(gormTournaments) == 0 {
+		return tournaments, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(gormTournaments))
+	for _, gormTournament := range gormTournaments {
+		ids = append(ids, gormTournament.ID)
+	}
+
+	var gormRounds []models.GormTournamentRound
+	if err := g.db.Where("tournament_id IN ?", ids).Order("number ASC").Find(&gormRounds).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tournament rounds: %w", err)
+	}
+
+	rounds := make(map[uuid.UUID][]models.TournamentRound, len(gormTournaments))
+	for _, gormRound := range gormRounds {
+		rounds[gormRound.TournamentID] = append(rounds[gormRound.TournamentID], *gormRound.ToTournamentRound())
+	}
+
+	for _, gormTournament := range gormTournaments {
+		tournament := gormTournament.ToTournament()
+		tournament.Rounds = rounds[tournament.ID]
+		if tournament.Rounds == nil {
+			tournament.Rounds = []models.TournamentRound{}
+		}
+		tournaments = append(tournaments, *tournament)
+	}
+
+	return tournaments, nil
+}
+
+// CreateTournament creates a new tournament with its rounds
+func (g *GormDB) CreateTournament(tournament *models.Tournament) error {
+	if tournament.ID == uuid.Nil {
+		tournament.ID = uuid.New()
+	}
+
+	gormTournament := &models.GormTournament{}
+	gormTournament.FromTournament(tournament)
+
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(gormTournament).Error; err != nil {
+			return err
+		}
+		for i := range tournament.Rounds {
+			tournament.Rounds[i].TournamentID = gormTournament.ID
+			gormRound := &models.GormTournamentRound{}
+			gormRound.FromTournamentRound(&tournament.Rounds[i])
+			if err := tx.Create(gormRound).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create tournament: %w", err)
+	}
+
+	tournament.CreatedAt = gormTournament.CreatedAt
+	tournament.UpdatedAt = gormTournament.UpdatedAt
+	return nil
+}
+
+// DeleteTournament deletes a tournament with its rounds, participants and attempts.
+// The attempt games are kept in the players' history.
+func (g *GormDB) DeleteTournament(tournamentID string) error {
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tournament_id = ?", tournamentID).Delete(&models.GormTournamentAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tournament_id = ?", tournamentID).Delete(&models.GormTournamentParticipant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tournament_id = ?", tournamentID).Delete(&models.GormTournamentRound{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", tournamentID).Delete(&models.GormTournament{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete tournament: %w", err)
+	}
+
+	return nil
+}
+
+// GetTournament retrieves a tournament with its rounds by ID
+func (g *GormDB) GetTournament(tournamentID string) (*models.Tournament, error) {
+	var gormTournament models.GormTournament
+	result := g.tournamentsWithParticipantCount().Where("tournaments.id = ?", tournamentID).First(&gormTournament)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("tournament not found")
+		}
+		return nil, fmt.Errorf("failed to get tournament: %w", result.Error)
+	}
+
+	tournaments, err := g.withTournamentRounds([]models.GormTournament{gormTournament})
+	if err != nil {
+		return nil, err
+	}
+
+	return &tournaments[0], nil
+}
+
+// ListTournaments retrieves all tournaments with their rounds, most recent registration first
+func (g *GormDB) ListTournaments() ([]models.Tournament, error) {
+	var gormTournaments []models.GormTournament
+	result := g.tournamentsWithParticipantCount().
+		Order("tournaments.registration_start DESC").
+		Find(&gormTournaments)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to list tournaments: %w", result.Error)
+	}
+
+	return g.withTournamentRounds(gormTournaments)
+}
+
+// GetUnfinishedTournaments retrieves the tournaments that are upcoming or running, with their rounds
+func (g *GormDB) GetUnfinishedTournaments() ([]models.Tournament, error) {
+	var gormTournaments []models.GormTournament
+	result := g.tournamentsWithParticipantCount().
+		Where("tournaments.status <> ?", string(models.TournamentFinished)).
+		Order("tournaments.registration_start ASC").
+		Find(&gormTournaments)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get unfinished tournaments: %w", result.Error)
+	}
+
+	return g.withTournamentRounds(gormTournaments)
+}
+
+// UpdateTournamentStatus sets the status of a tournament
+func (g *GormDB) UpdateTournamentStatus(tournamentID string, status models.TournamentStatus) error {
+	result := g.db.Model(&models.GormTournament{}).
+		Where("id = ?", tournamentID).
+		Updates(map[string]interface{}{
+			"status":     string(status),
+			"updated_at": time.Now(),
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update tournament status: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tournament not found")
+	}
+
+	return nil
+}
+
+// UpdateTournamentRoundStatus sets the status of a tournament round
+func (g *GormDB) UpdateTournamentRoundStatus(tournamentID string, round int, status models.TournamentRoundStatus) error {
+	result := g.db.Model(&models.GormTournamentRound{}).
+		Where("tournament_id = ? AND number = ?", tournamentID, round).
+		Update("status", string(status))
+
+	if result.Error != nil {
+		return fmt.Errorf("failed to update tournament round status: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tournament round not found")
+	}
+
+	return nil
+}
+
+// RegisterTournamentParticipant registers a user for a tournament, registering twice is a no-op
+func (g *GormDB) RegisterTournamentParticipant(tournamentID, userID string) error {
+	id, err := uuid.Parse(tournamentID)
+	if err != nil {
+		return fmt.Errorf("invalid tournament ID: %w", err)
+	}
+
+	participant := &models.GormTournamentParticipant{TournamentID: id, UserID: userID}
+	if err := g.db.Clauses(clause.OnConflict{DoNothing: true}).Create(participant).Error; err != nil {
+		return fmt.Errorf("failed to register tournament participant: %w", err)
+	}
+
+	return nil
+}
+
+// UnregisterTournamentParticipant withdraws a user's registration for a tournament
+func (g *GormDB) UnregisterTournamentParticipant(tournamentID, userID string) error {
+	result := g.db.Where("tournament_id = ? AND user_id = ?", tournamentID, userID).
+		Delete(&models.GormTournamentParticipant{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unregister tournament participant: %w", result.Error)
+	}
+
+	return nil
+}
+
+// tournamentParticipants builds a query over the participants of a tournament with their names
+func (g *GormDB) tournamentParticipants(tournamentID string) *gorm.DB {
+	return g.db.Table("tournament_participants p").
+		Select("p.tournament_id, p.user_id, u.name as user_name, u.avatar as user_avatar, "+
+			"p.registered_at, p.eliminated_round, p.final_rank").
+		Joins("JOIN users u ON u.id = p.user_id").
+		Where("p.tournament_id = ?", tournamentID)
+}
+
+// GetTournamentParticipant retrieves a user's registration for a tournament
+func (g *GormDB) GetTournamentParticipant(tournamentID, userID string) (*models.TournamentParticipant, error) {
+	participants := []models.TournamentParticipant{}
+	result := g.tournamentParticipants(tournamentID).
+		Where("p.user_id = ?", userID).
+		Limit(1).
+		Scan(&participants)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get tournament participant: %w", result.Error)
+	}
+
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("tournament participant not found")
+	}
+
+	return &participants[0], nil
+}
+
+// GetTournamentParticipants retrieves the participants of a tournament, ordered by final rank,
+// then by how far they got and when they registered
+func (g *GormDB) GetTournamentParticipants(tournamentID string) ([]models.TournamentParticipant, error) {
+	participants := []models.TournamentParticipant{}
+	result := g.tournamentParticipants(tournamentID).
+		Order("p.final_rank ASC NULLS LAST, p.eliminated_round DESC NULLS FIRST, p.registered_at ASC, p.user_id ASC").
+		Scan(&participants)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get tournament participants: %w", result.Error)
+	}
+
+	return participants, nil
+}
+
+// EliminateTournamentParticipants knocks out every remaining participant of a tournament
+// who is not among the advancing users, recording the round they were eliminated in
+func (g *GormDB) EliminateTournamentParticipants(tournamentID string, round int, advancing []string) error {
+	query := g.db.Model(&models.GormTournamentParticipant{}).
+		Where("tournament_id = ? AND eliminated_round IS NULL", tournamentID)
+	if len(advancing) > 0 {
+		query = query.Where("user_id NOT IN ?", advancing)
+	}
+
+	if err := query.Update("eliminated_round", round).Error; err != nil {
+		return fmt.Errorf("failed to eliminate tournament participants: %w", err)
+	}
+
+	return nil
+}
+
+// SetTournamentFinalRanks records the final ranks of a tournament's participants, keyed by user ID
+func (g *GormDB) SetTournamentFinalRanks(tournamentID string, ranks map[string]int) error {
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		for userID, rank := range ranks {
+			result := tx.Model(&models.GormTournamentParticipant{}).
+				Where("tournament_id = ? AND user_id = ?", tournamentID, userID).
+				Update("final_rank", rank)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set tournament final ranks: %w", err)
+	}
+
+	return nil
+}
+
+// CreateTournamentAttempt records a game played as a tournament attempt
+func (g *GormDB) CreateTournamentAttempt(attempt *models.TournamentAttempt) error {
+	gormAttempt := &models.GormTournamentAttempt{
+		GameID:       attempt.GameID,
+		TournamentID: attempt.TournamentID,
+		Round:        attempt.Round,
+		UserID:       attempt.UserID,
+	}
+
+	if err := g.db.Create(gormAttempt).Error; err != nil {
+		return fmt.Errorf("failed to create tournament attempt: %w", err)
+	}
+
+	attempt.CreatedAt = gormAttempt.CreatedAt
+	return nil
+}
+
+// CountTournamentAttempts returns the number of attempts a user started in a tournament round
+func (g *GormDB) CountTournamentAttempts(tournamentID string, round int, userID string) (int, error) {
+	var count int64
+	result := g.db.Model(&models.GormTournamentAttempt{}).
+		Where("tournament_id = ? AND round = ? AND user_id = ?", tournamentID, round, userID).
+		Count(&count)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to count tournament attempts: %w", result.Error)
+	}
+
+	return int(count), nil
+}
+
+// GetUnfinishedTournamentAttempts retrieves the attempts of a round whose game has not finished,
+// including games that were only cached so far
+func (g *GormDB) GetUnfinishedTournamentAttempts(tournamentID string, round int) ([]models.TournamentAttempt, error) {
+	attempts := []models.TournamentAttempt{}
+	result := g.db.Table("tournament_attempts a").
+		Select("a.game_id, a.tournament_id, a.round, a.user_id, a.created_at").
+		Joins("LEFT JOIN games g ON g.id = a.game_id").
+		Where("a.tournament_id = ? AND a.round = ?", tournamentID, round).
+		Where("g.id IS NULL OR (g.game_over = ? AND g.victory = ?)", false, false).
+		Order("a.created_at ASC").
+		Scan(&attempts)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get unfinished tournament attempts: %w", result.Error)
+	}
+
+	return attempts, nil
+}
+
+// GetTournamentAttemptRound retrieves the round a game was played in as a tournament attempt.
+// It returns nil if the game is not a tournament attempt.
+func (g *GormDB) GetTournamentAttemptRound(gameID string) (*models.TournamentRound, error) {
+	var gormRounds []models.GormTournamentRound
+	result := g.db.Table("tournament_rounds r").
+		Select("r.*").
+		Joins("JOIN tournament_attempts a ON a.tournament_id = r.tournament_id AND a.round = r.number").
+		Where("a.game_id = ?", gameID).
+		Limit(1).
+		Find(&gormRounds)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get tournament attempt round: %w", result.Error)
+	}
+
+	if len(gormRounds) == 0 {
+		return nil, nil
+	}
+	return gormRounds[0].ToTournamentRound(), nil
+}
+
+// GetDB returns the underlying GORM database instance
+func (g *GormDB) GetDB() *gorm.DB {
+	return g.db
+}
+
+// CreateRefreshToken stores a newly issued refresh token
+func (g *GormDB) CreateRefreshToken(token *models.RefreshToken) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+
+	gormToken := &models.GormRefreshToken{}
+	gormToken.FromRefreshToken(token)
+
+	if err := g.db.Create(gormToken).Error; err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	token.CreatedAt = gormToken.CreatedAt
+	return nil
+}
+
+// GetRefreshToken retrieves a refresh token by the hash of its value
+func (g *GormDB) GetRefreshToken(tokenHash string) (*models.RefreshToken, error) {
+	var gormToken models.GormRefreshToken
+	result := g.db.Where("token_hash = ?", tokenHash).First(&gormToken)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("refresh token not found")
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", result.Error)
+	}
+
+	return gormToken.ToRefreshToken(), nil
+}
+
+// RotateRefreshToken marks a refresh token rotated and stores its successor in one transaction.
+// It returns false without storing the successor if the token was already rotated or revoked.
+func (g *GormDB) RotateRefreshToken(tokenID string, next *models.RefreshToken) (bool, error) {
+	if next.ID == uuid.Nil {
+		next.ID = uuid.New()
+	}
+
+	rotated := false
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.GormRefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", tokenID).
+			Update("rotated_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		gormToken := &models.GormRefreshToken{}
+		gormToken.FromRefreshToken(next)
+		if err := tx.Create(gormToken).Error; err != nil {
+			return err
+		}
+
+		next.CreatedAt = gormToken.CreatedAt
+		rotated = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return rotated, nil
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token rotated from the same login
+func (g *GormDB) RevokeRefreshTokenFamily(familyID string) error {
+	result := g.db.Model(&models.GormRefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", result.Error)
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of a user
+func (g *GormDB) RevokeUserRefreshTokens(userID string) error {
+	result := g.db.Model(&models.GormRefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke user refresh tokens: %w", result.Error)
+	}
+
+	return nil
+}
+
+// DeleteExpiredRefreshTokens deletes refresh tokens that expired before the given time and returns how many were deleted
+func (g *GormDB) DeleteExpiredRefreshTokens(before time.Time) (int64, error) {
+	result := g.db.Where("expires_at < ?", before).Delete(&models.GormRefreshToken{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// seedRoles creates the built-in admin role with every permission
+func (g *GormDB) seedRoles() error {
+	return g.db.Transaction(func(tx *gorm.DB) error {
+		admin := models.GormRole{Name: models.RoleAdmin, Description: "Full access to the admin API"}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&admin).Error; err != nil {
+			return err
+		}
+
+		permission := models.GormRolePermission{Role: models.RoleAdmin, Permission: string(models.PermissionAll)}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&permission).Error
+	})
+}
+
+// ListRoles retrieves every role with its permissions
+func (g *GormDB) ListRoles() ([]models.Role, error) {
+	var gormRoles []models.GormRole
+	if err := g.db.Order("name ASC").Find(&gormRoles).Error; err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	var gormPermissions []models.GormRolePermission
+	if err := g.db.Order("permission ASC").Find(&gormPermissions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list role permissions: %w", err)
+	}
+
+	permissions := make(map[string][]string)
+	for _, gp := range gormPermissions {
+		permissions[gp.Role] = append(permissions[gp.Role], gp.Permission)
+	}
+
+	roles := make([]models.Role, len(gormRoles))
+	for i, gr := range gormRoles {
+		roles[i] = *gr.ToRole()
+		roles[i].Permissions = toPermissions(permissions[gr.Name])
+	}
+
+	return roles, nil
+}
+
+// GetRole retrieves a role with its permissions
+func (g *GormDB) GetRole(name string) (*models.Role, error) {
+	var gormRole models.GormRole
+	result := g.db.Where("name = ?", name).First(&gormRole)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("role not found")
+		}
+		return nil, fmt.Errorf("failed to get role: %w", result.Error)
+	}
+
+	var permissions []string
+	result = g.db.Model(&models.GormRolePermission{}).
+		Where("role = ?", name).
+		Order("permission ASC").
+		Pluck("permission", &permissions)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", result.Error)
+	}
+
+	role := gormRole.ToRole()
+	role.Permissions = toPermissions(permissions)
+	return role, nil
+}
+
+// SaveRole creates a role or replaces the description and permissions of an existing one
+func (g *GormDB) SaveRole(role *models.Role) error {
+	gormRole := &models.GormRole{Name: role.Name, Description: role.Description}
+
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description", "updated_at"}),
+		}).Create(gormRole)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.Where("role = ?", role.Name).Delete(&models.GormRolePermission{}).Error; err != nil {
+			return err
+		}
+		for _, permission := range role.Permissions {
+			rp := models.GormRolePermission{Role: role.Name, Permission: string(permission)}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rp).Error; err != nil {
+				return err
+			}
+		}
+
+		// The created time of an existing role is kept
+		return tx.Where("name = ?", role.Name).First(gormRole).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+
+	role.CreatedAt = gormRole.CreatedAt
+	role.UpdatedAt = gormRole.UpdatedAt
+	return nil
+}
+
+// DeleteRole deletes a role, taking it away from every user that had it
+func (g *GormDB) DeleteRole(name string) error {
+	var deleted int64
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", name).Delete(&models.GormUserRole{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role = ?", name).Delete(&models.GormRolePermission{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("name = ?", name).Delete(&models.GormRole{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	if deleted == 0 {
+		return fmt.Errorf("role not found")
+	}
+
+	return nil
+}
+
+// GrantRole grants a role to a user, granting a role the user already has changes nothing
+func (g *GormDB) GrantRole(userID, role, grantedBy string) error {
+	userRole := &models.GormUserRole{UserID: userID, Role: role, GrantedBy: grantedBy}
+
+	result := g.db.Clauses(clause.OnConflict{DoNothing: true}).Create(userRole)
+	if result.Error != nil {
+		return fmt.Errorf("failed to grant role: %w", result.Error)
+	}
+
+	return nil
+}
+
+// RevokeRole takes a role away from a user
+func (g *GormDB) RevokeRole(userID, role string) error {
+	result := g.db.Where("user_id = ? AND role = ?", userID, role).Delete(&models.GormUserRole{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke role: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("role not granted")
+	}
+
+	return nil
+}
+
+// GetUserRoles retrieves the roles granted to a user
+func (g *GormDB) GetUserRoles(userID string) ([]models.UserRole, error) {
+	var gormRoles []models.GormUserRole
+	result := g.db.Where("user_id = ?", userID).Order("role ASC").Find(&gormRoles)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", result.Error)
+	}
+
+	roles := make([]models.UserRole, len(gormRoles))
+	for i, gr := range gormRoles {
+		roles[i] = *gr.ToUserRole()
+	}
+
+	return roles, nil
+}
+
+// GetUserPermissions retrieves the permissions a user has through their roles
+func (g *GormDB) GetUserPermissions(userID string) ([]models.Permission, error) {
+	var permissions []string
+	result := g.db.Model(&models.GormRolePermission{}).
+		Distinct("role_permissions.permission").
+		Joins("JOIN user_roles ur ON ur.role = role_permissions.role").
+		Where("ur.user_id = ?", userID).
+		Pluck("role_permissions.permission", &permissions)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get user permissions: %w", result.Error)
+	}
+
+	return toPermissions(permissions), nil
+}
+
+// CreateAPIToken stores a newly created personal API token
+func (g *GormDB) CreateAPIToken(token *models.APIToken) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+
+	gormToken := &models.GormAPIToken{}
+	gormToken.FromAPIToken(token)
+
+	if err := g.db.Create(gormToken).Error; err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	token.CreatedAt = gormToken.CreatedAt
+	return nil
+}
+
+// GetAPIToken retrieves an API token by the hash of its value
+func (g *GormDB) GetAPIToken(tokenHash string) (*models.APIToken, error) {
+	var gormToken models.GormAPIToken
+	result := g.db.Where("token_hash = ?", tokenHash).First(&gormToken)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("API token not found")
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", result.Error)
+	}
+
+	return gormToken.ToAPIToken(), nil
+}
+
+// GetUserAPITokens retrieves the API tokens of a user that were not revoked, newest first
+func (g *GormDB) GetUserAPITokens(userID string) ([]models.APIToken, error) {
+	var gormTokens []models.GormAPIToken
+	result := g.db.Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&gormTokens)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get API tokens: %w", result.Error)
+	}
+
+	tokens := make([]models.APIToken, len(gormTokens))
+	for i := range gormTokens {
+		tokens[i] = *gormTokens[i].ToAPIToken()
+	}
+
+	return tokens, nil
+}
+
+// RevokeAPIToken revokes an API token of a user
+func (g *GormDB) RevokeAPIToken(userID, tokenID string) error {
+	result := g.db.Model(&models.GormAPIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke API token: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("API token not found")
+	}
+
+	return nil
+}
+
+// TouchAPIToken records when an API token was last used
+func (g *GormDB) TouchAPIToken(tokenID string, usedAt time.Time) error {
+	result := g.db.Model(&models.GormAPIToken{}).
+		Where("id = ?", tokenID).
+		Update("last_used_at", usedAt)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update API token: %w", result.Error)
+	}
+
+	return nil
+}
+
+// ModerateUser sets the moderation status of a user and records the action in the audit trail
+func (g *GormDB) ModerateUser(action *models.ModerationAction) error {
+	if action.ID == uuid.Nil {
+		action.ID = uuid.New()
+	}
+
+	gormAction := &models.GormModerationAction{}
+	gormAction.FromModerationAction(action)
+
+	err := g.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.GormUser{}).
+			Where("id = ?", action.UserID).
+			Updates(map[string]interface{}{
+				"moderation_status": string(action.Status),
+				"suspended_until":   action.SuspendedUntil,
+				"updated_at":        time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Create(gormAction).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		return fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to moderate user: %w", err)
+	}
+
+	action.CreatedAt = gormAction.CreatedAt
+	return nil
+}
+
+// GetModerationActions retrieves the moderation audit trail of a user, newest first
+func (g *GormDB) GetModerationActions(userID string) ([]models.ModerationAction, error) {
+	var gormActions []models.GormModerationAction
+	result := g.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&gormActions)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get moderation actions: %w", result.Error)
+	}
+
+	actions := make([]models.ModerationAction, len(gormActions))
+	for i := range gormActions {
+		actions[i] = *gormActions[i].ToModerationAction()
+	}
+
+	return actions, nil
+}
+
+// GetModeratedUsers retrieves the users that are suspended, banned or shadow-banned, most recently moderated first
+func (g *GormDB) GetModeratedUsers() ([]models.UserModeration, error) {
+	var gormUsers []models.GormUser
+	result := g.db.
+		Where("moderation_status <> ?", string(models.ModerationActive)).
+		Where("moderation_status <> ? OR suspended_until > ?", string(models.ModerationSuspended), time.Now()).
+		Order("updated_at DESC").
+		Find(&gormUsers)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to get moderated users: %w", result.Error)
+	}
+
+	users := make([]models.UserModeration, len(gormUsers))
+	for i := range gormUsers {
+		users[i] = models.UserModeration{
+			UserID:         gormUsers[i].ID,
+			UserName:       gormUsers[i].Name,
+			Status:         models.ModerationStatus(gormUsers[i].ModerationStatus),
+			SuspendedUntil: gormUsers[i].SuspendedUntil,
+		}
+	}
+
+	return users, nil
 }
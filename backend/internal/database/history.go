@@ -0,0 +1,120 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"game2048/pkg/models"
+)
+
+// gameTrendDays is the number of most recent days included in a score trend
+const gameTrendDays = 90
+
+// bestTileExpr is the SQL expression for the highest tile on a game's board
+const bestTileExpr = `(SELECT COALESCE(MAX(t.cell::int), 0)
+	FROM jsonb_array_elements(board) AS r(cells), jsonb_array_elements_text(r.cells) AS t(cell))`
+
+// gameHistoryConditions returns the SQL conditions selecting a user's games matching the filter.
+// Placeholders are written as ?, see rebind for numbered placeholders.
+func gameHistoryConditions(userID string, filter models.GameHistoryFilter, now time.Time) (string, []interface{}) {
+	conditions := []string{"user_id = ?"}
+	args := []interface{}{userID}
+
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, *filter.To)
+	}
+	if filter.Mode != "" {
+		conditions = append(conditions, "mode = ?")
+		args = append(args, filter.Mode)
+	}
+	if filter.MinScore > 0 {
+		conditions = append(conditions, "score >= ?")
+		args = append(args, filter.MinScore)
+	}
+
+	abandonedBefore := now.Add(-models.GameAbandonAfter)
+	switch filter.Status {
+	case models.GameStatusFinished:
+		conditions = append(conditions, "(game_over = true OR victory = true)")
+	case models.GameStatusAbandoned:
+		conditions = append(conditions, "game_over = false AND victory = false AND updated_at < ?")
+		args = append(args, abandonedBefore)
+	case models.GameStatusActive:
+		conditions = append(conditions, "game_over = false AND victory = false AND updated_at >= ?")
+		args = append(args, abandonedBefore)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// gameStatsQuery returns SQL computing the summary statistics of the games matching the conditions
+func gameStatsQuery(conditions string) string {
+	return `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE victory = true),
+			COALESCE(AVG(score), 0),
+			COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY score), 0),
+			COALESCE(MAX(score), 0)
+		FROM games
+		WHERE ` + conditions
+}
+
+// bestTilesQuery returns SQL counting the games matching the conditions by their best tile
+func bestTilesQuery(conditions string) string {
+	return `
+		SELECT best_tile as tile, COUNT(*) as count
+		FROM (SELECT ` + bestTileExpr + ` as best_tile FROM games WHERE ` + conditions + `) t
+		GROUP BY best_tile
+		ORDER BY best_tile DESC`
+}
+
+// scoreTrendQuery returns SQL summarizing the games matching the conditions per day, oldest first.
+// Its first placeholder is the timezone that days are taken in.
+func scoreTrendQuery(conditions string) string {
+	return `
+		SELECT date, games, average_score, best_score
+		FROM (
+			SELECT
+				(created_at AT TIME ZONE ?)::date as date,
+				COUNT(*) as games,
+				AVG(score) as average_score,
+				MAX(score) as best_score
+			FROM games
+			WHERE ` + conditions + `
+			GROUP BY 1
+			ORDER BY 1 DESC
+			LIMIT ` + strconv.Itoa(gameTrendDays) + `
+		) t
+		ORDER BY date ASC`
+}
+
+// playDatesQuery selects the distinct days a user played on, most recent first.
+// Its placeholders are the timezone that days are taken in, the user and the number of days.
+const playDatesQuery = `
+	SELECT DISTINCT (created_at AT TIME ZONE ?)::date as date
+	FROM games
+	WHERE user_id = ?
+	ORDER BY date DESC
+	LIMIT ?`
+
+// rebind replaces ? placeholders with numbered placeholders starting after the given count
+func rebind(query string, start int) string {
+	var b strings.Builder
+	n := start
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"game2048/pkg/models"
+)
+
+var (
+	// ErrIdentityNotFound is returned when a user has no identity with the given ID
+	ErrIdentityNotFound = errors.New("identity not found")
+	// ErrLastIdentity is returned when unlinking the only identity a user can sign in with
+	ErrLastIdentity = errors.New("cannot unlink the last identity")
+)
+
+// userRowTables are the tables with at most one row per user and key column.
+// A source user's row moves to the target unless the target already has one for the same key.
+var userRowTables = []struct {
+	table string
+	key   string
+}{
+	{"user_achievements", "achievement_id"},
+	{"user_badges", "season_id"},
+	{"season_standings", "season_id"},
+	{"group_members", "group_id"},
+	{"tournament_participants", "tournament_id"},
+	{"user_roles", "role"},
+	{"leaderboard_daily", "date"},
+	{"leaderboard_weekly", "week_start"},
+	{"leaderboard_monthly", "month_start"},
+}
+
+// userStatement is a SQL statement with its arguments
+type userStatement struct {
+	query string
+	args  []interface{}
+}
+
+// mergeUserStatements returns the statements that move everything of a source user to a target user and delete the source.
+// Rows the target already has an equivalent of are deleted with the source, so the target keeps its own.
+// The target takes the stricter moderation status of the two, so a merge cannot lift a ban.
+// Placeholders are written as ?, see rebind for numbered placeholders.
+func mergeUserStatements(source, target *models.User, now time.Time) []userStatement {
+	sourceID, targetID := source.ID, target.ID
+	status, suspendedUntil := models.StricterModeration(source, target, now)
+
+	statements := []userStatement{
+		{`UPDATE users SET moderation_status = ?, suspended_until = ? WHERE id = ?`,
+			[]interface{}{string(status), suspendedUntil, targetID}},
+		{`UPDATE games SET user_id = ? WHERE user_id = ?`, []interface{}{targetID, sourceID}},
+		{`UPDATE tournament_attempts SET user_id = ? WHERE user_id = ?`, []interface{}{targetID, sourceID}},
+		{`UPDATE user_identities SET user_id = ? WHERE user_id = ?`, []interface{}{targetID, sourceID}},
+		{`UPDATE api_tokens SET user_id = ? WHERE user_id = ?`, []interface{}{targetID, sourceID}},
+		{`UPDATE moderation_actions SET user_id = ? WHERE user_id = ?`, []interface{}{targetID, sourceID}},
+		{`UPDATE groups SET owner_id = ? WHERE owner_id = ?`, []interface{}{targetID, sourceID}},
+		{`UPDATE follows SET follower_id = ?
+			WHERE follower_id = ? AND followee_id <> ?
+			AND followee_id NOT IN (SELECT followee_id FROM follows WHERE follower_id = ?)`,
+			[]interface{}{targetID, sourceID, targetID, targetID}},
+		{`UPDATE follows SET followee_id = ?
+			WHERE followee_id = ? AND follower_id <> ?
+			AND follower_id NOT IN (SELECT follower_id FROM follows WHERE followee_id = ?)`,
+			[]interface{}{targetID, sourceID, targetID, targetID}},
+	}
+
+	for _, t := range userRowTables {
+		statements = append(statements, userStatement{
+			`UPDATE ` + t.table + ` SET user_id = ?
+			WHERE user_id = ? AND ` + t.key + ` NOT IN (SELECT ` + t.key + ` FROM ` + t.table + ` WHERE user_id = ?)`,
+			[]interface{}{targetID, sourceID, targetID},
+		})
+	}
+
+	// Foreign keys created by GORM do not cascade, so the rows left behind are deleted explicitly
+	for _, t := range userRowTables {
+		statements = append(statements, userStatement{`DELETE FROM ` + t.table + ` WHERE user_id = ?`, []interface{}{sourceID}})
+	}
+	statements = append(statements,
+		userStatement{`DELETE FROM follows WHERE follower_id = ? OR followee_id = ?`, []interface{}{sourceID, sourceID}},
+		userStatement{`DELETE FROM refresh_tokens WHERE user_id = ?`, []interface{}{sourceID}},
+		userStatement{`DELETE FROM users WHERE id = ?`, []interface{}{sourceID}},
+	)
+
+	return statements
+}
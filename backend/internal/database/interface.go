@@ -1,6 +1,14 @@
 package database
 
-import "game2048/pkg/models"
+import (
+	"errors"
+	"time"
+
+	"game2048/pkg/models"
+)
+
+// ErrSeasonNotFound is returned when no season has the given ID
+var ErrSeasonNotFound = errors.New("season not found")
 
 // Database defines the interface for database operations
 type Database interface {
@@ -8,15 +16,140 @@ type Database interface {
 	CreateUser(user *models.User) error
 	GetUser(userID string) (*models.User, error)
 	GetUserByProvider(provider, providerID string) (*models.User, error)
+	UpdateUserSettings(userID string, settings models.UserSettings) error
+	MergeGuestUser(guestID, userID string) error
+	DeleteExpiredGuests(createdBefore time.Time) (int64, error)
+	MergeUsers(sourceID, targetID string) error
+
+	// Identity operations
+	CreateUserIdentity(identity *models.UserIdentity) error
+	UpdateUserIdentity(identity *models.UserIdentity) error
+	GetUserIdentity(provider, providerID string) (*models.UserIdentity, error)
+	GetUserIdentities(userID string) ([]models.UserIdentity, error)
+	DeleteUserIdentity(userID, identityID string) error
 
 	// Game operations
 	CreateGame(game *models.GameState) error
 	UpdateGame(game *models.GameState) error
 	GetGame(gameID, userID string) (*models.GameState, error)
 	GetUserActiveGame(userID string) (*models.GameState, error)
+	GetFinishedGame(gameID string) (*models.GameState, error)
+
+	// Game history operations
+	GetUserGames(userID string, filter models.GameHistoryFilter, limit, offset int) ([]models.GameSummary, error)
+	GetUserGameCount(userID string, filter models.GameHistoryFilter) (int, error)
+	GetUserGameStats(userID string, filter models.GameHistoryFilter, loc *time.Location) (*models.GameStats, error)
 
 	// Leaderboard operations
-	GetLeaderboard(leaderboardType models.LeaderboardType, limit int) ([]models.LeaderboardEntry, error)
+	GetLeaderboard(query models.LeaderboardQuery, limit, offset int) ([]models.LeaderboardEntry, error)
+	GetLeaderboardCount(query models.LeaderboardQuery) (int, error)
+	GetUserRank(query models.LeaderboardQuery, userID string) (*models.LeaderboardEntry, error)
+
+	// Leaderboard snapshot operations
+	SnapshotLeaderboard(leaderboardType models.LeaderboardType, period models.LeaderboardPeriod, limit int) error
+	HasLeaderboardSnapshot(leaderboardType models.LeaderboardType, periodStart time.Time) (bool, error)
+	GetLeaderboardSnapshot(leaderboardType models.LeaderboardType, periodStart time.Time, limit, offset int) ([]models.LeaderboardEntry, error)
+	GetLeaderboardSnapshotCount(leaderboardType models.LeaderboardType, periodStart time.Time) (int, error)
+	GetLeaderboardSnapshotPeriods(leaderboardType models.LeaderboardType, limit int) ([]time.Time, error)
+
+	// Season operations
+	CreateSeason(season *models.Season) error
+	UpdateSeason(season *models.Season) error
+	GetSeason(seasonID string) (*models.Season, error)
+	GetActiveSeason(at time.Time) (*models.Season, error)
+	ListSeasons() ([]models.Season, error)
+	GetSeasonsToClose(endedBefore time.Time) ([]models.Season, error)
+	CloseSeason(seasonID string, limit int) (bool, error)
+	GetSeasonStandings(seasonID string, limit, offset int) ([]models.LeaderboardEntry, error)
+	GetSeasonStandingsCount(seasonID string) (int, error)
+
+	// Badge operations
+	GetUserBadges(userID string) ([]models.UserBadge, error)
+
+	// Achievement operations
+	UnlockAchievement(achievement *models.UserAchievement) (bool, error)
+	GetUserAchievements(userID string) ([]models.UserAchievement, error)
+	GetUserPlayDates(userID string, loc *time.Location, limit int) ([]time.Time, error)
+
+	// Follow operations
+	FollowUser(followerID, followeeID string) error
+	UnfollowUser(followerID, followeeID string) error
+	GetFollowing(userID string) ([]models.Friend, error)
+	GetFollowers(userID string) ([]models.Friend, error)
+
+	// Group operations
+	CreateGroup(group *models.Group) error
+	UpdateGroup(group *models.Group) error
+	DeleteGroup(groupID string) error
+	GetGroup(groupID string) (*models.Group, error)
+	GetGroupByInviteCode(inviteCode string) (*models.Group, error)
+	GetUserGroups(userID string) ([]models.Group, error)
+	AddGroupMember(groupID, userID string) error
+	RemoveGroupMember(groupID, userID string) error
+	IsGroupMember(groupID, userID string) (bool, error)
+	GetGroupMembers(groupID string, limit, offset int) ([]models.GroupMember, error)
+
+	// Webhook operations
+	CreateWebhook(webhook *models.Webhook) error
+	UpdateWebhook(webhook *models.Webhook) error
+	DeleteWebhook(webhookID string) error
+	GetWebhook(webhookID string) (*models.Webhook, error)
+	ListWebhooks() ([]models.Webhook, error)
+	CreateWebhookDelivery(delivery *models.WebhookDelivery) error
+	UpdateWebhookDelivery(delivery *models.WebhookDelivery) error
+	GetWebhookDelivery(deliveryID string) (*models.WebhookDelivery, error)
+	GetWebhookDeliveries(webhookID string, status models.WebhookDeliveryStatus, limit, offset int) ([]models.WebhookDelivery, error)
+	GetDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	GetPreviousBestScore(userID, gameID string) (int, bool, error)
+
+	// Tournament operations
+	CreateTournament(tournament *models.Tournament) error
+	DeleteTournament(tournamentID string) error
+	GetTournament(tournamentID string) (*models.Tournament, error)
+	ListTournaments() ([]models.Tournament, error)
+	GetUnfinishedTournaments() ([]models.Tournament, error)
+	UpdateTournamentStatus(tournamentID string, status models.TournamentStatus) error
+	UpdateTournamentRoundStatus(tournamentID string, round int, status models.TournamentRoundStatus) error
+	RegisterTournamentParticipant(tournamentID, userID string) error
+	UnregisterTournamentParticipant(tournamentID, userID string) error
+	GetTournamentParticipant(tournamentID, userID string) (*models.TournamentParticipant, error)
+	GetTournamentParticipants(tournamentID string) ([]models.TournamentParticipant, error)
+	EliminateTournamentParticipants(tournamentID string, round int, advancing []string) error
+	SetTournamentFinalRanks(tournamentID string, ranks map[string]int) error
+	CreateTournamentAttempt(attempt *models.TournamentAttempt) error
+	CountTournamentAttempts(tournamentID string, round int, userID string) (int, error)
+	GetUnfinishedTournamentAttempts(tournamentID string, round int) ([]models.TournamentAttempt, error)
+	GetTournamentAttemptRound(gameID string) (*models.TournamentRound, error)
+
+	// Refresh token operations
+	CreateRefreshToken(token *models.RefreshToken) error
+	GetRefreshToken(tokenHash string) (*models.RefreshToken, error)
+	RotateRefreshToken(tokenID string, next *models.RefreshToken) (bool, error)
+	RevokeRefreshTokenFamily(familyID string) error
+	RevokeUserRefreshTokens(userID string) error
+	DeleteExpiredRefreshTokens(before time.Time) (int64, error)
+
+	// API token operations
+	CreateAPIToken(token *models.APIToken) error
+	GetAPIToken(tokenHash string) (*models.APIToken, error)
+	GetUserAPITokens(userID string) ([]models.APIToken, error)
+	RevokeAPIToken(userID, tokenID string) error
+	TouchAPIToken(tokenID string, usedAt time.Time) error
+
+	// Moderation operations
+	ModerateUser(action *models.ModerationAction) error
+	GetModerationActions(userID string) ([]models.ModerationAction, error)
+	GetModeratedUsers() ([]models.UserModeration, error)
+
+	// Role operations
+	ListRoles() ([]models.Role, error)
+	GetRole(name string) (*models.Role, error)
+	SaveRole(role *models.Role) error
+	DeleteRole(name string) error
+	GrantRole(userID, role, grantedBy string) error
+	RevokeRole(userID, role string) error
+	GetUserRoles(userID string) ([]models.UserRole, error)
+	GetUserPermissions(userID string) ([]models.Permission, error)
 
 	// Connection management
 	Close() error
@@ -0,0 +1,6 @@
+package database
+
+import "game2048/pkg/models"
+
+// hiddenModerationStatuses are the moderation statuses of users kept off leaderboards
+var hiddenModerationStatuses = []string{string(models.ModerationBanned), string(models.ModerationShadowBanned)}
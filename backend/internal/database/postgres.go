@@ -9,7 +9,8 @@ import (
 
 	"game2048/pkg/models"
 
-	_ "github.com/lib/pq"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // PostgresDB wraps the database connection and implements Database interface
@@ -53,23 +54,24 @@ func (p *PostgresDB) Close() error {
 // CreateUser creates a new user
 func (p *PostgresDB) CreateUser(user *models.User) error {
 	query := `
-		INSERT INTO users (id, email, name, avatar, provider, provider_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO users (id, email, name, avatar, provider, provider_id, is_guest, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (provider, provider_id) 
 		DO UPDATE SET 
 			email = EXCLUDED.email,
 			name = EXCLUDED.name,
 			avatar = EXCLUDED.avatar,
 			updated_at = EXCLUDED.updated_at
-		RETURNING id, created_at, updated_at`
+		RETURNING id, profile_hidden, is_guest, created_at, updated_at, moderation_status, suspended_until`
 
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
 
 	err := p.db.QueryRow(query, user.ID, user.Email, user.Name, user.Avatar,
-		user.Provider, user.ProviderID, user.CreatedAt, user.UpdatedAt).
-		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+		user.Provider, user.ProviderID, user.IsGuest, user.CreatedAt, user.UpdatedAt).
+		Scan(&user.ID, &user.ProfileHidden, &user.IsGuest, &user.CreatedAt, &user.UpdatedAt,
+			&user.ModerationStatus, &user.SuspendedUntil)
 
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
@@ -81,13 +83,15 @@ func (p *PostgresDB) CreateUser(user *models.User) error {
 // GetUser retrieves a user by ID
 func (p *PostgresDB) GetUser(userID string) (*models.User, error) {
 	query := `
-		SELECT id, email, name, avatar, provider, provider_id, created_at, updated_at
+		SELECT id, email, name, avatar, provider, provider_id, profile_hidden, is_guest, created_at, updated_at,
+			moderation_status, suspended_until
 		FROM users WHERE id = $1`
 
 	user := &models.User{}
 	err := p.db.QueryRow(query, userID).Scan(
 		&user.ID, &user.Email, &user.Name, &user.Avatar,
-		&user.Provider, &user.ProviderID, &user.CreatedAt, &user.UpdatedAt)
+		&user.Provider, &user.ProviderID, &user.ProfileHidden, &user.IsGuest, &user.CreatedAt, &user.UpdatedAt,
+		&user.ModerationStatus, &user.SuspendedUntil)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -102,13 +106,15 @@ func (p *PostgresDB) GetUser(userID string) (*models.User, error) {
 // GetUserByProvider retrieves a user by provider and provider ID
 func (p *PostgresDB) GetUserByProvider(provider, providerID string) (*models.User, error) {
 	query := `
-		SELECT id, email, name, avatar, provider, provider_id, created_at, updated_at
+		SELECT id, email, name, avatar, provider, provider_id, profile_hidden, is_guest, created_at, updated_at,
+			moderation_status, suspended_until
 		FROM users WHERE provider = $1 AND provider_id = $2`
 
 	user := &models.User{}
 	err := p.db.QueryRow(query, provider, providerID).Scan(
 		&user.ID, &user.Email, &user.Name, &user.Avatar,
-		&user.Provider, &user.ProviderID, &user.CreatedAt, &user.UpdatedAt)
+		&user.Provider, &user.ProviderID, &user.ProfileHidden, &user.IsGuest, &user.CreatedAt, &user.UpdatedAt,
+		&user.ModerationStatus, &user.SuspendedUntil)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -120,6 +126,293 @@ func (p *PostgresDB) GetUserByProvider(provider, providerID string) (*models.Use
 	return user, nil
 }
 
+// UpdateUserSettings updates the settings a user can change
+func (p *PostgresDB) UpdateUserSettings(userID string, settings models.UserSettings) error {
+	query := `UPDATE users SET profile_hidden = $2, updated_at = $3 WHERE id = $1`
+
+	result, err := p.db.Exec(query, userID, settings.ProfileHidden, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update user settings: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// MergeGuestUser moves a guest's games and achievements to a user and deletes the guest.
+// Achievements the user already has keep their original unlock.
+func (p *PostgresDB) MergeGuestUser(guestID, userID string) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the guest so that concurrent merges cannot both move its games
+	var isGuest bool
+	err = tx.QueryRow(`SELECT is_guest FROM users WHERE id = $1 FOR UPDATE`, guestID).Scan(&isGuest)
+	if err == sql.ErrNoRows || (err == nil && !isGuest) {
+		return fmt.Errorf("guest not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock guest: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE games SET user_id = $2 WHERE user_id = $1`, guestID, userID); err != nil {
+		return fmt.Errorf("failed to move guest games: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_achievements (user_id, achievement_id, game_id, unlocked_at)
+		SELECT $2, achievement_id, game_id, unlocked_at FROM user_achievements WHERE user_id = $1
+		ON CONFLICT (user_id, achievement_id) DO NOTHING`, guestID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to move guest achievements: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM users WHERE id = $1`, guestID); err != nil {
+		return fmt.Errorf("failed to delete guest: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit guest merge: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredGuests deletes guests created before the given time together with their games and returns how many were deleted
+func (p *PostgresDB) DeleteExpiredGuests(createdBefore time.Time) (int64, error) {
+	result, err := p.db.Exec(`DELETE FROM users WHERE is_guest = true AND created_at < $1`, createdBefore)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired guests: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// MergeUsers moves the games, identities and everything else of a source user to a target user and deletes the source
+func (p *PostgresDB) MergeUsers(sourceID, targetID string) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock both users in a fixed order so that concurrent merges cannot deadlock
+	rows, err := tx.Query(`
+		SELECT id, moderation_status, suspended_until FROM users WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`,
+		sourceID, targetID)
+	if err != nil {
+		return fmt.Errorf("failed to lock users: %w", err)
+	}
+	users := make(map[string]*models.User)
+	for rows.Next() {
+		user := &models.User{}
+		if err := rows.Scan(&user.ID, &user.ModerationStatus, &user.SuspendedUntil); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to lock users: %w", err)
+		}
+		users[user.ID] = user
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to lock users: %w", err)
+	}
+	if users[sourceID] == nil || users[targetID] == nil {
+		return fmt.Errorf("user not found")
+	}
+
+	for _, statement := range mergeUserStatements(users[sourceID], users[targetID], time.Now()) {
+		if _, err := tx.Exec(rebind(statement.query, 0), statement.args...); err != nil {
+			return fmt.Errorf("failed to merge users: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user merge: %w", err)
+	}
+
+	return nil
+}
+
+// CreateUserIdentity links a provider identity to a user
+func (p *PostgresDB) CreateUserIdentity(identity *models.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (id, user_id, provider, provider_id, email, name, avatar, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	if identity.ID == uuid.Nil {
+		identity.ID = uuid.New()
+	}
+	now := time.Now()
+	identity.CreatedAt = now
+	if identity.LastUsedAt.IsZero() {
+		identity.LastUsedAt = now
+	}
+
+	_, err := p.db.Exec(query, identity.ID, identity.UserID, identity.Provider, identity.ProviderID,
+		identity.Email, identity.Name, identity.Avatar, identity.CreatedAt, identity.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create user identity: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateUserIdentity updates the profile of an identity and when it was last used
+func (p *PostgresDB) UpdateUserIdentity(identity *models.UserIdentity) error {
+	query := `
+		UPDATE user_identities SET email = $2, name = $3, avatar = $4, last_used_at = $5
+		WHERE id = $1`
+
+	result, err := p.db.Exec(query, identity.ID, identity.Email, identity.Name, identity.Avatar, identity.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update user identity: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrIdentityNotFound
+	}
+
+	return nil
+}
+
+// GetUserIdentity retrieves an identity by provider and provider ID
+func (p *PostgresDB) GetUserIdentity(provider, providerID string) (*models.UserIdentity, error) {
+	query := `
+		SELECT i.id, i.user_id, i.provider, i.provider_id, i.email, i.name, i.avatar, i.created_at, i.last_used_at,
+			i.provider = u.provider AND i.provider_id = u.provider_id
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.provider = $1 AND i.provider_id = $2`
+
+	identity := &models.UserIdentity{}
+	err := p.db.QueryRow(query, provider, providerID).Scan(
+		&identity.ID, &identity.UserID, &identity.Provider, &identity.ProviderID,
+		&identity.Email, &identity.Name, &identity.Avatar, &identity.CreatedAt, &identity.LastUsedAt, &identity.Primary)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, fmt.Errorf("failed to get user identity: %w", err)
+	}
+
+	return identity, nil
+}
+
+// GetUserIdentities retrieves the identities of a user, oldest first
+func (p *PostgresDB) GetUserIdentities(userID string) ([]models.UserIdentity, error) {
+	query := `
+		SELECT i.id, i.user_id, i.provider, i.provider_id, i.email, i.name, i.avatar, i.created_at, i.last_used_at,
+			i.provider = u.provider AND i.provider_id = u.provider_id
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.user_id = $1
+		ORDER BY i.created_at ASC`
+
+	rows, err := p.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user identities: %w", err)
+	}
+	defer rows.Close()
+
+	identities := []models.UserIdentity{}
+	for rows.Next() {
+		var identity models.UserIdentity
+		err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.ProviderID,
+			&identity.Email, &identity.Name, &identity.Avatar, &identity.CreatedAt, &identity.LastUsedAt, &identity.Primary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
+
+// DeleteUserIdentity unlinks an identity from a user, the last identity of a user cannot be unlinked.
+// When the primary identity is unlinked, the most recently used remaining identity becomes primary.
+func (p *PostgresDB) DeleteUserIdentity(userID, identityID string) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the user so that concurrent unlinks cannot remove every identity
+	var provider, providerID string
+	err = tx.QueryRow(`SELECT provider, provider_id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&provider, &providerID)
+	if err == sql.ErrNoRows {
+		return ErrIdentityNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock user: %w", err)
+	}
+
+	var primary bool
+	err = tx.QueryRow(`
+		SELECT provider = $3 AND provider_id = $4 FROM user_identities WHERE id = $1 AND user_id = $2`,
+		identityID, userID, provider, providerID).Scan(&primary)
+	if err == sql.ErrNoRows {
+		return ErrIdentityNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user identity: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM user_identities WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count user identities: %w", err)
+	}
+	if count <= 1 {
+		return ErrLastIdentity
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_identities WHERE id = $1`, identityID); err != nil {
+		return fmt.Errorf("failed to delete user identity: %w", err)
+	}
+
+	if primary {
+		_, err := tx.Exec(`
+			UPDATE users SET provider = i.provider, provider_id = i.provider_id,
+				email = i.email, name = i.name, avatar = i.avatar, updated_at = $2
+			FROM (
+				SELECT provider, provider_id, email, name, avatar FROM user_identities
+				WHERE user_id = $1 ORDER BY last_used_at DESC LIMIT 1
+			) i
+			WHERE users.id = $1`, userID, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to change primary identity: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit identity unlink: %w", err)
+	}
+
+	return nil
+}
+
 // CreateGame creates a new game
 func (p *PostgresDB) CreateGame(game *models.GameState) error {
 	boardJSON, err := json.Marshal(game.Board)
@@ -128,15 +421,22 @@ func (p *PostgresDB) CreateGame(game *models.GameState) error {
 	}
 
 	query := `
-		INSERT INTO games (id, user_id, board, score, game_over, victory, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+		INSERT INTO games (id, user_id, board, score, game_over, victory, mode, seed, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
 
+	if game.Mode == "" {
+		game.Mode = models.GameModeClassic
+	}
+
+	// Games that were only cached so far keep the time they were started
 	now := time.Now()
-	game.CreatedAt = now
+	if game.CreatedAt.IsZero() {
+		game.CreatedAt = now
+	}
 	game.UpdatedAt = now
 
 	_, err = p.db.Exec(query, game.ID, game.UserID, boardJSON, game.Score,
-		game.GameOver, game.Victory, game.CreatedAt, game.UpdatedAt)
+		game.GameOver, game.Victory, game.Mode, game.Seed, game.CreatedAt, game.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create game: %w", err)
@@ -181,7 +481,7 @@ func (p *PostgresDB) UpdateGame(game *models.GameState) error {
 // GetGame retrieves a game by ID and user ID
 func (p *PostgresDB) GetGame(gameID, userID string) (*models.GameState, error) {
 	query := `
-		SELECT id, user_id, board, score, game_over, victory, created_at, updated_at
+		SELECT id, user_id, board, score, game_over, victory, mode, seed, created_at, updated_at
 		FROM games WHERE id = $1 AND user_id = $2`
 
 	game := &models.GameState{}
@@ -189,7 +489,34 @@ func (p *PostgresDB) GetGame(gameID, userID string) (*models.GameState, error) {
 
 	err := p.db.QueryRow(query, gameID, userID).Scan(
 		&game.ID, &game.UserID, &boardJSON, &game.Score,
-		&game.GameOver, &game.Victory, &game.CreatedAt, &game.UpdatedAt)
+		&game.GameOver, &game.Victory, &game.Mode, &game.Seed, &game.CreatedAt, &game.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("game not found")
+		}
+		return nil, fmt.Errorf("failed to get game: %w", err)
+	}
+
+	if err := json.Unmarshal(boardJSON, &game.Board); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal board: %w", err)
+	}
+
+	return game, nil
+}
+
+// GetFinishedGame retrieves a finished game of any user, for public sharing
+func (p *PostgresDB) GetFinishedGame(gameID string) (*models.GameState, error) {
+	query := `
+		SELECT id, user_id, board, score, game_over, victory, mode, seed, created_at, updated_at
+		FROM games WHERE id = $1 AND (game_over = true OR victory = true)`
+
+	game := &models.GameState{}
+	var boardJSON []byte
+
+	err := p.db.QueryRow(query, gameID).Scan(
+		&game.ID, &game.UserID, &boardJSON, &game.Score,
+		&game.GameOver, &game.Victory, &game.Mode, &game.Seed, &game.CreatedAt, &game.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -208,7 +535,7 @@ func (p *PostgresDB) GetGame(gameID, userID string) (*models.GameState, error) {
 // GetUserActiveGame retrieves the user's active (non-finished) game
 func (p *PostgresDB) GetUserActiveGame(userID string) (*models.GameState, error) {
 	query := `
-		SELECT id, user_id, board, score, game_over, victory, created_at, updated_at
+		SELECT id, user_id, board, score, game_over, victory, mode, seed, created_at, updated_at
 		FROM games 
 		WHERE user_id = $1 AND game_over = false AND victory = false
 		ORDER BY updated_at DESC
@@ -219,7 +546,7 @@ func (p *PostgresDB) GetUserActiveGame(userID string) (*models.GameState, error)
 
 	err := p.db.QueryRow(query, userID).Scan(
 		&game.ID, &game.UserID, &boardJSON, &game.Score,
-		&game.GameOver, &game.Victory, &game.CreatedAt, &game.UpdatedAt)
+		&game.GameOver, &game.Victory, &game.Mode, &game.Seed, &game.CreatedAt, &game.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -235,12 +562,158 @@ func (p *PostgresDB) GetUserActiveGame(userID string) (*models.GameState, error)
 	return game, nil
 }
 
-// GetLeaderboard retrieves leaderboard entries
-func (p *PostgresDB) GetLeaderboard(leaderboardType models.LeaderboardType, limit int) ([]models.LeaderboardEntry, error) {
-	var query string
+// GetUserGames retrieves a page of a user's games matching the filter, most recent first
+func (p *PostgresDB) GetUserGames(userID string, filter models.GameHistoryFilter, limit, offset int) ([]models.GameSummary, error) {
+	now := time.Now()
+	conditions, args := gameHistoryConditions(userID, filter, now)
+
+	query := rebind(`
+		SELECT id, mode, score, `+bestTileExpr+` as best_tile, game_over, victory, created_at, updated_at
+		FROM games
+		WHERE `+conditions+`
+		ORDER BY created_at DESC, id ASC
+		LIMIT ? OFFSET ?`, 0)
+	args = append(args, limit, offset)
+
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user games: %w", err)
+	}
+	defer rows.Close()
+
+	games := []models.GameSummary{}
+	for rows.Next() {
+		var game models.GameSummary
+		err := rows.Scan(&game.ID, &game.Mode, &game.Score, &game.BestTile,
+			&game.GameOver, &game.Victory, &game.CreatedAt, &game.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan game: %w", err)
+		}
+		game.Status = models.GameStatusOf(game.GameOver, game.Victory, game.UpdatedAt, now)
+		games = append(games, game)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating games: %w", err)
+	}
+
+	return games, nil
+}
+
+// GetUserGameCount returns the number of a user's games matching the filter
+func (p *PostgresDB) GetUserGameCount(userID string, filter models.GameHistoryFilter) (int, error) {
+	conditions, args := gameHistoryConditions(userID, filter, time.Now())
+
+	var total int
+	if err := p.db.QueryRow(rebind(`SELECT COUNT(*) FROM games WHERE `+conditions, 0), args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count user games: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetUserGameStats computes statistics over a user's games matching the filter.
+// The score trend is grouped by day in the given timezone.
+func (p *PostgresDB) GetUserGameStats(userID string, filter models.GameHistoryFilter, loc *time.Location) (*models.GameStats, error) {
+	conditions, args := gameHistoryConditions(userID, filter, time.Now())
+
+	stats := &models.GameStats{}
+	err := p.db.QueryRow(rebind(gameStatsQuery(conditions), 0), args...).Scan(
+		&stats.GamesPlayed, &stats.Wins, &stats.AverageScore, &stats.MedianScore, &stats.BestScore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game stats: %w", err)
+	}
+	if stats.GamesPlayed > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.GamesPlayed)
+	}
+
+	rows, err := p.db.Query(rebind(bestTilesQuery(conditions), 0), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get best tile distribution: %w", err)
+	}
+	defer rows.Close()
+
+	stats.BestTiles = []models.TileCount{}
+	for rows.Next() {
+		var tile models.TileCount
+		if err := rows.Scan(&tile.Tile, &tile.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan best tile count: %w", err)
+		}
+		stats.BestTiles = append(stats.BestTiles, tile)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating best tile counts: %w", err)
+	}
+
+	trendArgs := append([]interface{}{loc.String()}, args...)
+	trendRows, err := p.db.Query(rebind(scoreTrendQuery(conditions), 0), trendArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get score trend: %w", err)
+	}
+	defer trendRows.Close()
+
+	stats.ScoreTrend = []models.ScoreTrendPoint{}
+	for trendRows.Next() {
+		var point models.ScoreTrendPoint
+		if err := trendRows.Scan(&point.Date, &point.Games, &point.AverageScore, &point.BestScore); err != nil {
+			return nil, fmt.Errorf("failed to scan score trend: %w", err)
+		}
+		stats.ScoreTrend = append(stats.ScoreTrend, point)
+	}
+	if err := trendRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating score trend: %w", err)
+	}
+
+	return stats, nil
+}
+
+// rankedLeaderboardQuery returns SQL selecting each user's best finished game together with its rank.
+// Its placeholders are numbered from $1, further placeholders must continue after len(args).
+func rankedLeaderboardQuery(query models.LeaderboardQuery) (string, []interface{}) {
+	var filter string
 	var args []interface{}
 
-	baseQuery := `
+	// Period bounds are computed in the leaderboard timezone, not the database session timezone
+	if query.Period != nil {
+		filter = fmt.Sprintf(` AND created_at >= $%d AND created_at < $%d`, len(args)+1, len(args)+2)
+		args = append(args, query.Period.Start, query.Period.End)
+	}
+
+	// Friends and group leaderboards rank only their own users
+	if query.FriendsOf != "" {
+		filter += fmt.Sprintf(` AND (user_id = $%d OR user_id IN (SELECT followee_id FROM follows WHERE follower_id = $%d))`,
+			len(args)+1, len(args)+1)
+		args = append(args, query.FriendsOf)
+	}
+	if query.GroupID != "" {
+		filter += fmt.Sprintf(` AND user_id IN (SELECT user_id FROM group_members WHERE group_id = $%d)`, len(args)+1)
+		args = append(args, query.GroupID)
+	}
+
+	// Tournament attempts are played on fixed seeds and only ranked on their round
+	if query.TournamentID != "" {
+		filter += fmt.Sprintf(` AND id IN (SELECT game_id FROM tournament_attempts WHERE tournament_id = $%d AND round = $%d)`,
+			len(args)+1, len(args)+2)
+		args = append(args, query.TournamentID, query.TournamentRound)
+	} else {
+		filter += ` AND mode <> '` + models.GameModeTournament + `'`
+	}
+
+	return rankBestScoresQuery(filter, args, query.Viewer)
+}
+
+// rankBestScoresQuery returns SQL picking each user's best finished game matching the filter and ranking users by it.
+// Hidden users are left out, except a shadow-banned viewer. The filter's placeholders are numbered up to len(args).
+func rankBestScoresQuery(filter string, args []interface{}, viewer string) (string, []interface{}) {
+	visible := fmt.Sprintf(`u.moderation_status <> ALL($%d)`, len(args)+1)
+	args = append(args, pq.Array(hiddenModerationStatuses))
+	if viewer != "" {
+		visible = fmt.Sprintf(`(%s OR (u.id = $%d AND u.moderation_status = $%d))`, visible, len(args)+1, len(args)+2)
+		args = append(args, viewer, string(models.ModerationShadowBanned))
+	}
+
+	// Rank over the whole board so that paging and per-user lookups agree on positions
+	return `
 		SELECT
 			g.user_id,
 			u.name as user_name,
@@ -248,44 +721,37 @@ func (p *PostgresDB) GetLeaderboard(leaderboardType models.LeaderboardType, limi
 			g.score,
 			g.id as game_id,
 			g.created_at,
-			ROW_NUMBER() OVER (ORDER BY g.score DESC) as rank
+			ROW_NUMBER() OVER (ORDER BY g.score DESC, g.created_at ASC, g.user_id ASC) as rank
 		FROM (
 			SELECT
 				user_id,
 				MAX(score) as score,
-				(ARRAY_AGG(id ORDER BY score DESC))[1] as id,
-				(ARRAY_AGG(created_at ORDER BY score DESC))[1] as created_at
+				(ARRAY_AGG(id ORDER BY score DESC, created_at ASC))[1] as id,
+				(ARRAY_AGG(created_at ORDER BY score DESC, created_at ASC))[1] as created_at
 			FROM games
-			WHERE (game_over = true OR victory = true)`
-
-	var timeFilter string
-	switch leaderboardType {
-	case models.LeaderboardDaily:
-		timeFilter = ` AND created_at >= CURRENT_DATE`
-	case models.LeaderboardWeekly:
-		timeFilter = ` AND created_at >= DATE_TRUNC('week', CURRENT_DATE)`
-	case models.LeaderboardMonthly:
-		timeFilter = ` AND created_at >= DATE_TRUNC('month', CURRENT_DATE)`
-	case models.LeaderboardAll:
-		timeFilter = ""
-	default:
-		return nil, fmt.Errorf("invalid leaderboard type")
-	}
-
-	query = baseQuery + timeFilter + `
+			WHERE (game_over = true OR victory = true)` + filter + `
 			GROUP BY user_id
 		) g
 		JOIN users u ON g.user_id = u.id
-		ORDER BY g.score DESC LIMIT $1`
-	args = append(args, limit)
+		WHERE u.is_guest = false AND ` + visible, args
+}
 
-	rows, err := p.db.Query(query, args...)
+// GetLeaderboard retrieves a page of leaderboard entries
+func (p *PostgresDB) GetLeaderboard(query models.LeaderboardQuery, limit, offset int) ([]models.LeaderboardEntry, error) {
+	rankedQuery, args := rankedLeaderboardQuery(query)
+
+	sqlQuery := fmt.Sprintf(`SELECT user_id, user_name, user_avatar, score, game_id, created_at, rank
+		FROM (`+rankedQuery+`) r
+		ORDER BY rank ASC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := p.db.Query(sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
 	}
 	defer rows.Close()
 
-	var entries []models.LeaderboardEntry
+	entries := []models.LeaderboardEntry{}
 	for rows.Next() {
 		var entry models.LeaderboardEntry
 		err := rows.Scan(
@@ -303,3 +769,2082 @@ func (p *PostgresDB) GetLeaderboard(leaderboardType models.LeaderboardType, limi
 
 	return entries, nil
 }
+
+// GetLeaderboardCount returns the number of users ranked on a leaderboard
+func (p *PostgresDB) GetLeaderboardCount(query models.LeaderboardQuery) (int, error) {
+	rankedQuery, args := rankedLeaderboardQuery(query)
+
+	var total int
+	if err := p.db.QueryRow(`SELECT COUNT(*) FROM (`+rankedQuery+`) r`, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count leaderboard entries: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetUserRank retrieves a user's entry on a leaderboard, or nil if the user is not ranked
+func (p *PostgresDB) GetUserRank(query models.LeaderboardQuery, userID string) (*models.LeaderboardEntry, error) {
+	rankedQuery, args := rankedLeaderboardQuery(query)
+
+	sqlQuery := fmt.Sprintf(`SELECT user_id, user_name, user_avatar, score, game_id, created_at, rank
+		FROM (`+rankedQuery+`) r
+		WHERE user_id = $%d`, len(args)+1)
+	args = append(args, userID)
+
+	entry := &models.LeaderboardEntry{}
+	err := p.db.QueryRow(sqlQuery, args...).Scan(
+		&entry.UserID, &entry.UserName, &entry.UserAvatar,
+		&entry.Score, &entry.GameID, &entry.CreatedAt, &entry.Rank)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // User has no finished games on this leaderboard
+		}
+		return nil, fmt.Errorf("failed to get user rank: %w", err)
+	}
+
+	return entry, nil
+}
+
+// SnapshotLeaderboard freezes the top entries of a finished period into its snapshot table.
+// Any existing snapshot for the same period is replaced.
+func (p *PostgresDB) SnapshotLeaderboard(leaderboardType models.LeaderboardType, period models.LeaderboardPeriod, limit int) error {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return err
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	periodDate := snapshotDate(period.Start)
+
+	if _, err := tx.Exec(`DELETE FROM `+table+` WHERE `+column+` = $1`, periodDate); err != nil {
+		return fmt.Errorf("failed to clear leaderboard snapshot: %w", err)
+	}
+
+	rankedQuery, args := rankedLeaderboardQuery(models.LeaderboardQuery{Type: leaderboardType, Period: &period})
+
+	query := fmt.Sprintf(`
+		INSERT INTO `+table+` (user_id, user_name, user_avatar, score, rank, game_id, `+column+`, created_at)
+		SELECT user_id, user_name, user_avatar, score, rank, game_id, $%d, CURRENT_TIMESTAMP
+		FROM (`+rankedQuery+`) r
+		WHERE rank <= $%d`, len(args)+1, len(args)+2)
+	args = append(args, periodDate, limit)
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to write leaderboard snapshot: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit leaderboard snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// HasLeaderboardSnapshot checks whether a period has already been frozen
+func (p *PostgresDB) HasLeaderboardSnapshot(leaderboardType models.LeaderboardType, periodStart time.Time) (bool, error) {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE ` + column + ` = $1)`
+	if err := p.db.QueryRow(query, snapshotDate(periodStart)).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check leaderboard snapshot: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetLeaderboardSnapshot retrieves a page of a frozen leaderboard.
+// Users hidden since the snapshot was taken are left out and the others move up, an unban restores them.
+func (p *PostgresDB) GetLeaderboardSnapshot(leaderboardType models.LeaderboardType, periodStart time.Time, limit, offset int) ([]models.LeaderboardEntry, error) {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT s.user_id, s.user_name, COALESCE(s.user_avatar, ''), s.score, s.game_id,
+			COALESCE(g.created_at, s.created_at), ROW_NUMBER() OVER (ORDER BY s.rank ASC)
+		FROM ` + table + ` s
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN games g ON g.id = s.game_id
+		WHERE s.` + column + ` = $1 AND u.moderation_status <> ALL($4)
+		ORDER BY s.rank ASC LIMIT $2 OFFSET $3`
+
+	rows, err := p.db.Query(query, snapshotDate(periodStart), limit, offset, pq.Array(hiddenModerationStatuses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard snapshot: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.LeaderboardEntry{}
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		err := rows.Scan(
+			&entry.UserID, &entry.UserName, &entry.UserAvatar,
+			&entry.Score, &entry.GameID, &entry.CreatedAt, &entry.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard snapshot entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating leaderboard snapshot rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetLeaderboardSnapshotCount returns the number of entries in a frozen leaderboard
+func (p *PostgresDB) GetLeaderboardSnapshotCount(leaderboardType models.LeaderboardType, periodStart time.Time) (int, error) {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int
+	query := `
+		SELECT COUNT(*) FROM ` + table + ` s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.` + column + ` = $1 AND u.moderation_status <> ALL($2)`
+	if err := p.db.QueryRow(query, snapshotDate(periodStart), pq.Array(hiddenModerationStatuses)).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count leaderboard snapshot entries: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetLeaderboardSnapshotPeriods lists the start dates of frozen periods, most recent first
+func (p *PostgresDB) GetLeaderboardSnapshotPeriods(leaderboardType models.LeaderboardType, limit int) ([]time.Time, error) {
+	table, column, err := snapshotTable(leaderboardType)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT DISTINCT ` + column + ` FROM ` + table + ` ORDER BY ` + column + ` DESC LIMIT $1`
+	rows, err := p.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leaderboard snapshot periods: %w", err)
+	}
+	defer rows.Close()
+
+	periods := []time.Time{}
+	for rows.Next() {
+		var period time.Time
+		if err := rows.Scan(&period); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard snapshot period: %w", err)
+		}
+		periods = append(periods, period)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating leaderboard snapshot periods: %w", err)
+	}
+
+	return periods, nil
+}
+
+// seasonColumns lists the seasons columns in the order scanSeason expects
+const seasonColumns = `id, name, start_at, end_at, reward_top_n, closed_at, created_at, updated_at`
+
+// scanSeason scans a season row
+func scanSeason(row interface{ Scan(...interface{}) error }) (*models.Season, error) {
+	season := &models.Season{}
+	var closedAt sql.NullTime
+	err := row.Scan(&season.ID, &season.Name, &season.StartAt, &season.EndAt,
+		&season.RewardTopN, &closedAt, &season.CreatedAt, &season.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		season.ClosedAt = &closedAt.Time
+	}
+	return season, nil
+}
+
+// querySeasons runs a query returning season rows
+func (p *PostgresDB) querySeasons(query string, args ...interface{}) ([]models.Season, error) {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seasons := []models.Season{}
+	for rows.Next() {
+		season, err := scanSeason(rows)
+		if err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, *season)
+	}
+
+	return seasons, rows.Err()
+}
+
+// CreateSeason creates a new season
+func (p *PostgresDB) CreateSeason(season *models.Season) error {
+	if season.ID == uuid.Nil {
+		season.ID = uuid.New()
+	}
+
+	query := `
+		INSERT INTO seasons (id, name, start_at, end_at, reward_top_n, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	now := time.Now()
+	season.CreatedAt = now
+	season.UpdatedAt = now
+
+	_, err := p.db.Exec(query, season.ID, season.Name, season.StartAt, season.EndAt,
+		season.RewardTopN, season.CreatedAt, season.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create season: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSeason updates the name, dates and rewards of a season
+func (p *PostgresDB) UpdateSeason(season *models.Season) error {
+	query := `
+		UPDATE seasons
+		SET name = $1, start_at = $2, end_at = $3, reward_top_n = $4, updated_at = $5
+		WHERE id = $6`
+
+	season.UpdatedAt = time.Now()
+
+	result, err := p.db.Exec(query, season.Name, season.StartAt, season.EndAt,
+		season.RewardTopN, season.UpdatedAt, season.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update season: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("season not found")
+	}
+
+	return nil
+}
+
+// GetSeason retrieves a season by ID
+func (p *PostgresDB) GetSeason(seasonID string) (*models.Season, error) {
+	if _, err := uuid.Parse(seasonID); err != nil {
+		return nil, ErrSeasonNotFound
+	}
+
+	query := `SELECT ` + seasonColumns + ` FROM seasons WHERE id = $1`
+
+	season, err := scanSeason(p.db.QueryRow(query, seasonID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSeasonNotFound
+		}
+		return nil, fmt.Errorf("failed to get season: %w", err)
+	}
+
+	return season, nil
+}
+
+// GetActiveSeason retrieves the season running at the given time, or nil if there is none
+func (p *PostgresDB) GetActiveSeason(at time.Time) (*models.Season, error) {
+	query := `
+		SELECT ` + seasonColumns + ` FROM seasons
+		WHERE start_at <= $1 AND end_at > $1
+		ORDER BY start_at DESC
+		LIMIT 1`
+
+	season, err := scanSeason(p.db.QueryRow(query, at))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No season is running
+		}
+		return nil, fmt.Errorf("failed to get active season: %w", err)
+	}
+
+	return season, nil
+}
+
+// ListSeasons retrieves all seasons, most recent first
+func (p *PostgresDB) ListSeasons() ([]models.Season, error) {
+	seasons, err := p.querySeasons(`SELECT ` + seasonColumns + ` FROM seasons ORDER BY start_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list seasons: %w", err)
+	}
+
+	return seasons, nil
+}
+
+// GetSeasonsToClose retrieves seasons that ended before the given time but are not closed yet
+func (p *PostgresDB) GetSeasonsToClose(endedBefore time.Time) ([]models.Season, error) {
+	query := `
+		SELECT ` + seasonColumns + ` FROM seasons
+		WHERE end_at <= $1 AND closed_at IS NULL
+		ORDER BY end_at ASC`
+
+	seasons, err := p.querySeasons(query, endedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seasons to close: %w", err)
+	}
+
+	return seasons, nil
+}
+
+// CloseSeason freezes the top entries of a season into its final standings,
+// awards badges to the top finishers and marks the season closed.
+// It reports whether this call closed the season, closing an already closed season is a no-op.
+func (p *PostgresDB) CloseSeason(seasonID string, limit int) (bool, error) {
+	season, err := p.GetSeason(seasonID)
+	if err != nil {
+		return false, err
+	}
+
+	if season.IsClosed() {
+		return false, nil
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the season row so that concurrent closes cannot both archive it
+	var closedAt sql.NullTime
+	if err := tx.QueryRow(`SELECT closed_at FROM seasons WHERE id = $1 FOR UPDATE`, season.ID).Scan(&closedAt); err != nil {
+		return false, fmt.Errorf("failed to lock season: %w", err)
+	}
+	if closedAt.Valid {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM season_standings WHERE season_id = $1`, season.ID); err != nil {
+		return false, fmt.Errorf("failed to clear season standings: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM user_badges WHERE season_id = $1`, season.ID); err != nil {
+		return false, fmt.Errorf("failed to clear season badges: %w", err)
+	}
+
+	rankedQuery, args := rankedLeaderboardQuery(season.Query())
+	query := fmt.Sprintf(`
+		INSERT INTO season_standings (season_id, user_id, user_name, user_avatar, score, rank, game_id, created_at)
+		SELECT $%d, user_id, user_name, user_avatar, score, rank, game_id, CURRENT_TIMESTAMP
+		FROM (`+rankedQuery+`) r
+		WHERE rank <= $%d`, len(args)+1, len(args)+2)
+	args = append(args, season.ID, limit)
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return false, fmt.Errorf("failed to write season standings: %w", err)
+	}
+
+	rows, err := tx.Query(`SELECT user_id, rank FROM season_standings WHERE season_id = $1 AND rank <= $2`,
+		season.ID, season.RewardTopN)
+	if err != nil {
+		return false, fmt.Errorf("failed to query season winners: %w", err)
+	}
+
+	type winner struct {
+		userID string
+		rank   int
+	}
+	var winners []winner
+	for rows.Next() {
+		var w winner
+		if err := rows.Scan(&w.userID, &w.rank); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("failed to scan season winner: %w", err)
+		}
+		winners = append(winners, w)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("error iterating season winners: %w", err)
+	}
+
+	for _, w := range winners {
+		_, err := tx.Exec(`
+			INSERT INTO user_badges (id, user_id, season_id, title, rank, awarded_at)
+			VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)`,
+			uuid.New(), w.userID, season.ID, season.BadgeTitle(w.rank), w.rank)
+		if err != nil {
+			return false, fmt.Errorf("failed to award season badge: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE seasons SET closed_at = CURRENT_TIMESTAMP WHERE id = $1`, season.ID); err != nil {
+		return false, fmt.Errorf("failed to close season: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit season close: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetSeasonStandings retrieves a page of a closed season's final standings.
+// Users hidden since the season closed are left out and the others move up, an unban restores them.
+func (p *PostgresDB) GetSeasonStandings(seasonID string, limit, offset int) ([]models.LeaderboardEntry, error) {
+	query := `
+		SELECT s.user_id, s.user_name, COALESCE(s.user_avatar, ''), s.score, s.game_id,
+			COALESCE(g.created_at, s.created_at), ROW_NUMBER() OVER (ORDER BY s.rank ASC)
+		FROM season_standings s
+		JOIN users u ON u.id = s.user_id
+		LEFT JOIN games g ON g.id = s.game_id
+		WHERE s.season_id = $1 AND u.moderation_status <> ALL($4)
+		ORDER BY s.rank ASC LIMIT $2 OFFSET $3`
+
+	rows, err := p.db.Query(query, seasonID, limit, offset, pq.Array(hiddenModerationStatuses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query season standings: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.LeaderboardEntry{}
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		err := rows.Scan(
+			&entry.UserID, &entry.UserName, &entry.UserAvatar,
+			&entry.Score, &entry.GameID, &entry.CreatedAt, &entry.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan season standing: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating season standings: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetSeasonStandingsCount returns the number of entries in a closed season's final standings
+func (p *PostgresDB) GetSeasonStandingsCount(seasonID string) (int, error) {
+	var total int
+	query := `
+		SELECT COUNT(*) FROM season_standings s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.season_id = $1 AND u.moderation_status <> ALL($2)`
+	if err := p.db.QueryRow(query, seasonID, pq.Array(hiddenModerationStatuses)).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count season standings: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetUserBadges retrieves all badges awarded to a user, most recent first
+func (p *PostgresDB) GetUserBadges(userID string) ([]models.UserBadge, error) {
+	query := `
+		SELECT id, user_id, season_id, title, rank, awarded_at
+		FROM user_badges WHERE user_id = $1
+		ORDER BY awarded_at DESC`
+
+	rows, err := p.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user badges: %w", err)
+	}
+	defer rows.Close()
+
+	badges := []models.UserBadge{}
+	for rows.Next() {
+		var badge models.UserBadge
+		if err := rows.Scan(&badge.ID, &badge.UserID, &badge.SeasonID, &badge.Title, &badge.Rank, &badge.AwardedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user badge: %w", err)
+		}
+		badges = append(badges, badge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user badges: %w", err)
+	}
+
+	return badges, nil
+}
+
+// UnlockAchievement records an unlocked achievement.
+// It returns false if the user had already unlocked it, so concurrent unlocks report success only once.
+func (p *PostgresDB) UnlockAchievement(achievement *models.UserAchievement) (bool, error) {
+	query := `
+		INSERT INTO user_achievements (user_id, achievement_id, game_id, unlocked_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, achievement_id) DO NOTHING`
+
+	result, err := p.db.Exec(query, achievement.UserID, achievement.AchievementID, achievement.GameID, achievement.UnlockedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock achievement: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock achievement: %w", err)
+	}
+
+	return rows == 1, nil
+}
+
+// GetUserAchievements retrieves the achievements unlocked by a user, most recent first
+func (p *PostgresDB) GetUserAchievements(userID string) ([]models.UserAchievement, error) {
+	query := `
+		SELECT user_id, achievement_id, game_id, unlocked_at
+		FROM user_achievements WHERE user_id = $1
+		ORDER BY unlocked_at DESC`
+
+	rows, err := p.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user achievements: %w", err)
+	}
+	defer rows.Close()
+
+	achievements := []models.UserAchievement{}
+	for rows.Next() {
+		var achievement models.UserAchievement
+		if err := rows.Scan(&achievement.UserID, &achievement.AchievementID, &achievement.GameID, &achievement.UnlockedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user achievement: %w", err)
+		}
+		achievements = append(achievements, achievement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user achievements: %w", err)
+	}
+
+	return achievements, nil
+}
+
+// GetUserPlayDates retrieves the most recent days, in the given timezone, on which the user played a stored game
+func (p *PostgresDB) GetUserPlayDates(userID string, loc *time.Location, limit int) ([]time.Time, error) {
+	rows, err := p.db.Query(rebind(playDatesQuery, 0), loc.String(), userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get play dates: %w", err)
+	}
+	defer rows.Close()
+
+	dates := []time.Time{}
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, fmt.Errorf("failed to scan play date: %w", err)
+		}
+		dates = append(dates, date)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating play dates: %w", err)
+	}
+
+	return dates, nil
+}
+
+// FollowUser makes a user follow another user, following twice is a no-op
+func (p *PostgresDB) FollowUser(followerID, followeeID string) error {
+	query := `
+		INSERT INTO follows (follower_id, followee_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (follower_id, followee_id) DO NOTHING`
+
+	if _, err := p.db.Exec(query, followerID, followeeID, time.Now()); err != nil {
+		return fmt.Errorf("failed to follow user: %w", err)
+	}
+
+	return nil
+}
+
+// UnfollowUser removes a follow relationship
+func (p *PostgresDB) UnfollowUser(followerID, followeeID string) error {
+	query := `DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`
+
+	if _, err := p.db.Exec(query, followerID, followeeID); err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+
+	return nil
+}
+
+// GetFollowing retrieves the users a user follows, most recent first
+func (p *PostgresDB) GetFollowing(userID string) ([]models.Friend, error) {
+	query := `
+		SELECT u.id, u.name, u.avatar, f.created_at
+		FROM follows f
+		JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = $1
+		ORDER BY f.created_at DESC`
+
+	friends, err := p.queryFriends(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get following: %w", err)
+	}
+
+	return friends, nil
+}
+
+// GetFollowers retrieves the users following a user, most recent first
+func (p *PostgresDB) GetFollowers(userID string) ([]models.Friend, error) {
+	query := `
+		SELECT u.id, u.name, u.avatar, f.created_at
+		FROM follows f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.followee_id = $1
+		ORDER BY f.created_at DESC`
+
+	friends, err := p.queryFriends(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followers: %w", err)
+	}
+
+	return friends, nil
+}
+
+// queryFriends runs a query returning user id, name, avatar and follow time rows
+func (p *PostgresDB) queryFriends(query string, args ...interface{}) ([]models.Friend, error) {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	friends := []models.Friend{}
+	for rows.Next() {
+		var friend models.Friend
+		if err := rows.Scan(&friend.UserID, &friend.UserName, &friend.UserAvatar, &friend.FollowedAt); err != nil {
+			return nil, err
+		}
+		friends = append(friends, friend)
+	}
+
+	return friends, rows.Err()
+}
+
+// groupColumns lists the groups columns in the order scanGroup expects
+const groupColumns = `id, name, owner_id, invite_code,
+	(SELECT COUNT(*) FROM group_members m WHERE m.group_id = groups.id) as member_count,
+	created_at, updated_at`
+
+// scanGroup scans a group row
+func scanGroup(row interface{ Scan(...interface{}) error }) (*models.Group, error) {
+	group := &models.Group{}
+	err := row.Scan(&group.ID, &group.Name, &group.OwnerID, &group.InviteCode,
+		&group.MemberCount, &group.CreatedAt, &group.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+// CreateGroup creates a new group with its owner as the first member
+func (p *PostgresDB) CreateGroup(group *models.Group) error {
+	if group.ID == uuid.Nil {
+		group.ID = uuid.New()
+	}
+
+	now := time.Now()
+	group.CreatedAt = now
+	group.UpdatedAt = now
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO groups (id, name, owner_id, invite_code, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		group.ID, group.Name, group.OwnerID, group.InviteCode, group.CreatedAt, group.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create group: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO group_members (group_id, user_id, joined_at) VALUES ($1, $2, $3)`,
+		group.ID, group.OwnerID, now)
+	if err != nil {
+		return fmt.Errorf("failed to add group owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit group: %w", err)
+	}
+
+	group.MemberCount = 1
+	return nil
+}
+
+// UpdateGroup updates the name and invite code of a group
+func (p *PostgresDB) UpdateGroup(group *models.Group) error {
+	query := `
+		UPDATE groups
+		SET name = $2, invite_code = $3, updated_at = $4
+		WHERE id = $1`
+
+	group.UpdatedAt = time.Now()
+
+	result, err := p.db.Exec(query, group.ID, group.Name, group.InviteCode, group.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update group: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("group not found")
+	}
+
+	return nil
+}
+
+// DeleteGroup deletes a group and all its memberships
+func (p *PostgresDB) DeleteGroup(groupID string) error {
+	// Memberships are removed by ON DELETE CASCADE
+	if _, err := p.db.Exec(`DELETE FROM groups WHERE id = $1`, groupID); err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return nil
+}
+
+// GetGroup retrieves a group by ID
+func (p *PostgresDB) GetGroup(groupID string) (*models.Group, error) {
+	group, err := scanGroup(p.db.QueryRow(`SELECT `+groupColumns+` FROM groups WHERE id = $1`, groupID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	return group, nil
+}
+
+// GetGroupByInviteCode retrieves a group by its invite code
+func (p *PostgresDB) GetGroupByInviteCode(inviteCode string) (*models.Group, error) {
+	group, err := scanGroup(p.db.QueryRow(`SELECT `+groupColumns+` FROM groups WHERE invite_code = $1`, inviteCode))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+
+	return group, nil
+}
+
+// GetUserGroups retrieves the groups a user is a member of, by name
+func (p *PostgresDB) GetUserGroups(userID string) ([]models.Group, error) {
+	query := `SELECT ` + groupColumns + ` FROM groups
+		WHERE id IN (SELECT group_id FROM group_members WHERE user_id = $1)
+		ORDER BY name ASC`
+
+	rows, err := p.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user groups: %w", err)
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	for rows.Next() {
+		group, err := scanGroup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		groups = append(groups, *group)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// AddGroupMember adds a user to a group, joining twice is a no-op
+func (p *PostgresDB) AddGroupMember(groupID, userID string) error {
+	query := `
+		INSERT INTO group_members (group_id, user_id, joined_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (group_id, user_id) DO NOTHING`
+
+	if _, err := p.db.Exec(query, groupID, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to add group member: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveGroupMember removes a user from a group
+func (p *PostgresDB) RemoveGroupMember(groupID, userID string) error {
+	query := `DELETE FROM group_members WHERE group_id = $1 AND user_id = $2`
+
+	if _, err := p.db.Exec(query, groupID, userID); err != nil {
+		return fmt.Errorf("failed to remove group member: %w", err)
+	}
+
+	return nil
+}
+
+// IsGroupMember reports whether a user is a member of a group
+func (p *PostgresDB) IsGroupMember(groupID, userID string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM group_members WHERE group_id = $1 AND user_id = $2)`
+
+	var exists bool
+	if err := p.db.QueryRow(query, groupID, userID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check group membership: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetGroupMembers retrieves a page of a group's members in the order they joined
+func (p *PostgresDB) GetGroupMembers(groupID string, limit, offset int) ([]models.GroupMember, error) {
+	query := `
+		SELECT u.id, u.name, u.avatar, m.joined_at
+		FROM group_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.group_id = $1
+		ORDER BY m.joined_at ASC, m.user_id ASC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := p.db.Query(query, groupID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.GroupMember{}
+	for rows.Next() {
+		var member models.GroupMember
+		if err := rows.Scan(&member.UserID, &member.UserName, &member.UserAvatar, &member.JoinedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan group member: %w", err)
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating group members: %w", err)
+	}
+
+	return members, nil
+}
+
+// webhookColumns lists the webhooks columns in the order scanWebhook expects
+const webhookColumns = `id, url, secret, events, active, created_at, updated_at`
+
+// scanWebhook scans a webhook row selected with webhookColumns
+func scanWebhook(row interface{ Scan(...interface{}) error }) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+	var events string
+	err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &events,
+		&webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Events = models.ParseWebhookEvents(events)
+	return webhook, nil
+}
+
+// CreateWebhook creates a new webhook
+func (p *PostgresDB) CreateWebhook(webhook *models.Webhook) error {
+	if webhook.ID == uuid.Nil {
+		webhook.ID = uuid.New()
+	}
+
+	query := `
+		INSERT INTO webhooks (id, url, secret, events, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	now := time.Now()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+
+	_, err := p.db.Exec(query, webhook.ID, webhook.URL, webhook.Secret,
+		models.JoinWebhookEvents(webhook.Events), webhook.Active, webhook.CreatedAt, webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateWebhook updates the URL, events and active flag of a webhook
+func (p *PostgresDB) UpdateWebhook(webhook *models.Webhook) error {
+	query := `
+		UPDATE webhooks
+		SET url = $2, events = $3, active = $4, updated_at = $5
+		WHERE id = $1`
+
+	webhook.UpdatedAt = time.Now()
+
+	result, err := p.db.Exec(query, webhook.ID, webhook.URL,
+		models.JoinWebhookEvents(webhook.Events), webhook.Active, webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes a webhook and its deliveries
+func (p *PostgresDB) DeleteWebhook(webhookID string) error {
+	// Deliveries are removed by ON DELETE CASCADE
+	if _, err := p.db.Exec(`DELETE FROM webhooks WHERE id = $1`, webhookID); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebhook retrieves a webhook by ID
+func (p *PostgresDB) GetWebhook(webhookID string) (*models.Webhook, error) {
+	webhook, err := scanWebhook(p.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = $1`, webhookID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks retrieves all webhooks, oldest first
+func (p *PostgresDB) ListWebhooks() ([]models.Webhook, error) {
+	rows, err := p.db.Query(`SELECT ` + webhookColumns + ` FROM webhooks ORDER BY created_at ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// webhookDeliveryColumns lists the webhook_deliveries columns in the order scanWebhookDelivery expects
+const webhookDeliveryColumns = `id, webhook_id, event, payload, status, attempts, last_error,
+	last_status_code, next_attempt_at, created_at, delivered_at`
+
+// scanWebhookDelivery scans a delivery row selected with webhookDeliveryColumns
+func scanWebhookDelivery(row interface{ Scan(...interface{}) error }) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+	var payload []byte
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &payload, &delivery.Status,
+		&delivery.Attempts, &delivery.LastError, &delivery.LastStatusCode, &delivery.NextAttemptAt,
+		&delivery.CreatedAt, &delivery.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = json.RawMessage(payload)
+	return delivery, nil
+}
+
+// scanWebhookDeliveries scans every row of a delivery query
+func scanWebhookDeliveries(rows *sql.Rows) ([]models.WebhookDelivery, error) {
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// CreateWebhookDelivery queues a delivery of an event to a webhook
+func (p *PostgresDB) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	if delivery.ID == uuid.Nil {
+		delivery.ID = uuid.New()
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (id, webhook_id, event, payload, status, attempts, last_error,
+			last_status_code, next_attempt_at, created_at, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	delivery.CreatedAt = time.Now()
+
+	_, err := p.db.Exec(query, delivery.ID, delivery.WebhookID, string(delivery.Event), string(delivery.Payload),
+		string(delivery.Status), delivery.Attempts, delivery.LastError, delivery.LastStatusCode,
+		delivery.NextAttemptAt, delivery.CreatedAt, delivery.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateWebhookDelivery saves the status and attempt bookkeeping of a delivery
+func (p *PostgresDB) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, last_error = $4, last_status_code = $5,
+			next_attempt_at = $6, delivered_at = $7
+		WHERE id = $1`
+
+	result, err := p.db.Exec(query, delivery.ID, string(delivery.Status), delivery.Attempts,
+		delivery.LastError, delivery.LastStatusCode, delivery.NextAttemptAt, delivery.DeliveredAt)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook delivery not found")
+	}
+
+	return nil
+}
+
+// GetWebhookDelivery retrieves a webhook delivery by ID
+func (p *PostgresDB) GetWebhookDelivery(deliveryID string) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	delivery, err := scanWebhookDelivery(p.db.QueryRow(query, deliveryID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("webhook delivery not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// GetWebhookDeliveries retrieves a page of deliveries, most recent first.
+// Empty webhook ID and status match every webhook and status.
+func (p *PostgresDB) GetWebhookDeliveries(webhookID string, status models.WebhookDeliveryStatus, limit, offset int) ([]models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE ($1 = '' OR webhook_id::text = $1) AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id ASC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := p.db.Query(query, webhookID, string(status), limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return scanWebhookDeliveries(rows)
+}
+
+// GetDueWebhookDeliveries retrieves pending deliveries whose next attempt is due, oldest first
+func (p *PostgresDB) GetDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at ASC
+		LIMIT $3`
+
+	rows, err := p.db.Query(query, string(models.WebhookDeliveryPending), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+
+	return scanWebhookDeliveries(rows)
+}
+
+// GetPreviousBestScore returns a user's best score over their finished games other than the given one.
+// It reports false if the user has no other finished game. Tournament attempts are not counted.
+func (p *PostgresDB) GetPreviousBestScore(userID, gameID string) (int, bool, error) {
+	query := `
+		SELECT MAX(score) FROM games
+		WHERE user_id = $1 AND id <> $2 AND (game_over = true OR victory = true) AND mode <> $3`
+
+	var best sql.NullInt64
+	if err := p.db.QueryRow(query, userID, gameID, models.GameModeTournament).Scan(&best); err != nil {
+		return 0, false, fmt.Errorf("failed to get previous best score: %w", err)
+	}
+
+	return int(best.Int64), best.Valid, nil
+}
+
+// tournamentColumns lists the tournaments columns in the order scanTournament expects
+const tournamentColumns = `id, name, description, registration_start, registration_end, max_attempts, status,
+	(SELECT COUNT(*) FROM tournament_participants p WHERE p.tournament_id = tournaments.id) as participant_count,
+	created_at, updated_at`
+
+// scanTournament scans a tournament row selected with tournamentColumns, without its rounds
+func scanTournament(row interface{ Scan(...interface{}) error }) (*models.Tournament, error) {
+	tournament := &models.Tournament{}
+	err := row.Scan(&tournament.ID, &tournament.Name, &tournament.Description,
+		&tournament.RegistrationStart, &tournament.RegistrationEnd, &tournament.MaxAttempts,
+		&tournament.Status, &tournament.ParticipantCount, &tournament.CreatedAt, &tournament.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	tournament.Rounds = []models.TournamentRound{}
+	return tournament, nil
+}
+
+// queryTournaments runs a tournaments query selecting tournamentColumns and loads the rounds of every tournament
+func (p *PostgresDB) queryTournaments(query string, args ...interface{}) ([]models.Tournament, error) {
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tournaments := []models.Tournament{}
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		tournament, err := scanTournament(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tournament: %w", err)
+		}
+		index[tournament.ID] = len(tournaments)
+		tournaments = append(tournaments, *tournament)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tournaments: %w", err)
+	}
+
+	if len(tournaments) == 0 {
+		return tournaments, nil
+	}
+
+	ids := make([]string, 0, len(tournaments))
+	for _, tournament := range tournaments {
+		ids = append(ids, tournament.ID.String())
+	}
+
+	roundRows, err := p.db.Query(`
+		SELECT tournament_id, number, start_at, end_at, seed, advance, status
+		FROM tournament_rounds
+		WHERE tournament_id::text = ANY($1)
+		ORDER BY number ASC`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tournament rounds: %w", err)
+	}
+	defer roundRows.Close()
+
+	for roundRows.Next() {
+		var round models.TournamentRound
+		err := roundRows.Scan(&round.TournamentID, &round.Number, &round.StartAt, &round.EndAt,
+			&round.Seed, &round.Advance, &round.Status)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tournament round: %w", err)
+		}
+		i := index[round.TournamentID]
+		tournaments[i].Rounds = append(tournaments[i].Rounds, round)
+	}
+
+	if err := roundRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tournament rounds: %w", err)
+	}
+
+	return tournaments, nil
+}
+
+// CreateTournament creates a new tournament with its rounds
+func (p *PostgresDB) CreateTournament(tournament *models.Tournament) error {
+	if tournament.ID == uuid.Nil {
+		tournament.ID = uuid.New()
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	tournament.CreatedAt = now
+	tournament.UpdatedAt = now
+
+	_, err = tx.Exec(`
+		INSERT INTO tournaments (id, name, description, registration_start, registration_end, max_attempts, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		tournament.ID, tournament.Name, tournament.Description, tournament.RegistrationStart,
+		tournament.RegistrationEnd, tournament.MaxAttempts, string(tournament.Status),
+		tournament.CreatedAt, tournament.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create tournament: %w", err)
+	}
+
+	for i := range tournament.Rounds {
+		round := &tournament.Rounds[i]
+		round.TournamentID = tournament.ID
+		_, err := tx.Exec(`
+			INSERT INTO tournament_rounds (tournament_id, number, start_at, end_at, seed, advance, status)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			round.TournamentID, round.Number, round.StartAt, round.EndAt, round.Seed, round.Advance, string(round.Status))
+		if err != nil {
+			return fmt.Errorf("failed to create tournament round: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tournament: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTournament deletes a tournament with its rounds, participants and attempts.
+// The attempt games are kept in the players' history.
+func (p *PostgresDB) DeleteTournament(tournamentID string) error {
+	// Rounds, participants and attempts are removed by ON DELETE CASCADE
+	if _, err := p.db.Exec(`DELETE FROM tournaments WHERE id = $1`, tournamentID); err != nil {
+		return fmt.Errorf("failed to delete tournament: %w", err)
+	}
+
+	return nil
+}
+
+// GetTournament retrieves a tournament with its rounds by ID
+func (p *PostgresDB) GetTournament(tournamentID string) (*models.Tournament, error) {
+	tournaments, err := p.queryTournaments(`SELECT `+tournamentColumns+` FROM tournaments WHERE id = $1`, tournamentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tournament: %w", err)
+	}
+
+	if len(tournaments) == 0 {
+		return nil, fmt.Errorf("tournament not found")
+	}
+
+	return &tournaments[0], nil
+}
+
+// ListTournaments retrieves all tournaments with their rounds, most recent registration first
+func (p *PostgresDB) ListTournaments() ([]models.Tournament, error) {
+	tournaments, err := p.queryTournaments(`SELECT ` + tournamentColumns + ` FROM tournaments ORDER BY registration_start DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tournaments: %w", err)
+	}
+
+	return tournaments, nil
+}
+
+// GetUnfinishedTournaments retrieves the tournaments that are upcoming or running, with their rounds
+func (p *PostgresDB) GetUnfinishedTournaments() ([]models.Tournament, error) {
+	tournaments, err := p.queryTournaments(`SELECT `+tournamentColumns+` FROM tournaments
+		WHERE status <> $1
+		ORDER BY registration_start ASC`, string(models.TournamentFinished))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unfinished tournaments: %w", err)
+	}
+
+	return tournaments, nil
+}
+
+// UpdateTournamentStatus sets the status of a tournament
+func (p *PostgresDB) UpdateTournamentStatus(tournamentID string, status models.TournamentStatus) error {
+	result, err := p.db.Exec(`UPDATE tournaments SET status = $2, updated_at = $3 WHERE id = $1`,
+		tournamentID, string(status), time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to update tournament status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("tournament not found")
+	}
+
+	return nil
+}
+
+// UpdateTournamentRoundStatus sets the status of a tournament round
+func (p *PostgresDB) UpdateTournamentRoundStatus(tournamentID string, round int, status models.TournamentRoundStatus) error {
+	result, err := p.db.Exec(`UPDATE tournament_rounds SET status = $3 WHERE tournament_id = $1 AND number = $2`,
+		tournamentID, round, string(status))
+	if err != nil {
+		return fmt.Errorf("failed to update tournament round status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("tournament round not found")
+	}
+
+	return nil
+}
+
+// RegisterTournamentParticipant registers a user for a tournament, registering twice is a no-op
+func (p *PostgresDB) RegisterTournamentParticipant(tournamentID, userID string) error {
+	query := `
+		INSERT INTO tournament_participants (tournament_id, user_id, registered_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tournament_id, user_id) DO NOTHING`
+
+	if _, err := p.db.Exec(query, tournamentID, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to register tournament participant: %w", err)
+	}
+
+	return nil
+}
+
+// UnregisterTournamentParticipant withdraws a user's registration for a tournament
+func (p *PostgresDB) UnregisterTournamentParticipant(tournamentID, userID string) error {
+	query := `DELETE FROM tournament_participants WHERE tournament_id = $1 AND user_id = $2`
+
+	if _, err := p.db.Exec(query, tournamentID, userID); err != nil {
+		return fmt.Errorf("failed to unregister tournament participant: %w", err)
+	}
+
+	return nil
+}
+
+// tournamentParticipantQuery selects the participants of the tournament in $1 with their names
+const tournamentParticipantQuery = `
+	SELECT p.tournament_id, p.user_id, u.name, u.avatar, p.registered_at, p.eliminated_round, p.final_rank
+	FROM tournament_participants p
+	JOIN users u ON u.id = p.user_id
+	WHERE p.tournament_id = $1`
+
+// scanTournamentParticipant scans a row selected with tournamentParticipantQuery
+func scanTournamentParticipant(row interface{ Scan(...interface{}) error }) (*models.TournamentParticipant, error) {
+	participant := &models.TournamentParticipant{}
+	var eliminatedRound, finalRank sql.NullInt64
+	err := row.Scan(&participant.TournamentID, &participant.UserID, &participant.UserName, &participant.UserAvatar,
+		&participant.RegisteredAt, &eliminatedRound, &finalRank)
+	if err != nil {
+		return nil, err
+	}
+
+	if eliminatedRound.Valid {
+		round := int(eliminatedRound.Int64)
+		participant.EliminatedRound = &round
+	}
+	if finalRank.Valid {
+		rank := int(finalRank.Int64)
+		participant.FinalRank = &rank
+	}
+	return participant, nil
+}
+
+// GetTournamentParticipant retrieves a user's registration for a tournament
+func (p *PostgresDB) GetTournamentParticipant(tournamentID, userID string) (*models.TournamentParticipant, error) {
+	participant, err := scanTournamentParticipant(p.db.QueryRow(tournamentParticipantQuery+` AND p.user_id = $2`, tournamentID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("tournament participant not found")
+		}
+		return nil, fmt.Errorf("failed to get tournament participant: %w", err)
+	}
+
+	return participant, nil
+}
+
+// GetTournamentParticipants retrieves the participants of a tournament, ordered by final rank,
+// then by how far they got and when they registered
+func (p *PostgresDB) GetTournamentParticipants(tournamentID string) ([]models.TournamentParticipant, error) {
+	rows, err := p.db.Query(tournamentParticipantQuery+`
+		ORDER BY p.final_rank ASC NULLS LAST, p.eliminated_round DESC NULLS FIRST, p.registered_at ASC, p.user_id ASC`,
+		tournamentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tournament participants: %w", err)
+	}
+	defer rows.Close()
+
+	participants := []models.TournamentParticipant{}
+	for rows.Next() {
+		participant, err := scanTournamentParticipant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tournament participant: %w", err)
+		}
+		participants = append(participants, *participant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tournament participants: %w", err)
+	}
+
+	return participants, nil
+}
+
+// EliminateTournamentParticipants knocks out every remaining participant of a tournament
+// who is not among the advancing users, recording the round they were eliminated in
+func (p *PostgresDB) EliminateTournamentParticipants(tournamentID string, round int, advancing []string) error {
+	query := `
+		UPDATE tournament_participants
+		SET eliminated_round = $2
+		WHERE tournament_id = $1 AND eliminated_round IS NULL AND NOT (user_id = ANY($3))`
+
+	if _, err := p.db.Exec(query, tournamentID, round, pq.Array(advancing)); err != nil {
+		return fmt.Errorf("failed to eliminate tournament participants: %w", err)
+	}
+
+	return nil
+}
+
+// SetTournamentFinalRanks records the final ranks of a tournament's participants, keyed by user ID
+func (p *PostgresDB) SetTournamentFinalRanks(tournamentID string, ranks map[string]int) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for userID, rank := range ranks {
+		_, err := tx.Exec(`UPDATE tournament_participants SET final_rank = $3 WHERE tournament_id = $1 AND user_id = $2`,
+			tournamentID, userID, rank)
+		if err != nil {
+			return fmt.Errorf("failed to set tournament final rank: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tournament final ranks: %w", err)
+	}
+
+	return nil
+}
+
+// CreateTournamentAttempt records a game played as a tournament attempt
+func (p *PostgresDB) CreateTournamentAttempt(attempt *models.TournamentAttempt) error {
+	query := `
+		INSERT INTO tournament_attempts (game_id, tournament_id, round, user_id, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	attempt.CreatedAt = time.Now()
+
+	_, err := p.db.Exec(query, attempt.GameID, attempt.TournamentID, attempt.Round, attempt.UserID, attempt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create tournament attempt: %w", err)
+	}
+
+	return nil
+}
+
+// CountTournamentAttempts returns the number of attempts a user started in a tournament round
+func (p *PostgresDB) CountTournamentAttempts(tournamentID string, round int, userID string) (int, error) {
+	query := `SELECT COUNT(*) FROM tournament_attempts WHERE tournament_id = $1 AND round = $2 AND user_id = $3`
+
+	var count int
+	if err := p.db.QueryRow(query, tournamentID, round, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count tournament attempts: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetUnfinishedTournamentAttempts retrieves the attempts of a round whose game has not finished,
+// including games that were only cached so far
+func (p *PostgresDB) GetUnfinishedTournamentAttempts(tournamentID string, round int) ([]models.TournamentAttempt, error) {
+	query := `
+		SELECT a.game_id, a.tournament_id, a.round, a.user_id, a.created_at
+		FROM tournament_attempts a
+		LEFT JOIN games g ON g.id = a.game_id
+		WHERE a.tournament_id = $1 AND a.round = $2
+			AND (g.id IS NULL OR (g.game_over = false AND g.victory = false))
+		ORDER BY a.created_at ASC`
+
+	rows, err := p.db.Query(query, tournamentID, round)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unfinished tournament attempts: %w", err)
+	}
+	defer rows.Close()
+
+	attempts := []models.TournamentAttempt{}
+	for rows.Next() {
+		var attempt models.TournamentAttempt
+		if err := rows.Scan(&attempt.GameID, &attempt.TournamentID, &attempt.Round, &attempt.UserID, &attempt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tournament attempt: %w", err)
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tournament attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// GetTournamentAttemptRound retrieves the round a game was played in as a tournament attempt.
+// It returns nil if the game is not a tournament attempt.
+func (p *PostgresDB) GetTournamentAttemptRound(gameID string) (*models.TournamentRound, error) {
+	query := `
+		SELECT r.tournament_id, r.number, r.start_at, r.end_at, r.seed, r.advance, r.status
+		FROM tournament_rounds r
+		JOIN tournament_attempts a ON a.tournament_id = r.tournament_id AND a.round = r.number
+		WHERE a.game_id = $1`
+
+	var round models.TournamentRound
+	err := p.db.QueryRow(query, gameID).Scan(&round.TournamentID, &round.Number, &round.StartAt, &round.EndAt,
+		&round.Seed, &round.Advance, &round.Status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get tournament attempt round: %w", err)
+	}
+
+	return &round, nil
+}
+
+// refreshTokenColumns lists the refresh token columns in the order scanRefreshToken reads them
+const refreshTokenColumns = `id, user_id, family_id, token_hash, expires_at, created_at, rotated_at, revoked_at`
+
+// scanRefreshToken scans a refresh token selected with refreshTokenColumns
+func scanRefreshToken(row interface{ Scan(...interface{}) error }) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := row.Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &token.ExpiresAt,
+		&token.CreatedAt, &token.RotatedAt, &token.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// CreateRefreshToken stores a newly issued refresh token
+func (p *PostgresDB) CreateRefreshToken(token *models.RefreshToken) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+
+	query := `
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	token.CreatedAt = time.Now()
+
+	_, err := p.db.Exec(query, token.ID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// GetRefreshToken retrieves a refresh token by the hash of its value
+func (p *PostgresDB) GetRefreshToken(tokenHash string) (*models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1`
+
+	token, err := scanRefreshToken(p.db.QueryRow(query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("refresh token not found")
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+// RotateRefreshToken marks a refresh token rotated and stores its successor in one transaction.
+// It returns false without storing the successor if the token was already rotated or revoked.
+func (p *PostgresDB) RotateRefreshToken(tokenID string, next *models.RefreshToken) (bool, error) {
+	if next.ID == uuid.Nil {
+		next.ID = uuid.New()
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE refresh_tokens SET rotated_at = $2
+		WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL`, tokenID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	next.CreatedAt = time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		next.ID, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt, next.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// RevokeRefreshTokenFamily revokes every refresh token rotated from the same login
+func (p *PostgresDB) RevokeRefreshTokenFamily(familyID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`
+
+	if _, err := p.db.Exec(query, familyID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of a user
+func (p *PostgresDB) RevokeUserRefreshTokens(userID string) error {
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+
+	if _, err := p.db.Exec(query, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to revoke user refresh tokens: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredRefreshTokens deletes refresh tokens that expired before the given time and returns how many were deleted
+func (p *PostgresDB) DeleteExpiredRefreshTokens(before time.Time) (int64, error) {
+	result, err := p.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// roleQuery selects roles with their permissions
+const roleQuery = `
+	SELECT r.name, r.description, r.created_at, r.updated_at,
+		COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+	FROM roles r
+	LEFT JOIN role_permissions rp ON rp.role = r.name`
+
+// ListRoles retrieves every role with its permissions
+func (p *PostgresDB) ListRoles() ([]models.Role, error) {
+	rows, err := p.db.Query(roleQuery + ` GROUP BY r.name ORDER BY r.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		var permissions pq.StringArray
+		if err := rows.Scan(&role.Name, &role.Description, &role.CreatedAt, &role.UpdatedAt, &permissions); err != nil {
+			return nil, fmt.Errorf("failed to scan role: %w", err)
+		}
+		role.Permissions = toPermissions(permissions)
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// GetRole retrieves a role with its permissions
+func (p *PostgresDB) GetRole(name string) (*models.Role, error) {
+	role := &models.Role{}
+	var permissions pq.StringArray
+	err := p.db.QueryRow(roleQuery+` WHERE r.name = $1 GROUP BY r.name`, name).
+		Scan(&role.Name, &role.Description, &role.CreatedAt, &role.UpdatedAt, &permissions)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("role not found")
+		}
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	role.Permissions = toPermissions(permissions)
+	return role, nil
+}
+
+// SaveRole creates a role or replaces the description and permissions of an existing one
+func (p *PostgresDB) SaveRole(role *models.Role) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	err = tx.QueryRow(`
+		INSERT INTO roles (name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at`, role.Name, role.Description, now).
+		Scan(&role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role = $1`, role.Name); err != nil {
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+	for _, permission := range role.Permissions {
+		_, err := tx.Exec(`
+			INSERT INTO role_permissions (role, permission) VALUES ($1, $2)
+			ON CONFLICT (role, permission) DO NOTHING`, role.Name, string(permission))
+		if err != nil {
+			return fmt.Errorf("failed to add role permission: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit role: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRole deletes a role, taking it away from every user that had it
+func (p *PostgresDB) DeleteRole(name string) error {
+	result, err := p.db.Exec(`DELETE FROM roles WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("role not found")
+	}
+
+	return nil
+}
+
+// GrantRole grants a role to a user, granting a role the user already has changes nothing
+func (p *PostgresDB) GrantRole(userID, role, grantedBy string) error {
+	query := `
+		INSERT INTO user_roles (user_id, role, granted_by, granted_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, role) DO NOTHING`
+
+	if _, err := p.db.Exec(query, userID, role, grantedBy, time.Now()); err != nil {
+		return fmt.Errorf("failed to grant role: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeRole takes a role away from a user
+func (p *PostgresDB) RevokeRole(userID, role string) error {
+	result, err := p.db.Exec(`DELETE FROM user_roles WHERE user_id = $1 AND role = $2`, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to revoke role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("role not granted")
+	}
+
+	return nil
+}
+
+// GetUserRoles retrieves the roles granted to a user
+func (p *PostgresDB) GetUserRoles(userID string) ([]models.UserRole, error) {
+	query := `
+		SELECT user_id, role, granted_by, granted_at FROM user_roles
+		WHERE user_id = $1
+		ORDER BY role`
+
+	rows, err := p.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []models.UserRole{}
+	for rows.Next() {
+		var role models.UserRole
+		if err := rows.Scan(&role.UserID, &role.Role, &role.GrantedBy, &role.GrantedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user role: %w", err)
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// GetUserPermissions retrieves the permissions a user has through their roles
+func (p *PostgresDB) GetUserPermissions(userID string) ([]models.Permission, error) {
+	query := `
+		SELECT DISTINCT rp.permission
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role = ur.role
+		WHERE ur.user_id = $1`
+
+	rows, err := p.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user permissions: %w", err)
+	}
+	defer rows.Close()
+
+	permissions := []models.Permission{}
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, fmt.Errorf("failed to scan permission: %w", err)
+		}
+		permissions = append(permissions, models.Permission(permission))
+	}
+
+	return permissions, rows.Err()
+}
+
+// apiTokenColumns lists the API token columns in the order scanAPIToken reads them
+const apiTokenColumns = `id, user_id, name, token_hash, prefix, scopes, created_at, expires_at, last_used_at, revoked_at`
+
+// scanAPIToken scans an API token selected with apiTokenColumns
+func scanAPIToken(row interface{ Scan(...interface{}) error }) (*models.APIToken, error) {
+	var token models.APIToken
+	var scopes string
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.Prefix, &scopes,
+		&token.CreatedAt, &token.ExpiresAt, &token.LastUsedAt, &token.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = models.ParseTokenScopes(scopes)
+	return &token, nil
+}
+
+// CreateAPIToken stores a newly created personal API token
+func (p *PostgresDB) CreateAPIToken(token *models.APIToken) error {
+	if token.ID == uuid.Nil {
+		token.ID = uuid.New()
+	}
+
+	query := `
+		INSERT INTO api_tokens (id, user_id, name, token_hash, prefix, scopes, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	token.CreatedAt = time.Now()
+
+	_, err := p.db.Exec(query, token.ID, token.UserID, token.Name, token.TokenHash, token.Prefix,
+		models.JoinTokenScopes(token.Scopes), token.CreatedAt, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create API token: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIToken retrieves an API token by the hash of its value
+func (p *PostgresDB) GetAPIToken(tokenHash string) (*models.APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens WHERE token_hash = $1`
+
+	token, err := scanAPIToken(p.db.QueryRow(query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("API token not found")
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	return token, nil
+}
+
+// GetUserAPITokens retrieves the API tokens of a user that were not revoked, newest first
+func (p *PostgresDB) GetUserAPITokens(userID string) ([]models.APIToken, error) {
+	query := `SELECT ` + apiTokenColumns + ` FROM api_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC`
+
+	rows, err := p.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API token: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// RevokeAPIToken revokes an API token of a user
+func (p *PostgresDB) RevokeAPIToken(userID, tokenID string) error {
+	query := `UPDATE api_tokens SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	result, err := p.db.Exec(query, tokenID, userID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to revoke API token: %w", err)
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("API token not found")
+	}
+
+	return nil
+}
+
+// TouchAPIToken records when an API token was last used
+func (p *PostgresDB) TouchAPIToken(tokenID string, usedAt time.Time) error {
+	if _, err := p.db.Exec(`UPDATE api_tokens SET last_used_at = $2 WHERE id = $1`, tokenID, usedAt); err != nil {
+		return fmt.Errorf("failed to update API token: %w", err)
+	}
+
+	return nil
+}
+
+// ModerateUser sets the moderation status of a user and records the action in the audit trail
+func (p *PostgresDB) ModerateUser(action *models.ModerationAction) error {
+	if action.ID == uuid.Nil {
+		action.ID = uuid.New()
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	action.CreatedAt = time.Now()
+
+	result, err := tx.Exec(`UPDATE users SET moderation_status = $2, suspended_until = $3, updated_at = $4 WHERE id = $1`,
+		action.UserID, action.Status, action.SuspendedUntil, action.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to moderate user: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO moderation_actions (id, user_id, moderator_id, status, suspended_until, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		action.ID, action.UserID, action.ModeratorID, action.Status, action.SuspendedUntil, action.Reason, action.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record moderation action: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetModerationActions retrieves the moderation audit trail of a user, newest first
+func (p *PostgresDB) GetModerationActions(userID string) ([]models.ModerationAction, error) {
+	query := `
+		SELECT id, user_id, moderator_id, status, suspended_until, reason, created_at
+		FROM moderation_actions
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := p.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moderation actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []models.ModerationAction{}
+	for rows.Next() {
+		var action models.ModerationAction
+		err := rows.Scan(&action.ID, &action.UserID, &action.ModeratorID, &action.Status,
+			&action.SuspendedUntil, &action.Reason, &action.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan moderation action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
+
+// GetModeratedUsers retrieves the users that are suspended, banned or shadow-banned, most recently moderated first
+func (p *PostgresDB) GetModeratedUsers() ([]models.UserModeration, error) {
+	query := `
+		SELECT id, name, moderation_status, suspended_until
+		FROM users
+		WHERE moderation_status <> 'active'
+		AND (moderation_status <> 'suspended' OR suspended_until > $1)
+		ORDER BY updated_at DESC`
+
+	rows, err := p.db.Query(query, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get moderated users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.UserModeration{}
+	for rows.Next() {
+		var user models.UserModeration
+		if err := rows.Scan(&user.UserID, &user.UserName, &user.Status, &user.SuspendedUntil); err != nil {
+			return nil, fmt.Errorf("failed to scan moderated user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,12 @@
+package database
+
+import "game2048/pkg/models"
+
+// toPermissions converts stored permission names to permissions
+func toPermissions(names []string) []models.Permission {
+	permissions := make([]models.Permission, len(names))
+	for i, name := range names {
+		permissions[i] = models.Permission(name)
+	}
+	return permissions
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"game2048/pkg/models"
+)
+
+// snapshotTable returns the snapshot table and its period column for a leaderboard type
+func snapshotTable(leaderboardType models.LeaderboardType) (string, string, error) {
+	switch leaderboardType {
+	case models.LeaderboardDaily:
+		return "leaderboard_daily", "date", nil
+	case models.LeaderboardWeekly:
+		return "leaderboard_weekly", "week_start", nil
+	case models.LeaderboardMonthly:
+		return "leaderboard_monthly", "month_start", nil
+	default:
+		return "", "", fmt.Errorf("leaderboard type %q has no snapshot table", leaderboardType)
+	}
+}
+
+// snapshotDate formats a period start as a DATE literal.
+// The calendar date is taken in the period's own location so it never shifts with the session timezone.
+func snapshotDate(periodStart time.Time) string {
+	return periodStart.Format("2006-01-02")
+}
@@ -0,0 +1,154 @@
+package events
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultQueueSize is the queue size for subscribers that do not need a specific one
+const DefaultQueueSize = 256
+
+// Handler processes events delivered to a subscriber
+type Handler func(event Event)
+
+// Bus delivers published events to subscribers in process.
+// Every subscriber has its own bounded queue and goroutine, so it sees events in publishing order
+// and a slow or failing subscriber does not hold up publishers or other subscribers.
+type Bus struct {
+	subscribers []*subscriber
+	closed      bool
+	mutex       sync.RWMutex
+	wg          sync.WaitGroup
+}
+
+// subscriber is a named handler with its queue
+type subscriber struct {
+	name    string
+	queue   chan queuedEvent
+	handler Handler
+	dropped atomic.Int64
+
+	// Events dropped since the last queued event, guarded by mutex so that
+	// the count travels with the first event queued after them
+	missed int64
+	mutex  sync.Mutex
+}
+
+// queuedEvent is an event with the number of events dropped right before it
+type queuedEvent struct {
+	event  Event
+	missed int64
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler that receives every published event from now on.
+// Events are dropped for the subscriber when its queue is full, the handler then receives
+// EventsDropped before the next event it is delivered.
+func (b *Bus) Subscribe(name string, queueSize int, handler Handler) {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+
+	s := &subscriber{
+		name:    name,
+		queue:   make(chan queuedEvent, queueSize),
+		handler: handler,
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		log.Printf("Event bus closed, not subscribing %s", name)
+		return
+	}
+
+	b.subscribers = append(b.subscribers, s)
+	b.wg.Add(1)
+	go b.run(s)
+}
+
+// Publish queues an event for every subscriber without blocking.
+// Events published after Close are dropped.
+func (b *Bus) Publish(event Event) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.closed {
+		return
+	}
+
+	for _, s := range b.subscribers {
+		s.enqueue(event)
+	}
+}
+
+// enqueue queues an event for the subscriber, dropping it if the queue is full
+func (s *subscriber) enqueue(event Event) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	select {
+	case s.queue <- queuedEvent{event: event, missed: s.missed}:
+		s.missed = 0
+	default:
+		s.missed++
+		dropped := s.dropped.Add(1)
+		log.Printf("Event queue of %s is full, dropped %s (%d dropped so far)", s.name, event.EventName(), dropped)
+	}
+}
+
+// Close stops accepting events and waits until subscribers have handled the queued ones.
+// It returns the context error if the context ends first.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mutex.Lock()
+	if !b.closed {
+		b.closed = true
+		for _, s := range b.subscribers {
+			close(s.queue)
+		}
+	}
+	b.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run delivers queued events to a subscriber until its queue is closed and drained
+func (b *Bus) run(s *subscriber) {
+	defer b.wg.Done()
+	for queued := range s.queue {
+		if queued.missed > 0 {
+			b.deliver(s, EventsDropped{Count: queued.missed})
+		}
+		b.deliver(s, queued.event)
+	}
+}
+
+// deliver passes an event to a subscriber, recovering from panics so the subscriber keeps running
+func (b *Bus) deliver(s *subscriber, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event subscriber %s panicked on %s: %v\n%s", s.name, event.EventName(), r, debug.Stack())
+		}
+	}()
+
+	s.handler(event)
+}
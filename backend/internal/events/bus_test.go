@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// numbered is an event told apart by its number
+type numbered int
+
+func (numbered) EventName() string { return "test.numbered" }
+
+func TestDroppedEventsAreReportedWhereTheyWereDropped(t *testing.T) {
+	bus := NewBus()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handled := make(chan Event, 10)
+	bus.Subscribe("test", 1, func(event Event) {
+		if event == numbered(1) {
+			close(started)
+			<-release
+		}
+		handled <- event
+	})
+
+	bus.Publish(numbered(1))
+	<-started
+
+	// 2 fills the queue while 1 is handled, 3 is dropped
+	bus.Publish(numbered(2))
+	bus.Publish(numbered(3))
+	close(release)
+
+	var got []Event
+	for len(got) < 2 {
+		select {
+		case event := <-handled:
+			got = append(got, event)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("events were not handled, got %v", got)
+		}
+	}
+
+	bus.Publish(numbered(4))
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	close(handled)
+	for event := range handled {
+		got = append(got, event)
+	}
+
+	want := []Event{numbered(1), numbered(2), EventsDropped{Count: 1}, numbered(4)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("handled %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package events
+
+import (
+	"time"
+
+	"game2048/pkg/models"
+)
+
+// Event is a fact published on the bus, subscribers tell events apart with a type switch
+type Event interface {
+	// EventName identifies the event in logs and external payloads
+	EventName() string
+}
+
+// GameStarted is published when a user starts a new game
+type GameStarted struct {
+	UserID string
+	Game   models.GameState
+	At     time.Time
+}
+
+// MoveApplied is published after a move was applied to a game and saved
+type MoveApplied struct {
+	UserID      string
+	Game        models.GameState
+	Direction   models.Direction
+	ScoreGained int
+	At          time.Time
+}
+
+// GameFinished is published after the move that ended a game, following its MoveApplied event
+type GameFinished struct {
+	UserID string
+	Game   models.GameState
+	At     time.Time
+}
+
+// UserLoggedIn is published when a user signs in
+type UserLoggedIn struct {
+	UserID   string
+	Provider string
+	At       time.Time
+}
+
+// SeasonClosed is published after a season was closed and its final standings archived
+type SeasonClosed struct {
+	Season models.Season
+	At     time.Time
+}
+
+// EventsDropped is delivered to a subscriber in place of events dropped because its queue was full,
+// right before the next event queued for it. Subscribers following a game must assume they missed any of its events.
+type EventsDropped struct {
+	Count int64
+}
+
+// EventName returns the name of the event
+func (GameStarted) EventName() string { return "game.started" }
+
+// EventName returns the name of the event
+func (MoveApplied) EventName() string { return "game.move_applied" }
+
+// EventName returns the name of the event
+func (GameFinished) EventName() string { return "game.finished" }
+
+// EventName returns the name of the event
+func (UserLoggedIn) EventName() string { return "user.logged_in" }
+
+// EventName returns the name of the event
+func (SeasonClosed) EventName() string { return "season.closed" }
+
+// EventName returns the name of the event
+func (EventsDropped) EventName() string { return "bus.events_dropped" }
@@ -1,7 +1,9 @@
 package game
 
 import (
+	"encoding/binary"
 	"game2048/pkg/models"
+	"hash/fnv"
 	"math/rand"
 	"time"
 )
@@ -23,14 +25,36 @@ func (e *Engine) NewGame() models.Board {
 	board := models.NewBoard()
 
 	// Add two initial tiles
-	e.addRandomTile(&board)
-	e.addRandomTile(&board)
+	e.addRandomTile(&board, e.rng)
+	e.addRandomTile(&board, e.rng)
+
+	return board
+}
+
+// NewSeededGame creates a new game whose initial tiles only depend on the seed
+func (e *Engine) NewSeededGame(seed int64) models.Board {
+	board := models.NewBoard()
+
+	rng := rand.New(rand.NewSource(seed))
+	e.addRandomTile(&board, rng)
+	e.addRandomTile(&board, rng)
 
 	return board
 }
 
 // Move executes a move in the given direction and returns the new board and score gained
 func (e *Engine) Move(board models.Board, direction models.Direction) (models.Board, int, bool) {
+	return e.move(board, direction, 0)
+}
+
+// MoveSeeded executes a move like Move, but the new tile only depends on the seed and the board.
+// Players of a seeded game who make the same moves get the same tiles.
+func (e *Engine) MoveSeeded(board models.Board, direction models.Direction, seed int64) (models.Board, int, bool) {
+	return e.move(board, direction, seed)
+}
+
+// move executes a move, spawning the new tile from the seed if it is not 0
+func (e *Engine) move(board models.Board, direction models.Direction, seed int64) (models.Board, int, bool) {
 	newBoard := board.Copy()
 	scoreGained := 0
 	moved := false
@@ -48,7 +72,11 @@ func (e *Engine) Move(board models.Board, direction models.Direction) (models.Bo
 
 	// Add a new tile if the move was valid
 	if moved {
-		e.addRandomTile(&newBoard)
+		rng := e.rng
+		if seed != 0 {
+			rng = seededRand(seed, newBoard)
+		}
+		e.addRandomTile(&newBoard, rng)
 	}
 
 	return newBoard, scoreGained, moved
@@ -82,19 +110,34 @@ func (e *Engine) IsVictory(board models.Board) bool {
 	return board.HasVictoryTile()
 }
 
+// seededRand returns a random source derived from a seed and a board
+func seededRand(seed int64, board models.Board) *rand.Rand {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(seed))
+	h.Write(buf[:])
+	for _, row := range board {
+		for _, cell := range row {
+			binary.LittleEndian.PutUint64(buf[:], uint64(cell))
+			h.Write(buf[:])
+		}
+	}
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
 // addRandomTile adds a random tile (2 or 4) to an empty position
-func (e *Engine) addRandomTile(board *models.Board) bool {
+func (e *Engine) addRandomTile(board *models.Board, rng *rand.Rand) bool {
 	emptyCells := board.GetEmptyCells()
 	if len(emptyCells) == 0 {
 		return false
 	}
 
 	// Choose random empty cell
-	pos := emptyCells[e.rng.Intn(len(emptyCells))]
+	pos := emptyCells[rng.Intn(len(emptyCells))]
 
 	// 90% chance for 2, 10% chance for 4
 	value := 2
-	if e.rng.Float32() < 0.1 {
+	if rng.Float32() < 0.1 {
 		value = 4
 	}
 
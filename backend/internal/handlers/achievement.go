@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"game2048/internal/achievements"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AchievementHandler handles achievement requests
+type AchievementHandler struct {
+	engine *achievements.Engine
+}
+
+// NewAchievementHandler creates a new achievement handler
+func NewAchievementHandler(engine *achievements.Engine) *AchievementHandler {
+	return &AchievementHandler{
+		engine: engine,
+	}
+}
+
+// ListMyAchievements returns every achievement with whether the authenticated user has unlocked it
+func (h *AchievementHandler) ListMyAchievements(c *gin.Context) {
+	statuses, err := h.engine.Achievements(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get achievements",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"achievements": statuses,
+	})
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"game2048/internal/auth"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ListAPITokens lists the personal API tokens of the authenticated user, without their values
+func (h *AuthHandler) ListAPITokens(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	tokens, err := h.authService.APITokens(userID)
+	if err != nil {
+		log.Printf("Failed to get API tokens of user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get API tokens",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": tokens,
+		"scopes": models.AllTokenScopes(),
+	})
+}
+
+// CreateAPIToken creates a personal API token for the authenticated user.
+// The token value is only in this response, it is stored hashed.
+func (h *AuthHandler) CreateAPIToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req models.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !scope.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Unknown scope: " + string(scope),
+			})
+			return
+		}
+	}
+
+	token, err := h.authService.CreateAPIToken(userID, &req)
+	if err == auth.ErrTooManyAPITokens {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "At most " + strconv.Itoa(auth.MaxAPITokens) + " API tokens can be active, revoke one first",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create API token for user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create API token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// RevokeAPIToken revokes a personal API token of the authenticated user and closes its WebSocket connections
+func (h *AuthHandler) RevokeAPIToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "API token not found",
+		})
+		return
+	}
+
+	if err := h.authService.RevokeAPIToken(userID, tokenID.String()); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "API token not found",
+		})
+		return
+	}
+
+	h.sessions.DisconnectToken(tokenID.String())
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API token revoked",
+	})
+}
@@ -1,31 +1,60 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"game2048/internal/auth"
 	"game2048/internal/database"
+	"game2048/internal/events"
+	"game2048/internal/service"
+	"game2048/pkg/models"
 
 	"github.com/gin-gonic/gin"
 )
 
+// SessionCloser closes the live connections of revoked sessions
+type SessionCloser interface {
+	DisconnectToken(tokenID string)
+	DisconnectUser(userID string)
+}
+
 // AuthHandler handles authentication-related requests
 type AuthHandler struct {
 	authService *auth.AuthService
 	db          database.Database
+	bus         *events.Bus
+	sessions    SessionCloser
+	games       *service.GameService // Merges guest games into the account on login
 }
 
 // NewAuthHandler creates a new authentication handler
-func NewAuthHandler(authService *auth.AuthService, db database.Database) *AuthHandler {
+func NewAuthHandler(authService *auth.AuthService, db database.Database, bus *events.Bus, sessions SessionCloser, games *service.GameService) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
 		db:          db,
+		bus:         bus,
+		sessions:    sessions,
+		games:       games,
 	}
 }
 
-// Login initiates the OAuth2 login flow
+// Login initiates the OAuth2 login flow with a provider, the first configured one if none is given
 func (h *AuthHandler) Login(c *gin.Context) {
-	authURL, err := h.authService.GetAuthURL()
+	provider := c.Param("provider")
+	if provider == "" {
+		provider = h.authService.DefaultProvider()
+	}
+
+	authURL, err := h.authService.GetAuthURL(c.Request.Context(), provider)
+	if err == auth.ErrUnknownProvider {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Unknown login provider",
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to generate auth URL",
@@ -36,7 +65,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	c.Redirect(http.StatusTemporaryRedirect, authURL)
 }
 
-// Callback handles the OAuth2 callback
+// Callback handles the OAuth2 callback of a provider.
+// The callback route without a provider serves redirect URLs registered before multiple providers were supported.
 func (h *AuthHandler) Callback(c *gin.Context) {
 	code := c.Query("code")
 	state := c.Query("state")
@@ -58,7 +88,7 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 	}
 
 	// Handle the callback
-	user, token, err := h.authService.HandleCallback(c.Request.Context(), code, state)
+	result, err := h.authService.HandleCallback(c.Request.Context(), c.Param("provider"), code, state)
 	if err != nil {
 		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
 			"error": "Authentication failed: " + err.Error(),
@@ -66,58 +96,260 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 		return
 	}
 
-	// Check if user exists in database
-	existingUser, err := h.db.GetUserByProvider(user.Provider, user.ProviderID)
+	// Linking an identity to the signed in user does not sign in again
+	if result.LinkUserID != "" {
+		h.finishLink(c, result)
+		return
+	}
+
+	// Find the user of the identity, creating it on the first sign in
+	user, err := h.authService.SignIn(result.Identity)
 	if err != nil {
-		// User doesn't exist, create new user
-		if err := h.db.CreateUser(user); err != nil {
-			c.HTML(http.StatusInternalServerError, "error.html", gin.H{
-				"error": "Failed to create user account",
-			})
+		log.Printf("Failed to sign in with %s: %v", result.Identity.Provider, err)
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to create user account",
+		})
+		return
+	}
+
+	// Suspended and banned users are not signed in
+	switch user.Moderation(time.Now()) {
+	case models.ModerationSuspended:
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Your account is suspended until " + user.SuspendedUntil.UTC().Format("2006-01-02 15:04 MST"),
+		})
+		return
+	case models.ModerationBanned:
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Your account is banned",
+		})
+		return
+	}
+
+	// A guest signing in keeps the games it played
+	h.mergeGuest(c, user.ID)
+
+	// Issue tokens with the correct user ID (either new or existing)
+	tokens, err := h.authService.IssueTokens(user.ID)
+	if err != nil {
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to generate authentication token",
+		})
+		return
+	}
+
+	h.bus.Publish(events.UserLoggedIn{UserID: user.ID, Provider: result.Identity.Provider, At: time.Now()})
+
+	h.setAuthCookies(c, tokens)
+
+	// Redirect to game page
+	c.HTML(http.StatusOK, "login_success.html", gin.H{
+		"user":  user,
+		"token": tokens.AccessToken,
+	})
+}
+
+// Guest starts guest play, or continues it with the guest token cookie of an earlier visit
+func (h *AuthHandler) Guest(c *gin.Context) {
+	if token, err := c.Cookie("guest_token"); err == nil {
+		if claims, err := h.authService.ParseGuestToken(token); err == nil {
+			if guest, err := h.db.GetUser(claims.UserID); err == nil {
+				c.JSON(http.StatusOK, models.GuestSession{
+					Token:     token,
+					ExpiresIn: int(time.Until(claims.ExpiresAt).Seconds()),
+					User:      guest,
+				})
+				return
+			}
+		}
+	}
+
+	guest, token, err := h.authService.CreateGuest(c.ClientIP())
+	if err == auth.ErrGuestPlayDisabled {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Guest play is disabled",
+		})
+		return
+	}
+	if err == auth.ErrGuestRateLimited {
+		c.Header("Retry-After", strconv.Itoa(int(h.authService.GuestRetryAfter().Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many guests created, please try again later",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to create guest: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to start guest play",
+		})
+		return
+	}
+
+	expiresIn := int(h.authService.GuestTokenTTL().Seconds())
+	c.SetCookie(
+		"guest_token",
+		token,
+		expiresIn,
+		"/",
+		"",
+		h.isHTTPS(c),
+		true,
+	)
+
+	c.JSON(http.StatusOK, models.GuestSession{
+		Token:     token,
+		ExpiresIn: expiresIn,
+		User:      guest,
+	})
+}
+
+// mergeGuest moves the games of the guest token cookie's guest to the user that signed in and ends guest play.
+// Failing to merge does not fail the login, the guest's games stay with the guest.
+func (h *AuthHandler) mergeGuest(c *gin.Context, userID string) {
+	token, err := c.Cookie("guest_token")
+	if err != nil {
+		return
+	}
+
+	if claims, err := h.authService.ParseGuestToken(token); err == nil {
+		if err := h.games.MergeGuest(c.Request.Context(), claims.UserID, userID); err != nil {
+			log.Printf("Failed to merge guest %s into user %s: %v", claims.UserID, userID, err)
 			return
 		}
-	} else {
-		// User exists, update user info but keep the existing ID
-		user.ID = existingUser.ID
-		user.CreatedAt = existingUser.CreatedAt
-		if err := h.db.CreateUser(user); err != nil {
-			c.HTML(http.StatusInternalServerError, "error.html", gin.H{
-				"error": "Failed to update user account",
+		h.sessions.DisconnectUser(claims.UserID)
+	}
+
+	c.SetCookie("guest_token", "", -1, "/", "", h.isHTTPS(c), true)
+}
+
+// Refresh exchanges a refresh token, from the JSON body or else the refresh cookie, for new tokens.
+// The new refresh token is only returned in the body if the old one came in the body, otherwise it stays in the HTTP-only cookie.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	fromCookie := false
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		req.RefreshToken, _ = c.Cookie("refresh_token")
+		fromCookie = true
+	}
+
+	if req.RefreshToken == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Missing refresh token",
+		})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		switch err {
+		case auth.ErrRefreshTokenReused:
+			h.clearAuthCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Refresh token was already used, please log in again",
+			})
+		case auth.ErrInvalidRefreshToken:
+			h.clearAuthCookies(c)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid refresh token",
+			})
+		default:
+			log.Printf("Failed to refresh tokens: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to refresh tokens",
+			})
+		}
+		return
+	}
+
+	h.setAuthCookies(c, tokens)
+
+	response := *tokens
+	if fromCookie {
+		response.RefreshToken = ""
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout handles user logout, revoking the token it was called with
+func (h *AuthHandler) Logout(c *gin.Context) {
+	// Revoke the refresh token so the session cannot be extended
+	if refreshToken, err := c.Cookie("refresh_token"); err == nil {
+		if err := h.authService.RevokeRefreshToken(refreshToken); err != nil {
+			log.Printf("Failed to revoke refresh token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to log out",
 			})
 			return
 		}
 	}
 
-	// Generate JWT token with the correct user ID (either new or existing)
-	token, err = h.authService.GenerateJWT(user.ID)
-	if err != nil {
-		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
-			"error": "Failed to generate authentication token",
+	if token, ok := h.requestToken(c); ok {
+		// Logging out with an invalid or already revoked token only clears the cookie
+		if claims, err := h.authService.ParseJWT(token); err == nil {
+			if err := h.authService.RevokeJWT(claims); err != nil {
+				log.Printf("Failed to revoke token of user %s: %v", claims.UserID, err)
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"error": "Failed to log out",
+				})
+				return
+			}
+			h.sessions.DisconnectToken(claims.TokenID)
+		}
+	}
+
+	h.clearAuthCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// LogoutAll revokes every token of the authenticated user, logging out all of their sessions
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	if err := h.authService.RevokeAllJWTs(userID); err != nil {
+		log.Printf("Failed to revoke tokens of user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to log out all sessions",
 		})
 		return
 	}
+	h.sessions.DisconnectUser(userID)
+
+	h.clearAuthCookies(c)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out of all sessions",
+	})
+}
 
-	// Set JWT token as HTTP-only cookie
+// setAuthCookies stores issued tokens as HTTP-only cookies.
+// The refresh token cookie is only sent to the auth routes.
+func (h *AuthHandler) setAuthCookies(c *gin.Context, tokens *models.AuthTokens) {
 	c.SetCookie(
 		"auth_token",
-		token,
-		3600*24, // 24 hours
+		tokens.AccessToken,
+		tokens.ExpiresIn,
 		"/",
 		"",
 		h.isHTTPS(c), // Secure flag based on HTTPS detection
 		true,         // HTTP-only
 	)
-
-	// Redirect to game page
-	c.HTML(http.StatusOK, "login_success.html", gin.H{
-		"user":  user,
-		"token": token,
-	})
+	c.SetCookie(
+		"refresh_token",
+		tokens.RefreshToken,
+		tokens.RefreshExpiresIn,
+		"/auth",
+		"",
+		h.isHTTPS(c),
+		true,
+	)
 }
 
-// Logout handles user logout
-func (h *AuthHandler) Logout(c *gin.Context) {
-	// Clear the auth cookie
+// clearAuthCookies clears the auth and refresh token cookies
+func (h *AuthHandler) clearAuthCookies(c *gin.Context) {
 	c.SetCookie(
 		"auth_token",
 		"",
@@ -127,10 +359,15 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		h.isHTTPS(c), // Same secure flag as when setting
 		true,
 	)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Logged out successfully",
-	})
+	c.SetCookie(
+		"refresh_token",
+		"",
+		-1,
+		"/auth",
+		"",
+		h.isHTTPS(c),
+		true,
+	)
 }
 
 // Me returns the current user information
@@ -151,28 +388,48 @@ func (h *AuthHandler) Me(c *gin.Context) {
 		return
 	}
 
+	badges, err := h.db.GetUserBadges(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load user badges",
+		})
+		return
+	}
+
+	permissions, err := h.db.GetUserPermissions(user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load user permissions",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"user": user,
+		"user":        user,
+		"badges":      badges,
+		"permissions": permissions,
 	})
 }
 
-// AuthMiddleware validates JWT tokens
-func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware validates JWT tokens.
+// Personal API tokens are accepted only when they have one of the scopes, so routes without scopes are limited to sessions.
+func (h *AuthHandler) AuthMiddleware(scopes ...models.TokenScope) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Try to get token from cookie first
-		token, err := c.Cookie("auth_token")
-		if err != nil {
-			// Try to get token from Authorization header
-			authHeader := c.GetHeader("Authorization")
-			if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-				token = authHeader[7:]
-			} else {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Missing authentication token",
-				})
-				c.Abort()
-				return
+		token, ok := h.requestToken(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Missing authentication token",
+			})
+			c.Abort()
+			return
+		}
+
+		if auth.IsAPIToken(token) {
+			if userID, ok := h.apiTokenUser(c, token, scopes); ok && h.accountAllowed(c, userID) {
+				c.Set("user_id", userID)
+				c.Next()
 			}
+			return
 		}
 
 		// Validate token
@@ -185,6 +442,11 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Suspended and banned users are refused even with tokens issued before
+		if !h.accountAllowed(c, userID) {
+			return
+		}
+
 		// Set user ID in context
 		c.Set("user_id", userID)
 		c.Next()
@@ -194,18 +456,11 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 // OptionalAuthMiddleware validates JWT tokens but doesn't require them
 func (h *AuthHandler) OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Try to get token from cookie first
-		token, err := c.Cookie("auth_token")
-		if err != nil {
-			// Try to get token from Authorization header
-			authHeader := c.GetHeader("Authorization")
-			if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-				token = authHeader[7:]
-			} else {
-				// No token found, continue without authentication
-				c.Next()
-				return
-			}
+		token, ok := h.requestToken(c)
+		if !ok {
+			// No token found, continue without authentication
+			c.Next()
+			return
 		}
 
 		// Validate token
@@ -216,12 +471,189 @@ func (h *AuthHandler) OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Suspended and banned users continue without authentication
+		if _, err := h.authService.CheckAccount(userID); err != nil {
+			c.Next()
+			return
+		}
+
 		// Set user ID in context
 		c.Set("user_id", userID)
 		c.Next()
 	}
 }
 
+// RequirePermission requires the authenticated user to have a permission through one of their roles.
+// It runs after AuthMiddleware. Permissions are looked up on every request, so revoking a role takes effect immediately.
+func (h *AuthHandler) RequirePermission(permission models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("user_id")
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required",
+			})
+			c.Abort()
+			return
+		}
+
+		permissions, err := h.db.GetUserPermissions(userID)
+		if err != nil {
+			log.Printf("Failed to get permissions of user %s: %v", userID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to check permissions",
+			})
+			c.Abort()
+			return
+		}
+
+		if !models.HasPermission(permissions, permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Access denied. Missing permission: " + string(permission),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// PlayerMiddleware validates the tokens of signed-in users and guests, setting guest for guests.
+// Personal API tokens are accepted when they have one of the scopes.
+func (h *AuthHandler) PlayerMiddleware(scopes ...models.TokenScope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, ok := h.requestToken(c); ok && auth.IsAPIToken(token) {
+			if userID, ok := h.apiTokenUser(c, token, scopes); ok && h.accountAllowed(c, userID) {
+				c.Set("user_id", userID)
+				c.Set("guest", false)
+				c.Next()
+			}
+			return
+		}
+
+		userID, guest, ok := h.player(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "Missing authentication token",
+			})
+			c.Abort()
+			return
+		}
+
+		// Guests cannot be moderated, they are kept off leaderboards anyway
+		if !guest && !h.accountAllowed(c, userID) {
+			return
+		}
+
+		c.Set("user_id", userID)
+		c.Set("guest", guest)
+		c.Next()
+	}
+}
+
+// OptionalPlayerMiddleware validates the tokens of signed-in users and guests but doesn't require them
+func (h *AuthHandler) OptionalPlayerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID, guest, ok := h.player(c); ok {
+			// Suspended and banned users continue without authentication, so they see the login page
+			if _, err := h.authService.CheckAccount(userID); guest || err == nil {
+				c.Set("user_id", userID)
+				c.Set("guest", guest)
+			}
+		}
+		c.Next()
+	}
+}
+
+// player returns the signed-in user or guest making a request.
+// The guest token is read from the request token or else the guest token cookie.
+func (h *AuthHandler) player(c *gin.Context) (string, bool, bool) {
+	if token, ok := h.requestToken(c); ok {
+		if claims, err := h.authService.ParsePlayerToken(token); err == nil {
+			return claims.UserID, claims.Guest, true
+		}
+	}
+
+	if token, err := c.Cookie("guest_token"); err == nil {
+		if claims, err := h.authService.ParseGuestToken(token); err == nil {
+			return claims.UserID, true, true
+		}
+	}
+
+	return "", false, false
+}
+
+// accountAllowed checks that the user of a request is not suspended or banned.
+// It writes the error response and aborts the request when the user is refused.
+func (h *AuthHandler) accountAllowed(c *gin.Context, userID string) bool {
+	user, err := h.authService.CheckAccount(userID)
+	switch err {
+	case nil:
+		return true
+	case auth.ErrAccountSuspended:
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":           "Account suspended",
+			"suspended_until": user.SuspendedUntil,
+		})
+	case auth.ErrAccountBanned:
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Account banned",
+		})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "User not found",
+		})
+	}
+	c.Abort()
+	return false
+}
+
+// apiTokenUser authenticates the personal API token of a request that needs one of the scopes and returns its user.
+// It writes the error response and aborts the request when the token is not accepted.
+func (h *AuthHandler) apiTokenUser(c *gin.Context, token string, scopes []models.TokenScope) (string, bool) {
+	apiToken, err := h.authService.AuthenticateAPIToken(token, scopes...)
+	switch err {
+	case nil:
+		c.Set("api_token_id", apiToken.ID.String())
+		return apiToken.UserID, true
+	case auth.ErrAPITokenScope:
+		message := "API tokens are not accepted for this endpoint"
+		if len(scopes) > 0 {
+			message = "API token is missing scope: " + models.JoinTokenScopes(scopes)
+		}
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": message,
+		})
+	case auth.ErrAPITokenRateLimited:
+		c.Header("Retry-After", strconv.Itoa(int(h.authService.APITokenRetryAfter().Seconds())+1))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": "API token rate limit exceeded",
+		})
+	default:
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid authentication token",
+		})
+	}
+	c.Abort()
+	return "", false
+}
+
+// requestToken returns the JWT or API token of a request, from the auth cookie or else the Authorization header
+func (h *AuthHandler) requestToken(c *gin.Context) (string, bool) {
+	// Try to get token from cookie first
+	if token, err := c.Cookie("auth_token"); err == nil {
+		return token, true
+	}
+
+	// Try to get token from Authorization header
+	authHeader := c.GetHeader("Authorization")
+	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
+		return authHeader[7:], true
+	}
+
+	return "", false
+}
+
 // isHTTPS determines if the request is using HTTPS
 // Checks TLS connection, X-Forwarded-Proto header, and X-Forwarded-Ssl header
 func (h *AuthHandler) isHTTPS(c *gin.Context) bool {
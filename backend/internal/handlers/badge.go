@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"game2048/internal/render"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// badgeMaxAge is how long badges and the ranks they show may be cached
+const badgeMaxAge = 5 * time.Minute
+
+// GetBadge renders an embeddable SVG badge with a user's rank and best score on a leaderboard.
+// The route parameter is the user ID followed by ".svg", the period parameter the leaderboard type.
+func (h *LeaderboardHandler) GetBadge(c *gin.Context) {
+	file := c.Param("file")
+	if !strings.HasSuffix(file, ".svg") {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Badge not found",
+		})
+		return
+	}
+	userID := strings.TrimSuffix(file, ".svg")
+
+	lbType, ok := parseLeaderboardType(c.DefaultQuery("period", "all"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid period. Must be one of: daily, weekly, monthly, all, season",
+		})
+		return
+	}
+
+	user, err := h.db.GetUser(userID)
+	if err != nil || user.ProfileHidden {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Player not found",
+		})
+		return
+	}
+
+	query, season, err := h.resolveQuery(lbType, c.Query("season"))
+	if err != nil {
+		writeSeasonError(c, err)
+		return
+	}
+
+	entry, err := h.loadUserRank(query, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user rank",
+		})
+		return
+	}
+
+	label := "2048 " + string(lbType)
+	switch {
+	case season != nil:
+		label = "2048 " + season.Name
+	case lbType == models.LeaderboardAll:
+		label = "2048 all time"
+	}
+
+	message := "unranked"
+	if entry != nil {
+		message = fmt.Sprintf("#%d | %s", entry.Rank, render.FormatScore(entry.Score))
+	}
+
+	svg := render.BadgeSVG(label, message)
+	sum := sha256.Sum256(svg)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(badgeMaxAge.Seconds())))
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", svg)
+}
+
+// loadUserRank returns a user's leaderboard entry, using the cache when available.
+// It returns nil if the user is not ranked. Filtered ranks depend on who is asking and are not cached.
+func (h *LeaderboardHandler) loadUserRank(query models.LeaderboardQuery, userID string) (*models.LeaderboardEntry, error) {
+	if query.IsFiltered() {
+		return h.db.GetUserRank(query, userID)
+	}
+
+	if h.cache != nil {
+		if entry, err := h.cache.GetUserRank(query, userID); err == nil {
+			return entry, nil
+		}
+	}
+
+	entry, err := h.db.GetUserRank(query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.cache != nil {
+		if err := h.cache.SetUserRank(query, userID, entry, badgeMaxAge); err != nil {
+			log.Printf("Failed to cache user rank: %v", err)
+		}
+	}
+
+	return entry, nil
+}
+
+// etagMatches reports whether an If-None-Match header matches the ETag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+
+	"game2048/internal/service"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GameHandler handles game requests for clients that do not use the WebSocket
+type GameHandler struct {
+	games *service.GameService
+}
+
+// NewGameHandler creates a new game handler
+func NewGameHandler(games *service.GameService) *GameHandler {
+	return &GameHandler{
+		games: games,
+	}
+}
+
+// NewGame starts a new game for the authenticated user, replacing the current one
+func (h *GameHandler) NewGame(c *gin.Context) {
+	gameState, err := h.games.NewGame(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create new game",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"game":    gameState,
+		"message": "New game started!",
+	})
+}
+
+// CurrentGame returns the authenticated user's current game
+func (h *GameHandler) CurrentGame(c *gin.Context) {
+	gameState, err := h.games.CurrentGame(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get game state",
+		})
+		return
+	}
+
+	if gameState == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No active game found. Start a new game first.",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game":    gameState,
+		"message": gameState.FinishMessage(),
+	})
+}
+
+// GetGame returns one of the authenticated user's games
+func (h *GameHandler) GetGame(c *gin.Context) {
+	gameID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Game not found",
+		})
+		return
+	}
+
+	gameState, err := h.games.GetGame(c.Request.Context(), c.GetString("user_id"), gameID)
+	if err != nil {
+		if err == service.ErrGameNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Game not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get game state",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game":    gameState,
+		"message": gameState.FinishMessage(),
+	})
+}
+
+// Move applies a move to one of the authenticated user's games, which must be the current one
+func (h *GameHandler) Move(c *gin.Context) {
+	gameID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Game not found",
+		})
+		return
+	}
+
+	var req models.MoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid move request format",
+		})
+		return
+	}
+
+	gameState, err := h.games.Move(c.Request.Context(), c.GetString("user_id"), gameID, req.Direction)
+	if err != nil {
+		status, message := moveErrorResponse(err)
+		c.JSON(status, gin.H{
+			"error": message,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"game":    gameState,
+		"message": gameState.FinishMessage(),
+	})
+}
+
+// moveErrorResponse returns the status code and message for a move error
+func moveErrorResponse(err error) (int, string) {
+	switch err {
+	case service.ErrInvalidDirection:
+		return http.StatusBadRequest, "Invalid direction"
+	case service.ErrInvalidMove:
+		return http.StatusUnprocessableEntity, "Invalid move - no tiles moved"
+	case service.ErrNoActiveGame, service.ErrGameNotFound:
+		return http.StatusNotFound, "Game not found"
+	case service.ErrGameFinished:
+		return http.StatusConflict, "Game is already finished"
+	case service.ErrRoundEnded:
+		return http.StatusConflict, "Tournament round has ended"
+	default:
+		return http.StatusInternalServerError, "Failed to get game state"
+	}
+}
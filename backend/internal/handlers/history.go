@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"game2048/internal/database"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HistoryHandler handles requests for a user's game history
+type HistoryHandler struct {
+	db       database.Database
+	calendar models.PeriodCalendar
+}
+
+// NewHistoryHandler creates a new history handler
+func NewHistoryHandler(db database.Database, calendar models.PeriodCalendar) *HistoryHandler {
+	return &HistoryHandler{
+		db:       db,
+		calendar: calendar,
+	}
+}
+
+// ListMyGames returns a page of the authenticated user's games, most recent first
+func (h *HistoryHandler) ListMyGames(c *gin.Context) {
+	filter, ok := h.parseFilter(c)
+	if !ok {
+		return
+	}
+
+	limitStr := c.DefaultQuery("limit", "20")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offsetStr := c.DefaultQuery("offset", "0")
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	userID := c.GetString("user_id")
+
+	games, err := h.db.GetUserGames(userID, filter, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get games",
+		})
+		return
+	}
+
+	total, err := h.db.GetUserGameCount(userID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get games",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"games":  games,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	})
+}
+
+// GetMyStats returns statistics over the authenticated user's games, accepting the same filters as ListMyGames
+func (h *HistoryHandler) GetMyStats(c *gin.Context) {
+	filter, ok := h.parseFilter(c)
+	if !ok {
+		return
+	}
+
+	loc := h.calendar.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	stats, err := h.db.GetUserGameStats(c.GetString("user_id"), filter, loc)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get game statistics",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// parseFilter reads the history filter from the query parameters.
+// Dates are calendar days in the leaderboard timezone, both ends inclusive.
+// It writes an error response and returns false on invalid parameters.
+func (h *HistoryHandler) parseFilter(c *gin.Context) (models.GameHistoryFilter, bool) {
+	var filter models.GameHistoryFilter
+
+	if fromStr := c.Query("from"); fromStr != "" {
+		date, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid from date. Must be in YYYY-MM-DD format",
+			})
+			return filter, false
+		}
+		from := h.calendar.Date(date.Date())
+		filter.From = &from
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		date, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid to date. Must be in YYYY-MM-DD format",
+			})
+			return filter, false
+		}
+		to := h.calendar.Date(date.Date()).AddDate(0, 0, 1)
+		filter.To = &to
+	}
+
+	filter.Mode = c.Query("mode")
+
+	switch status := models.GameStatus(c.Query("status")); status {
+	case "", models.GameStatusFinished, models.GameStatusAbandoned, models.GameStatusActive:
+		filter.Status = status
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status. Must be one of: finished, abandoned, active",
+		})
+		return filter, false
+	}
+
+	if minScoreStr := c.Query("min_score"); minScoreStr != "" {
+		minScore, err := strconv.Atoi(minScoreStr)
+		if err != nil || minScore < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid min_score",
+			})
+			return filter, false
+		}
+		filter.MinScore = minScore
+	}
+
+	return filter, true
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"game2048/internal/auth"
+	"game2048/internal/database"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Link starts linking the identity of a provider to the authenticated user.
+// The client sends the user to the returned URL, the provider redirects back to the login callback.
+func (h *AuthHandler) Link(c *gin.Context) {
+	authURL, err := h.authService.GetLinkURL(c.Request.Context(), c.Param("provider"), c.GetString("user_id"))
+	if err == auth.ErrUnknownProvider {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Unknown login provider",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to generate link URL: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate auth URL",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"auth_url": authURL,
+	})
+}
+
+// finishLink links the identity of a callback to the user that started linking it.
+// The browser must still be signed in as that user, so a link started by someone else cannot capture this browser's identity.
+func (h *AuthHandler) finishLink(c *gin.Context, result *auth.CallbackResult) {
+	userID := ""
+	if token, ok := h.requestToken(c); ok {
+		userID, _ = h.authService.ValidateJWT(token)
+	}
+	if userID != result.LinkUserID {
+		c.HTML(http.StatusForbidden, "error.html", gin.H{
+			"error": "Linking was started by another user, please sign in and try again",
+		})
+		return
+	}
+
+	err := h.authService.LinkIdentity(userID, result.Identity)
+	if err == auth.ErrIdentityLinked {
+		c.HTML(http.StatusConflict, "error.html", gin.H{
+			"error": "This account is already linked to another user",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to link %s identity to user %s: %v", result.Identity.Provider, userID, err)
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to link account",
+		})
+		return
+	}
+
+	c.Redirect(http.StatusFound, "/")
+}
+
+// Identities lists the identities the authenticated user can sign in with
+func (h *AuthHandler) Identities(c *gin.Context) {
+	h.listIdentities(c, c.GetString("user_id"))
+}
+
+// UnlinkIdentity removes one of the authenticated user's identities, the last one cannot be removed
+func (h *AuthHandler) UnlinkIdentity(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	identityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Identity not found",
+		})
+		return
+	}
+
+	err = h.authService.UnlinkIdentity(userID, identityID.String())
+	switch err {
+	case nil:
+	case database.ErrIdentityNotFound:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Identity not found",
+		})
+		return
+	case database.ErrLastIdentity:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The last sign-in method cannot be removed",
+		})
+		return
+	default:
+		log.Printf("Failed to unlink identity %s of user %s: %v", identityID, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to unlink identity",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Identity unlinked",
+	})
+}
+
+// UserIdentities lists the identities of any user (requires users:manage)
+func (h *AuthHandler) UserIdentities(c *gin.Context) {
+	h.listIdentities(c, c.Param("id"))
+}
+
+// MergeUsers merges a duplicate account into the user of the path (requires users:manage).
+// The source account's games, identities and other records move to the target and the source is deleted.
+func (h *AuthHandler) MergeUsers(c *gin.Context) {
+	targetID := c.Param("id")
+
+	var req models.MergeUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	if req.SourceUserID == targetID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "An account cannot be merged into itself",
+		})
+		return
+	}
+
+	for _, userID := range []string{req.SourceUserID, targetID} {
+		if _, err := h.db.GetUser(userID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+	}
+
+	if err := h.games.MergeUsers(c.Request.Context(), req.SourceUserID, targetID); err != nil {
+		log.Printf("Failed to merge user %s into user %s: %v", req.SourceUserID, targetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to merge users",
+		})
+		return
+	}
+
+	// The source account no longer exists, end its sessions
+	if err := h.authService.RevokeAllJWTs(req.SourceUserID); err != nil {
+		log.Printf("Failed to revoke tokens of merged user %s: %v", req.SourceUserID, err)
+	}
+	h.sessions.DisconnectUser(req.SourceUserID)
+
+	h.listIdentities(c, targetID)
+}
+
+// listIdentities writes the identities of a user
+func (h *AuthHandler) listIdentities(c *gin.Context, userID string) {
+	identities, err := h.authService.Identities(userID)
+	if err != nil {
+		if _, userErr := h.db.GetUser(userID); userErr != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "User not found",
+			})
+			return
+		}
+		log.Printf("Failed to get identities of user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get identities",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"identities": identities,
+	})
+}
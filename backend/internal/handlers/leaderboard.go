@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -15,37 +17,31 @@ import (
 
 // LeaderboardHandler handles leaderboard-related requests
 type LeaderboardHandler struct {
-	db    database.Database
-	cache cache.Cache
+	db       database.Database
+	cache    cache.Cache
+	calendar models.PeriodCalendar
 }
 
 // NewLeaderboardHandler creates a new leaderboard handler
-func NewLeaderboardHandler(db database.Database, redisCache cache.Cache) *LeaderboardHandler {
+func NewLeaderboardHandler(db database.Database, redisCache cache.Cache, calendar models.PeriodCalendar) *LeaderboardHandler {
 	return &LeaderboardHandler{
-		db:    db,
-		cache: redisCache,
+		db:       db,
+		cache:    redisCache,
+		calendar: calendar,
 	}
 }
 
+// leaderboardCacheWindow is the number of top entries kept in the leaderboard cache.
+// Pages that fall inside this window are served from cache, deeper pages go to the database.
+const leaderboardCacheWindow = 100
+
 // GetLeaderboard handles public leaderboard requests
 func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
 	// Get leaderboard type from query parameter
-	leaderboardType := c.DefaultQuery("type", "daily")
-
-	// Validate leaderboard type
-	var lbType models.LeaderboardType
-	switch leaderboardType {
-	case "daily":
-		lbType = models.LeaderboardDaily
-	case "weekly":
-		lbType = models.LeaderboardWeekly
-	case "monthly":
-		lbType = models.LeaderboardMonthly
-	case "all":
-		lbType = models.LeaderboardAll
-	default:
+	lbType, ok := parseLeaderboardType(c.DefaultQuery("type", "daily"))
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid leaderboard type. Must be one of: daily, weekly, monthly, all",
+			"error": "Invalid leaderboard type. Must be one of: daily, weekly, monthly, all, season",
 		})
 		return
 	}
@@ -57,24 +53,35 @@ func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
 		limit = 100
 	}
 
-	// Try to get from cache first
-	var entries []models.LeaderboardEntry
+	// Get offset from query parameter (default 0)
+	offsetStr := c.DefaultQuery("offset", "0")
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
 
-	if h.cache != nil {
-		entries, err = h.cache.GetLeaderboard(lbType)
-		if err == nil {
-			// Cache hit, return cached data
-			response := models.LeaderboardResponse{
-				Type:     lbType,
-				Rankings: entries,
-			}
-			c.JSON(http.StatusOK, response)
-			return
-		}
+	query, season, err := h.resolveQuery(lbType, c.Query("season"))
+	if err != nil {
+		writeSeasonError(c, err)
+		return
 	}
 
-	// Cache miss or no cache, get from database
-	entries, err = h.db.GetLeaderboard(lbType, limit)
+	group := c.Query("group")
+	if !h.applyGroupFilter(c, &query, group) {
+		return
+	}
+	if !h.applyViewer(c, &query) {
+		return
+	}
+
+	var entries []models.LeaderboardEntry
+	var total int
+	if season != nil && season.IsClosed() && !query.IsFiltered() {
+		// Closed seasons are served from their frozen final standings
+		entries, total, err = h.loadSeasonStandings(season, limit, offset)
+	} else {
+		entries, total, err = h.loadLeaderboard(query, limit, offset)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to get leaderboard",
@@ -82,28 +89,23 @@ func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
 		return
 	}
 
-	// Cache the result if cache is available
-	if h.cache != nil {
-		cacheTTL := 30 * time.Second // 30 seconds cache
-		if err := h.cache.SetLeaderboard(lbType, entries, cacheTTL); err != nil {
-			// Log error but don't fail the request
-			// log.Printf("Failed to cache leaderboard: %v", err)
-		}
-	}
-
 	// Return response
 	response := models.LeaderboardResponse{
 		Type:     lbType,
 		Rankings: entries,
+		Total:    total,
+		Offset:   offset,
+		Limit:    limit,
+		Period:   query.Period,
+		Season:   season,
+		Group:    group,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
-// RefreshCache manually refreshes the leaderboard cache
-// Only accessible by user with ID "1" (admin)
-func (h *LeaderboardHandler) RefreshCache(c *gin.Context) {
-	// Get user ID from context (set by auth middleware)
+// GetMyRank returns the authenticated user's rank with neighbouring entries above and below
+func (h *LeaderboardHandler) GetMyRank(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -112,14 +114,416 @@ func (h *LeaderboardHandler) RefreshCache(c *gin.Context) {
 		return
 	}
 
-	// Check if user is admin (ID = "1")
-	if userID.(string) != "1" {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Access denied. Admin privileges required.",
+	lbType, ok := parseLeaderboardType(c.DefaultQuery("type", "daily"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid leaderboard type. Must be one of: daily, weekly, monthly, all, season",
+		})
+		return
+	}
+
+	// Get neighbour count from query parameter (default 5, max 25)
+	neighborsStr := c.DefaultQuery("neighbors", "5")
+	neighbors, err := strconv.Atoi(neighborsStr)
+	if err != nil || neighbors < 0 || neighbors > 25 {
+		neighbors = 5
+	}
+
+	query, _, err := h.resolveQuery(lbType, c.Query("season"))
+	if err != nil {
+		writeSeasonError(c, err)
+		return
+	}
+
+	group := c.Query("group")
+	if !h.applyGroupFilter(c, &query, group) {
+		return
+	}
+
+	if !h.applyViewer(c, &query) {
+		return
+	}
+
+	total, err := h.loadLeaderboardTotal(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get leaderboard",
+		})
+		return
+	}
+
+	response := models.UserRankResponse{
+		Type:   lbType,
+		Above:  []models.LeaderboardEntry{},
+		Below:  []models.LeaderboardEntry{},
+		Total:  total,
+		Period: query.Period,
+		Group:  group,
+	}
+
+	entry, err := h.db.GetUserRank(query, userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user rank",
+		})
+		return
+	}
+
+	if entry == nil || neighbors == 0 {
+		// User is not ranked yet, or no neighbours were requested
+		response.Entry = entry
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	// Ranks are 1-based, offsets are 0-based
+	start := entry.Rank - 1 - neighbors
+	if start < 0 {
+		start = 0
+	}
+
+	window, err := h.db.GetLeaderboard(query, entry.Rank+neighbors-start, start)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get leaderboard",
+		})
+		return
+	}
+
+	response.Entry = entry
+	for _, e := range window {
+		switch {
+		case e.Rank < entry.Rank:
+			response.Above = append(response.Above, e)
+		case e.Rank > entry.Rank:
+			response.Below = append(response.Below, e)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetLeaderboardHistory returns a frozen leaderboard of a past period.
+// The date parameter may be any day inside the period, e.g. 2026-09-07 for the week of September 7th.
+func (h *LeaderboardHandler) GetLeaderboardHistory(c *gin.Context) {
+	lbType, ok := parseLeaderboardType(c.DefaultQuery("type", "daily"))
+	if !ok || !lbType.IsPeriodic() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid leaderboard type. Must be one of: daily, weekly, monthly",
+		})
+		return
+	}
+
+	// Default to the most recently finished period
+	current, _ := h.calendar.PeriodContaining(lbType, time.Now())
+	period := h.calendar.Previous(lbType, current)
+
+	if dateStr := c.Query("date"); dateStr != "" {
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid date. Must be in YYYY-MM-DD format",
+			})
+			return
+		}
+		// The date is a calendar day in the leaderboard timezone
+		period, _ = h.calendar.PeriodContaining(lbType, h.calendar.Date(date.Date()))
+	}
+
+	limitStr := c.DefaultQuery("limit", "100")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 100
+	}
+
+	offsetStr := c.DefaultQuery("offset", "0")
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	entries, err := h.db.GetLeaderboardSnapshot(lbType, period.Start, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get leaderboard history",
+		})
+		return
+	}
+
+	total, err := h.db.GetLeaderboardSnapshotCount(lbType, period.Start)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get leaderboard history",
+		})
+		return
+	}
+
+	response := models.LeaderboardResponse{
+		Type:     lbType,
+		Rankings: entries,
+		Total:    total,
+		Offset:   offset,
+		Limit:    limit,
+		Period:   &period,
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetLeaderboardHistoryPeriods lists the past periods that have a frozen leaderboard
+func (h *LeaderboardHandler) GetLeaderboardHistoryPeriods(c *gin.Context) {
+	lbType, ok := parseLeaderboardType(c.DefaultQuery("type", "daily"))
+	if !ok || !lbType.IsPeriodic() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid leaderboard type. Must be one of: daily, weekly, monthly",
+		})
+		return
+	}
+
+	limitStr := c.DefaultQuery("limit", "50")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > 366 {
+		limit = 50
+	}
+
+	starts, err := h.db.GetLeaderboardSnapshotPeriods(lbType, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list leaderboard history",
 		})
 		return
 	}
 
+	periods := make([]models.LeaderboardPeriod, 0, len(starts))
+	for _, start := range starts {
+		// Snapshot dates carry no timezone, they are calendar days in the leaderboard timezone
+		if period, ok := h.calendar.PeriodContaining(lbType, h.calendar.Date(start.Date())); ok {
+			periods = append(periods, period)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":    lbType,
+		"periods": periods,
+	})
+}
+
+var (
+	errSeasonNotFound = errors.New("season not found")
+	errNoActiveSeason = errors.New("no active season")
+)
+
+// writeSeasonError responds to a failed season lookup.
+// Missing seasons are a 404, anything else is a server error.
+func writeSeasonError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errNoActiveSeason):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "No active season",
+		})
+	case errors.Is(err, errSeasonNotFound):
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Season not found",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load season",
+		})
+	}
+}
+
+// resolveQuery builds the leaderboard query for a type.
+// Season leaderboards use the requested season, or the active one when no season is given.
+func (h *LeaderboardHandler) resolveQuery(lbType models.LeaderboardType, seasonID string) (models.LeaderboardQuery, *models.Season, error) {
+	if lbType != models.LeaderboardSeason {
+		return h.calendar.Query(lbType, time.Now()), nil, nil
+	}
+
+	var season *models.Season
+	var err error
+	if seasonID != "" {
+		season, err = h.db.GetSeason(seasonID)
+		if errors.Is(err, database.ErrSeasonNotFound) {
+			return models.LeaderboardQuery{}, nil, errSeasonNotFound
+		}
+		if err != nil {
+			log.Printf("Failed to get season %s: %v", seasonID, err)
+			return models.LeaderboardQuery{}, nil, err
+		}
+	} else {
+		season, err = h.db.GetActiveSeason(time.Now())
+		if err != nil {
+			log.Printf("Failed to get active season: %v", err)
+			return models.LeaderboardQuery{}, nil, err
+		}
+		if season == nil {
+			return models.LeaderboardQuery{}, nil, errNoActiveSeason
+		}
+	}
+
+	return season.Query(), season, nil
+}
+
+// applyGroupFilter limits the query to the friends of the authenticated user, or to a group they are a member of.
+// An empty group leaves the query unfiltered. It writes an error response and returns false on failure.
+func (h *LeaderboardHandler) applyGroupFilter(c *gin.Context, query *models.LeaderboardQuery, group string) bool {
+	if group == "" {
+		return true
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Authentication required for friends and group leaderboards",
+		})
+		return false
+	}
+
+	if group == models.LeaderboardGroupFriends {
+		query.FriendsOf = userID.(string)
+		return true
+	}
+
+	member, err := h.db.IsGroupMember(group, userID.(string))
+	if err != nil || !member {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Group not found",
+		})
+		return false
+	}
+
+	query.GroupID = group
+	return true
+}
+
+// applyViewer ranks a shadow-banned authenticated user on the leaderboard they see, so it agrees with their own rank.
+// Anonymous requests are left unchanged. It writes an error response and returns false on failure.
+func (h *LeaderboardHandler) applyViewer(c *gin.Context, query *models.LeaderboardQuery) bool {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return true
+	}
+
+	user, err := h.db.GetUser(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get leaderboard",
+		})
+		return false
+	}
+
+	*query = query.ViewedBy(user)
+	return true
+}
+
+// loadSeasonStandings returns a page of a closed season's final standings and its total entry count
+func (h *LeaderboardHandler) loadSeasonStandings(season *models.Season, limit, offset int) ([]models.LeaderboardEntry, int, error) {
+	seasonID := season.ID.String()
+
+	entries, err := h.db.GetSeasonStandings(seasonID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := h.db.GetSeasonStandingsCount(seasonID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// loadLeaderboard returns a page of entries and the total participant count, using the cache when possible
+func (h *LeaderboardHandler) loadLeaderboard(query models.LeaderboardQuery, limit, offset int) ([]models.LeaderboardEntry, int, error) {
+	// Pages beyond the cached window and friends or group leaderboards always come from the database
+	if h.cache == nil || offset+limit > leaderboardCacheWindow || query.IsFiltered() {
+		entries, err := h.db.GetLeaderboard(query, limit, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		total, err := h.loadLeaderboardTotal(query)
+		if err != nil {
+			return nil, 0, err
+		}
+		return entries, total, nil
+	}
+
+	// Try to get from cache first
+	entries, err := h.cache.GetLeaderboard(query)
+	if err == nil {
+		total, err := h.cache.GetLeaderboardTotal(query)
+		if err == nil {
+			return pageEntries(entries, limit, offset), total, nil
+		}
+	}
+
+	// Cache miss, load the whole window from the database
+	entries, err = h.db.GetLeaderboard(query, leaderboardCacheWindow, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := h.db.GetLeaderboardCount(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Cache the result, errors are not fatal
+	cacheTTL := 30 * time.Second // 30 seconds cache
+	if err := h.cache.SetLeaderboard(query, entries, cacheTTL); err != nil {
+		log.Printf("Failed to cache leaderboard: %v", err)
+	}
+	if err := h.cache.SetLeaderboardTotal(query, total, cacheTTL); err != nil {
+		log.Printf("Failed to cache leaderboard total: %v", err)
+	}
+
+	return pageEntries(entries, limit, offset), total, nil
+}
+
+// loadLeaderboardTotal returns the number of ranked users, using the cache when possible
+func (h *LeaderboardHandler) loadLeaderboardTotal(query models.LeaderboardQuery) (int, error) {
+	if h.cache != nil && !query.IsFiltered() {
+		if total, err := h.cache.GetLeaderboardTotal(query); err == nil {
+			return total, nil
+		}
+	}
+
+	return h.db.GetLeaderboardCount(query)
+}
+
+// pageEntries slices a page out of the cached leaderboard window
+func pageEntries(entries []models.LeaderboardEntry, limit, offset int) []models.LeaderboardEntry {
+	if offset >= len(entries) {
+		return []models.LeaderboardEntry{}
+	}
+
+	end := offset + limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	return entries[offset:end]
+}
+
+// parseLeaderboardType validates a leaderboard type query value
+func parseLeaderboardType(value string) (models.LeaderboardType, bool) {
+	switch value {
+	case "daily":
+		return models.LeaderboardDaily, true
+	case "weekly":
+		return models.LeaderboardWeekly, true
+	case "monthly":
+		return models.LeaderboardMonthly, true
+	case "all":
+		return models.LeaderboardAll, true
+	case "season":
+		return models.LeaderboardSeason, true
+	default:
+		return "", false
+	}
+}
+
+// RefreshCache manually refreshes the leaderboard cache (requires leaderboards:manage)
+func (h *LeaderboardHandler) RefreshCache(c *gin.Context) {
 	// Check if cache is available
 	if h.cache == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
@@ -139,9 +543,9 @@ func (h *LeaderboardHandler) RefreshCache(c *gin.Context) {
 		lbType := models.LeaderboardType(typeParam)
 
 		// Validate leaderboard type
-		if lbType != models.LeaderboardDaily && lbType != models.LeaderboardWeekly && lbType != models.LeaderboardMonthly && lbType != models.LeaderboardAll {
+		if _, ok := parseLeaderboardType(typeParam); !ok {
 			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid leaderboard type. Must be 'daily', 'weekly', 'monthly', or 'all'",
+				"error": "Invalid leaderboard type. Must be 'daily', 'weekly', 'monthly', 'all', or 'season'",
 			})
 			return
 		}
@@ -159,6 +563,7 @@ func (h *LeaderboardHandler) RefreshCache(c *gin.Context) {
 			models.LeaderboardWeekly,
 			models.LeaderboardMonthly,
 			models.LeaderboardAll,
+			models.LeaderboardSeason,
 		}
 
 		for _, lbType := range allTypes {
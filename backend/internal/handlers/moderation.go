@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"game2048/internal/auth"
+	"game2048/internal/database"
+	"game2048/internal/service"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ModerationHandler handles moderation requests, all of them require the users:moderate permission
+type ModerationHandler struct {
+	authService *auth.AuthService
+	db          database.Database
+	sessions    SessionCloser
+	games       *service.GameService // Drops cached leaderboards when users are hidden or shown again
+}
+
+// NewModerationHandler creates a new moderation handler
+func NewModerationHandler(authService *auth.AuthService, db database.Database, sessions SessionCloser, games *service.GameService) *ModerationHandler {
+	return &ModerationHandler{
+		authService: authService,
+		db:          db,
+		sessions:    sessions,
+		games:       games,
+	}
+}
+
+// ListModeratedUsers lists the users that are currently suspended, banned or shadow-banned
+func (h *ModerationHandler) ListModeratedUsers(c *gin.Context) {
+	users, err := h.db.GetModeratedUsers()
+	if err != nil {
+		log.Printf("Failed to list moderated users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list moderated users",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+	})
+}
+
+// GetUserModeration returns the moderation status of a user with its audit trail
+func (h *ModerationHandler) GetUserModeration(c *gin.Context) {
+	user, err := h.db.GetUser(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	h.writeModeration(c, user)
+}
+
+// ModerateUser sets the moderation status of a user, recording the moderator and reason in the audit trail.
+// Suspending or banning a user also ends their sessions, restoring them lets their API tokens work again.
+func (h *ModerationHandler) ModerateUser(c *gin.Context) {
+	userID := c.Param("id")
+	moderatorID := c.GetString("user_id")
+
+	var req models.ModerateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	if !req.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Unknown moderation status: " + string(req.Status),
+		})
+		return
+	}
+
+	// Only suspensions have an end
+	if req.Status != models.ModerationSuspended {
+		req.SuspendedUntil = nil
+	} else if req.SuspendedUntil == nil || !req.SuspendedUntil.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Suspensions need a suspended_until in the future",
+		})
+		return
+	}
+
+	if userID == moderatorID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Moderators cannot moderate themselves",
+		})
+		return
+	}
+
+	user, err := h.db.GetUser(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+	if user.IsGuest {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Guests cannot be moderated",
+		})
+		return
+	}
+
+	action := &models.ModerationAction{
+		UserID:         userID,
+		ModeratorID:    moderatorID,
+		Status:         req.Status,
+		SuspendedUntil: req.SuspendedUntil,
+		Reason:         req.Reason,
+	}
+	if err := h.db.ModerateUser(action); err != nil {
+		log.Printf("Failed to moderate user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to moderate user",
+		})
+		return
+	}
+	log.Printf("User %s set moderation status of user %s to %s: %s", moderatorID, userID, req.Status, req.Reason)
+
+	// Requests are refused from now on, end the sessions and connections opened before
+	if req.Status == models.ModerationSuspended || req.Status == models.ModerationBanned {
+		if err := h.authService.RevokeAllJWTs(userID); err != nil {
+			log.Printf("Failed to revoke tokens of moderated user %s: %v", userID, err)
+		}
+		h.sessions.DisconnectUser(userID)
+	}
+
+	// The user may have been hidden from or shown again on the leaderboards
+	h.games.InvalidateLeaderboards(c.Request.Context(), models.AllLeaderboardTypes()...)
+
+	user.ModerationStatus = action.Status
+	user.SuspendedUntil = action.SuspendedUntil
+	h.writeModeration(c, user)
+}
+
+// writeModeration writes the moderation status of a user with its audit trail
+func (h *ModerationHandler) writeModeration(c *gin.Context, user *models.User) {
+	actions, err := h.db.GetModerationActions(user.ID)
+	if err != nil {
+		log.Printf("Failed to get moderation actions of user %s: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get moderation actions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserModeration{
+		UserID:         user.ID,
+		UserName:       user.Name,
+		Status:         user.Moderation(time.Now()),
+		SuspendedUntil: user.SuspendedUntil,
+		Actions:        actions,
+	})
+}
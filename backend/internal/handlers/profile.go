@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"game2048/internal/database"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// profileRecentGames is the number of recent finished games shown on a profile
+const profileRecentGames = 10
+
+var errProfileNotFound = errors.New("profile not found")
+
+// ProfileHandler handles public player profile requests
+type ProfileHandler struct {
+	db       database.Database
+	calendar models.PeriodCalendar
+}
+
+// NewProfileHandler creates a new profile handler
+func NewProfileHandler(db database.Database, calendar models.PeriodCalendar) *ProfileHandler {
+	return &ProfileHandler{
+		db:       db,
+		calendar: calendar,
+	}
+}
+
+// GetProfile returns a player's public profile as JSON
+func (h *ProfileHandler) GetProfile(c *gin.Context) {
+	profile, err := h.loadProfile(c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		if err == errProfileNotFound {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Player not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load player profile",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// ProfilePage renders a player's public profile page
+func (h *ProfileHandler) ProfilePage(c *gin.Context) {
+	profile, err := h.loadProfile(c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		if err == errProfileNotFound {
+			c.HTML(http.StatusNotFound, "error.html", gin.H{
+				"error": "Player not found",
+			})
+			return
+		}
+		c.HTML(http.StatusInternalServerError, "error.html", gin.H{
+			"error": "Failed to load player profile",
+		})
+		return
+	}
+
+	c.HTML(http.StatusOK, "profile.html", gin.H{
+		"title":   profile.Name + " - 2048 Game",
+		"profile": profile,
+	})
+}
+
+// UpdateMySettings updates the authenticated user's settings, such as hiding their profile
+func (h *ProfileHandler) UpdateMySettings(c *gin.Context) {
+	var settings models.UserSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid settings format",
+		})
+		return
+	}
+
+	if err := h.db.UpdateUserSettings(c.GetString("user_id"), settings); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": settings,
+	})
+}
+
+// loadProfile builds the public profile of a user.
+// Hidden profiles are reported as not found unless the viewer is their owner, guests and banned users have no profile.
+func (h *ProfileHandler) loadProfile(userID, viewerID string) (*models.PlayerProfile, error) {
+	user, err := h.db.GetUser(userID)
+	if err != nil {
+		return nil, errProfileNotFound
+	}
+
+	if user.IsGuest || user.ModerationStatus == models.ModerationBanned || (user.ProfileHidden && user.ID != viewerID) {
+		return nil, errProfileNotFound
+	}
+
+	profile := &models.PlayerProfile{
+		UserID:     user.ID,
+		Name:       user.Name,
+		Avatar:     user.Avatar,
+		BestScores: []models.ProfileBestScore{},
+		Hidden:     user.ProfileHidden,
+	}
+
+	// Best scores on the current leaderboards, including the running season
+	now := time.Now()
+	queries := []models.LeaderboardQuery{
+		h.calendar.Query(models.LeaderboardDaily, now),
+		h.calendar.Query(models.LeaderboardWeekly, now),
+		h.calendar.Query(models.LeaderboardMonthly, now),
+		h.calendar.Query(models.LeaderboardAll, now),
+	}
+	season, err := h.db.GetActiveSeason(now)
+	if err != nil {
+		log.Printf("Failed to get active season: %v", err)
+	} else if season != nil {
+		queries = append(queries, season.Query())
+	}
+
+	for _, query := range queries {
+		// Shadow-banned players see their own ranks, everyone else sees them unranked
+		if user.ID == viewerID {
+			query = query.ViewedBy(user)
+		}
+		entry, err := h.db.GetUserRank(query, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+		profile.BestScores = append(profile.BestScores, models.ProfileBestScore{
+			Type:   query.Type,
+			Score:  entry.Score,
+			Rank:   entry.Rank,
+			Period: query.Period,
+		})
+	}
+
+	finished := models.GameHistoryFilter{Status: models.GameStatusFinished}
+
+	profile.RecentGames, err = h.db.GetUserGames(user.ID, finished, profileRecentGames, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := h.calendar.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	stats, err := h.db.GetUserGameStats(user.ID, finished, loc)
+	if err != nil {
+		return nil, err
+	}
+	// Best tiles are ordered from the highest down
+	if len(stats.BestTiles) > 0 {
+		profile.HighestTile = stats.BestTiles[0].Tile
+	}
+
+	// Season badges of hidden players are not shown to others, like their ranks
+	profile.Badges = []models.UserBadge{}
+	if !user.Hidden() || user.ID == viewerID {
+		profile.Badges, err = h.db.GetUserBadges(user.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+
+	"game2048/internal/database"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roleNamePattern restricts role names to lowercase identifiers
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+
+// RoleHandler handles role management requests, all of them require the roles:manage permission
+type RoleHandler struct {
+	db database.Database
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(db database.Database) *RoleHandler {
+	return &RoleHandler{
+		db: db,
+	}
+}
+
+// ListRoles returns every role with its permissions, and the permissions a role can have
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.db.ListRoles()
+	if err != nil {
+		log.Printf("Failed to list roles: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list roles",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       roles,
+		"permissions": models.AllPermissions(),
+	})
+}
+
+// SaveRole creates a role or replaces the description and permissions of an existing one
+func (h *RoleHandler) SaveRole(c *gin.Context) {
+	name := c.Param("name")
+	if !roleNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Role names are lowercase letters, digits, dashes and underscores",
+		})
+		return
+	}
+
+	var req models.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	for _, permission := range req.Permissions {
+		if !permission.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "Unknown permission: " + string(permission),
+			})
+			return
+		}
+	}
+
+	// The built-in admin role always keeps every permission, so an admin cannot lock everyone out
+	if name == models.RoleAdmin && !models.HasPermission(req.Permissions, models.PermissionAll) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The admin role must keep the * permission",
+		})
+		return
+	}
+
+	role := &models.Role{
+		Name:        name,
+		Description: req.Description,
+		Permissions: req.Permissions,
+	}
+	if err := h.db.SaveRole(role); err != nil {
+		log.Printf("Failed to save role %s: %v", name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to save role",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, role)
+}
+
+// DeleteRole deletes a role and takes it away from every user, the built-in admin role cannot be deleted
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	name := c.Param("name")
+	if name == models.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "The admin role cannot be deleted",
+		})
+		return
+	}
+
+	if err := h.db.DeleteRole(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Role not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role deleted",
+	})
+}
+
+// GetUserRoles returns the roles granted to a user and the permissions they add up to
+func (h *RoleHandler) GetUserRoles(c *gin.Context) {
+	userID := c.Param("id")
+	if _, err := h.db.GetUser(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	h.writeUserRoles(c, userID)
+}
+
+// GrantRole grants a role to a user
+func (h *RoleHandler) GrantRole(c *gin.Context) {
+	userID := c.Param("id")
+	role := c.Param("role")
+
+	user, err := h.db.GetUser(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+	if user.IsGuest {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Guests cannot be granted roles",
+		})
+		return
+	}
+
+	if _, err := h.db.GetRole(role); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Role not found",
+		})
+		return
+	}
+
+	if err := h.db.GrantRole(userID, role, c.GetString("user_id")); err != nil {
+		log.Printf("Failed to grant role %s to user %s: %v", role, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to grant role",
+		})
+		return
+	}
+
+	log.Printf("User %s granted role %s to user %s", c.GetString("user_id"), role, userID)
+	h.writeUserRoles(c, userID)
+}
+
+// RevokeRole takes a role away from a user
+func (h *RoleHandler) RevokeRole(c *gin.Context) {
+	userID := c.Param("id")
+	role := c.Param("role")
+
+	if err := h.db.RevokeRole(userID, role); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Role not granted",
+		})
+		return
+	}
+
+	log.Printf("User %s revoked role %s of user %s", c.GetString("user_id"), role, userID)
+	h.writeUserRoles(c, userID)
+}
+
+// writeUserRoles writes the roles and permissions of a user
+func (h *RoleHandler) writeUserRoles(c *gin.Context, userID string) {
+	roles, err := h.db.GetUserRoles(userID)
+	if err != nil {
+		log.Printf("Failed to get roles of user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user roles",
+		})
+		return
+	}
+
+	permissions, err := h.db.GetUserPermissions(userID)
+	if err != nil {
+		log.Printf("Failed to get permissions of user %s: %v", userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get user permissions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       roles,
+		"permissions": permissions,
+	})
+}
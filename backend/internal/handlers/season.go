@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"game2048/internal/cache"
+	"game2048/internal/database"
+	"game2048/internal/events"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SeasonHandler handles season-related requests
+type SeasonHandler struct {
+	db         database.Database
+	cache      cache.Cache
+	bus        *events.Bus
+	maxEntries int
+}
+
+// NewSeasonHandler creates a new season handler
+func NewSeasonHandler(db database.Database, redisCache cache.Cache, bus *events.Bus, maxEntries int) *SeasonHandler {
+	return &SeasonHandler{
+		db:         db,
+		cache:      redisCache,
+		bus:        bus,
+		maxEntries: maxEntries,
+	}
+}
+
+// ListSeasons returns all seasons, most recent first
+func (h *SeasonHandler) ListSeasons(c *gin.Context) {
+	seasons, err := h.db.ListSeasons()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list seasons",
+		})
+		return
+	}
+
+	active, err := h.db.GetActiveSeason(time.Now())
+	if err != nil {
+		log.Printf("Failed to get active season: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"seasons": seasons,
+		"active":  active,
+	})
+}
+
+// CreateSeason creates a new season (requires seasons:manage)
+func (h *SeasonHandler) CreateSeason(c *gin.Context) {
+	var req models.SeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid season request format",
+		})
+		return
+	}
+
+	if msg := validateSeasonRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	season := &models.Season{
+		Name:       strings.TrimSpace(req.Name),
+		StartAt:    req.StartAt,
+		EndAt:      req.EndAt,
+		RewardTopN: req.RewardTopN,
+	}
+
+	if err := h.db.CreateSeason(season); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create season",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"season": season,
+	})
+}
+
+// UpdateSeason updates the name, dates and rewards of a season that has not closed yet (requires seasons:manage)
+func (h *SeasonHandler) UpdateSeason(c *gin.Context) {
+	season, err := h.db.GetSeason(c.Param("id"))
+	if errors.Is(err, database.ErrSeasonNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Season not found",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get season %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load season",
+		})
+		return
+	}
+
+	if season.IsClosed() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Season is already closed",
+		})
+		return
+	}
+
+	var req models.SeasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid season request format",
+		})
+		return
+	}
+
+	if msg := validateSeasonRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	season.Name = strings.TrimSpace(req.Name)
+	season.StartAt = req.StartAt
+	season.EndAt = req.EndAt
+	season.RewardTopN = req.RewardTopN
+
+	if err := h.db.UpdateSeason(season); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update season",
+		})
+		return
+	}
+
+	h.invalidateSeasonCache()
+
+	c.JSON(http.StatusOK, gin.H{
+		"season": season,
+	})
+}
+
+// CloseSeason archives the final standings of an ended season and awards its badges (requires seasons:manage).
+// Seasons are also closed automatically by the season job shortly after they end.
+func (h *SeasonHandler) CloseSeason(c *gin.Context) {
+	season, err := h.db.GetSeason(c.Param("id"))
+	if errors.Is(err, database.ErrSeasonNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Season not found",
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get season %s: %v", c.Param("id"), err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load season",
+		})
+		return
+	}
+
+	if time.Now().Before(season.EndAt) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Season has not ended yet",
+		})
+		return
+	}
+
+	if season.IsClosed() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Season is already closed",
+		})
+		return
+	}
+
+	closed, err := h.db.CloseSeason(season.ID.String(), h.maxEntries)
+	if err != nil {
+		log.Printf("Failed to close season %s: %v", season.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to close season",
+		})
+		return
+	}
+	if !closed {
+		// The season job closed it in the meantime and announced it
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Season is already closed",
+		})
+		return
+	}
+
+	h.invalidateSeasonCache()
+
+	season, err = h.db.GetSeason(season.ID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to load season",
+		})
+		return
+	}
+
+	h.bus.Publish(events.SeasonClosed{Season: *season, At: time.Now()})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Season closed",
+		"season":  season,
+	})
+}
+
+// invalidateSeasonCache drops cached season leaderboards after a season changes
+func (h *SeasonHandler) invalidateSeasonCache() {
+	if h.cache == nil {
+		return
+	}
+	if err := h.cache.InvalidateLeaderboard(models.LeaderboardSeason); err != nil {
+		log.Printf("Failed to invalidate season leaderboard cache: %v", err)
+	}
+}
+
+// validateSeasonRequest returns an error message for an invalid season request, or an empty string
+func validateSeasonRequest(req *models.SeasonRequest) string {
+	if strings.TrimSpace(req.Name) == "" {
+		return "Season name is required"
+	}
+	if req.StartAt.IsZero() || req.EndAt.IsZero() {
+		return "Season start_at and end_at are required"
+	}
+	if !req.EndAt.After(req.StartAt) {
+		return "Season end_at must be after start_at"
+	}
+	if req.RewardTopN < 0 {
+		return "Season reward_top_n must not be negative"
+	}
+	return ""
+}
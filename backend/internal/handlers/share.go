@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"game2048/internal/cache"
+	"game2048/internal/database"
+	"game2048/internal/render"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// shareCardMaxAge is how long share cards may be cached
+const shareCardMaxAge = time.Hour
+
+// ShareHandler handles share cards and share pages of finished games
+type ShareHandler struct {
+	db       database.Database
+	cache    cache.Cache
+	nameFont *render.NameFont
+}
+
+// NewShareHandler creates a new share handler, the cache and the name font are optional
+func NewShareHandler(db database.Database, redisCache cache.Cache, nameFont *render.NameFont) *ShareHandler {
+	return &ShareHandler{
+		db:       db,
+		cache:    redisCache,
+		nameFont: nameFont,
+	}
+}
+
+// Card renders a finished game as a PNG share card
+func (h *ShareHandler) Card(c *gin.Context) {
+	game, user, ok := h.loadSharedGame(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Game not found",
+		})
+		return
+	}
+
+	card, err := h.loadCard(game, user)
+	if err != nil {
+		log.Printf("Failed to render share card: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to render share card",
+		})
+		return
+	}
+
+	sum := sha256.Sum256(card)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	// Finished games never change, only the player name might
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(shareCardMaxAge.Seconds())))
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", card)
+}
+
+// loadCard returns the encoded share card of a game, using the cache when available
+func (h *ShareHandler) loadCard(game *models.GameState, user *models.User) ([]byte, error) {
+	gameID := game.ID.String()
+	if h.cache != nil {
+		if card, err := h.cache.GetShareCard(gameID, user.Name); err == nil {
+			return card, nil
+		}
+	}
+
+	var buf bytes.Buffer
+	err := render.EncodeCard(&buf, render.Card{
+		Board:      game.Board,
+		Score:      game.Score,
+		PlayerName: user.Name,
+		Victory:    game.Victory,
+		NameFont:   h.nameFont,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if h.cache != nil {
+		if err := h.cache.SetShareCard(gameID, user.Name, buf.Bytes(), shareCardMaxAge); err != nil {
+			log.Printf("Failed to cache share card: %v", err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SharePage renders a page for a finished game with Open Graph tags pointing at its share card
+func (h *ShareHandler) SharePage(c *gin.Context) {
+	game, user, ok := h.loadSharedGame(c.Param("id"))
+	if !ok {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Game not found",
+		})
+		return
+	}
+
+	baseURL := requestBaseURL(c)
+	title := fmt.Sprintf("%s scored %d in 2048", user.Name, game.Score)
+
+	c.HTML(http.StatusOK, "share.html", gin.H{
+		"title":       title,
+		"description": game.FinishMessage(),
+		"imageURL":    fmt.Sprintf("%s/api/public/games/%s/card.png", baseURL, game.ID),
+		"pageURL":     fmt.Sprintf("%s/share/%s", baseURL, game.ID),
+		"imageWidth":  render.CardWidth,
+		"imageHeight": render.CardHeight,
+		"game":        game,
+		"user":        user,
+	})
+}
+
+// loadSharedGame returns a finished game and its player.
+// Games of players with a hidden profile are not shared.
+func (h *ShareHandler) loadSharedGame(id string) (*models.GameState, *models.User, bool) {
+	gameID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	game, err := h.db.GetFinishedGame(gameID.String())
+	if err != nil {
+		return nil, nil, false
+	}
+
+	user, err := h.db.GetUser(game.UserID)
+	if err != nil || user.ProfileHidden {
+		return nil, nil, false
+	}
+
+	return game, user, true
+}
+
+// requestBaseURL returns the scheme and host the request was made to, honoring reverse proxies
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"game2048/internal/database"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SocialHandler handles follow and group requests
+type SocialHandler struct {
+	db database.Database
+}
+
+// NewSocialHandler creates a new social handler
+func NewSocialHandler(db database.Database) *SocialHandler {
+	return &SocialHandler{
+		db: db,
+	}
+}
+
+// GetFriends returns the users the authenticated user follows and the users following them
+func (h *SocialHandler) GetFriends(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	following, err := h.db.GetFollowing(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get friends",
+		})
+		return
+	}
+
+	followers, err := h.db.GetFollowers(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get followers",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"following": following,
+		"followers": followers,
+	})
+}
+
+// Follow makes the authenticated user follow another user
+func (h *SocialHandler) Follow(c *gin.Context) {
+	userID := c.GetString("user_id")
+	followeeID := c.Param("id")
+
+	if followeeID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "You cannot follow yourself",
+		})
+		return
+	}
+
+	if _, err := h.db.GetUser(followeeID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "User not found",
+		})
+		return
+	}
+
+	if err := h.db.FollowUser(userID, followeeID); err != nil {
+		log.Printf("Failed to follow user %s: %v", followeeID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to follow user",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User followed",
+	})
+}
+
+// Unfollow makes the authenticated user stop following another user
+func (h *SocialHandler) Unfollow(c *gin.Context) {
+	if err := h.db.UnfollowUser(c.GetString("user_id"), c.Param("id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to unfollow user",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unfollowed",
+	})
+}
+
+// ListGroups returns the groups the authenticated user is a member of
+func (h *SocialHandler) ListGroups(c *gin.Context) {
+	groups, err := h.db.GetUserGroups(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list groups",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"groups": groups,
+	})
+}
+
+// CreateGroup creates a group owned by the authenticated user
+func (h *SocialHandler) CreateGroup(c *gin.Context) {
+	var req models.GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Group name is required",
+		})
+		return
+	}
+
+	inviteCode, err := generateInviteCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate invite code",
+		})
+		return
+	}
+
+	group := &models.Group{
+		Name:       strings.TrimSpace(req.Name),
+		OwnerID:    c.GetString("user_id"),
+		InviteCode: inviteCode,
+	}
+
+	if err := h.db.CreateGroup(group); err != nil {
+		log.Printf("Failed to create group: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create group",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"group": group,
+	})
+}
+
+// JoinGroup adds the authenticated user to the group with the given invite code
+func (h *SocialHandler) JoinGroup(c *gin.Context) {
+	var req models.JoinGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.InviteCode) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invite code is required",
+		})
+		return
+	}
+
+	group, err := h.db.GetGroupByInviteCode(strings.ToUpper(strings.TrimSpace(req.InviteCode)))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Invalid invite code",
+		})
+		return
+	}
+
+	if err := h.db.AddGroupMember(group.ID.String(), c.GetString("user_id")); err != nil {
+		log.Printf("Failed to join group %s: %v", group.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to join group",
+		})
+		return
+	}
+
+	// Reload for the updated member count
+	if updated, err := h.db.GetGroup(group.ID.String()); err == nil {
+		group = updated
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group": group,
+	})
+}
+
+// GetGroup returns a group and a page of its members, visible to members only
+func (h *SocialHandler) GetGroup(c *gin.Context) {
+	group, ok := h.loadMemberGroup(c)
+	if !ok {
+		return
+	}
+
+	limitStr := c.DefaultQuery("limit", "100")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 100
+	}
+
+	offsetStr := c.DefaultQuery("offset", "0")
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	members, err := h.db.GetGroupMembers(group.ID.String(), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get group members",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group":   group,
+		"members": members,
+	})
+}
+
+// UpdateGroup renames a group (owner only)
+func (h *SocialHandler) UpdateGroup(c *gin.Context) {
+	group, ok := h.loadOwnedGroup(c)
+	if !ok {
+		return
+	}
+
+	var req models.GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Group name is required",
+		})
+		return
+	}
+
+	group.Name = strings.TrimSpace(req.Name)
+	if err := h.db.UpdateGroup(group); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update group",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group": group,
+	})
+}
+
+// RegenerateInviteCode replaces a group's invite code, invalidating the old one (owner only)
+func (h *SocialHandler) RegenerateInviteCode(c *gin.Context) {
+	group, ok := h.loadOwnedGroup(c)
+	if !ok {
+		return
+	}
+
+	inviteCode, err := generateInviteCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate invite code",
+		})
+		return
+	}
+
+	group.InviteCode = inviteCode
+	if err := h.db.UpdateGroup(group); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update group",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group": group,
+	})
+}
+
+// DeleteGroup deletes a group (owner only)
+func (h *SocialHandler) DeleteGroup(c *gin.Context) {
+	group, ok := h.loadOwnedGroup(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.DeleteGroup(group.ID.String()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete group",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Group deleted",
+	})
+}
+
+// LeaveGroup removes the authenticated user from a group.
+// The owner cannot leave and has to delete the group instead.
+func (h *SocialHandler) LeaveGroup(c *gin.Context) {
+	group, ok := h.loadMemberGroup(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if group.OwnerID == userID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The group owner cannot leave the group",
+		})
+		return
+	}
+
+	if err := h.db.RemoveGroupMember(group.ID.String(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to leave group",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Left group",
+	})
+}
+
+// RemoveGroupMember removes another member from a group (owner only)
+func (h *SocialHandler) RemoveGroupMember(c *gin.Context) {
+	group, ok := h.loadOwnedGroup(c)
+	if !ok {
+		return
+	}
+
+	memberID := c.Param("userID")
+	if memberID == group.OwnerID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "The group owner cannot be removed",
+		})
+		return
+	}
+
+	if err := h.db.RemoveGroupMember(group.ID.String(), memberID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to remove group member",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member removed",
+	})
+}
+
+// loadMemberGroup loads the group in the URL and checks that the authenticated user is a member.
+// It writes an error response and returns false otherwise.
+func (h *SocialHandler) loadMemberGroup(c *gin.Context) (*models.Group, bool) {
+	group, err := h.db.GetGroup(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Group not found",
+		})
+		return nil, false
+	}
+
+	member, err := h.db.IsGroupMember(group.ID.String(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to check group membership",
+		})
+		return nil, false
+	}
+
+	if !member {
+		// Do not reveal groups to non-members
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Group not found",
+		})
+		return nil, false
+	}
+
+	return group, true
+}
+
+// loadOwnedGroup loads the group in the URL and checks that the authenticated user owns it.
+// It writes an error response and returns false otherwise.
+func (h *SocialHandler) loadOwnedGroup(c *gin.Context) (*models.Group, bool) {
+	group, ok := h.loadMemberGroup(c)
+	if !ok {
+		return nil, false
+	}
+
+	if group.OwnerID != c.GetString("user_id") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Only the group owner can do this",
+		})
+		return nil, false
+	}
+
+	return group, true
+}
+
+// generateInviteCode generates a random, easy to type group invite code
+func generateInviteCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	// 5 bytes encode to exactly 8 base32 characters without padding
+	return base32.StdEncoding.EncodeToString(b), nil
+}
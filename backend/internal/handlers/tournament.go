@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"game2048/internal/database"
+	"game2048/internal/tournaments"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TournamentHandler handles tournament requests
+type TournamentHandler struct {
+	tournaments *tournaments.Service
+	db          database.Database
+}
+
+// NewTournamentHandler creates a new tournament handler
+func NewTournamentHandler(service *tournaments.Service, db database.Database) *TournamentHandler {
+	return &TournamentHandler{
+		tournaments: service,
+		db:          db,
+	}
+}
+
+// ListTournaments returns all tournaments, most recent first
+func (h *TournamentHandler) ListTournaments(c *gin.Context) {
+	tournamentList, err := h.db.ListTournaments()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list tournaments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tournaments": tournamentList,
+	})
+}
+
+// GetTournament returns a tournament with its participants, ordered by final rank once it finished
+func (h *TournamentHandler) GetTournament(c *gin.Context) {
+	tournament, participants, ok := h.loadTournament(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tournament not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tournament":   tournament,
+		"participants": participants,
+	})
+}
+
+// GetRoundStandings returns a page of a round's standings, ranked by each player's best finished attempt
+func (h *TournamentHandler) GetRoundStandings(c *gin.Context) {
+	tournament, err := h.db.GetTournament(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Tournament not found",
+		})
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("round"))
+	round := tournament.Round(number)
+	if err != nil || round == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Round not found",
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	entries, total, err := h.tournaments.Standings(round, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get round standings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"round":   round,
+		"entries": entries,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// Register registers the authenticated user for a tournament
+func (h *TournamentHandler) Register(c *gin.Context) {
+	if err := h.tournaments.Register(c.Param("id"), c.GetString("user_id")); err != nil {
+		respondTournamentError(c, err, "Failed to register for tournament")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Registered for tournament",
+	})
+}
+
+// Unregister withdraws the authenticated user's registration for a tournament
+func (h *TournamentHandler) Unregister(c *gin.Context) {
+	if err := h.tournaments.Unregister(c.Param("id"), c.GetString("user_id")); err != nil {
+		respondTournamentError(c, err, "Failed to unregister from tournament")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Unregistered from tournament",
+	})
+}
+
+// Play starts an attempt in the round being played, which becomes the authenticated user's current game
+func (h *TournamentHandler) Play(c *gin.Context) {
+	gameState, err := h.tournaments.Play(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		respondTournamentError(c, err, "Failed to start tournament attempt")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"game":    gameState,
+		"message": "Tournament attempt started!",
+	})
+}
+
+// GetMyStatus returns the authenticated user's registration, attempts and round rank in a tournament
+func (h *TournamentHandler) GetMyStatus(c *gin.Context) {
+	status, err := h.tournaments.Status(c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		respondTournamentError(c, err, "Failed to get tournament status")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// CreateTournament creates a tournament (requires tournaments:manage)
+func (h *TournamentHandler) CreateTournament(c *gin.Context) {
+	var req models.TournamentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid tournament request format",
+		})
+		return
+	}
+
+	if msg := validateTournamentRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	tournament, err := h.tournaments.Create(&req)
+	if err != nil {
+		log.Printf("Failed to create tournament: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create tournament",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"tournament": tournament,
+	})
+}
+
+// DeleteTournament deletes a tournament that has not started yet (requires tournaments:manage)
+func (h *TournamentHandler) DeleteTournament(c *gin.Context) {
+	if err := h.tournaments.Delete(c.Param("id")); err != nil {
+		respondTournamentError(c, err, "Failed to delete tournament")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tournament deleted",
+	})
+}
+
+// TournamentPage renders the rounds and results of a tournament
+func (h *TournamentHandler) TournamentPage(c *gin.Context) {
+	tournament, participants, ok := h.loadTournament(c.Param("id"))
+	if !ok {
+		c.HTML(http.StatusNotFound, "error.html", gin.H{
+			"error": "Tournament not found",
+		})
+		return
+	}
+
+	// Standings of every round that has started, the last one shown first
+	type roundStandings struct {
+		Round   models.TournamentRound
+		Entries []models.LeaderboardEntry
+	}
+	var rounds []roundStandings
+	for i := len(tournament.Rounds) - 1; i >= 0; i-- {
+		round := tournament.Rounds[i]
+		if round.Status == models.TournamentRoundScheduled {
+			continue
+		}
+
+		entries, _, err := h.tournaments.Standings(&round, 50, 0)
+		if err != nil {
+			log.Printf("Failed to get standings of round %d of tournament %s: %v", round.Number, tournament.ID, err)
+			continue
+		}
+		rounds = append(rounds, roundStandings{Round: round, Entries: entries})
+	}
+
+	c.HTML(http.StatusOK, "tournament.html", gin.H{
+		"title":        "2048 Game - " + tournament.Name,
+		"tournament":   tournament,
+		"participants": participants,
+		"rounds":       rounds,
+	})
+}
+
+// loadTournament loads a tournament with its participants
+func (h *TournamentHandler) loadTournament(tournamentID string) (*models.Tournament, []models.TournamentParticipant, bool) {
+	tournament, err := h.db.GetTournament(tournamentID)
+	if err != nil {
+		return nil, nil, false
+	}
+
+	participants, err := h.db.GetTournamentParticipants(tournament.ID.String())
+	if err != nil {
+		log.Printf("Failed to get participants of tournament %s: %v", tournament.ID, err)
+		return nil, nil, false
+	}
+
+	return tournament, participants, true
+}
+
+// respondTournamentError maps tournament service errors to responses
+func respondTournamentError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+
+	switch err {
+	case tournaments.ErrTournamentNotFound:
+		status, message = http.StatusNotFound, "Tournament not found"
+	case tournaments.ErrNotRegistered:
+		status, message = http.StatusForbidden, "Not registered for this tournament"
+	case tournaments.ErrEliminated:
+		status, message = http.StatusForbidden, "Eliminated from this tournament"
+	case tournaments.ErrRegistrationClosed:
+		status, message = http.StatusConflict, "Registration is closed"
+	case tournaments.ErrNoActiveRound:
+		status, message = http.StatusConflict, "No round is being played"
+	case tournaments.ErrNoAttemptsLeft:
+		status, message = http.StatusConflict, "No attempts left in this round"
+	case tournaments.ErrAlreadyStarted:
+		status, message = http.StatusConflict, "Tournament has already started"
+	default:
+		log.Printf("%s: %v", fallback, err)
+	}
+
+	c.JSON(status, gin.H{
+		"error": message,
+	})
+}
+
+// validateTournamentRequest checks a tournament request and returns an error message, or "" when it is valid
+func validateTournamentRequest(req *models.TournamentRequest) string {
+	if strings.TrimSpace(req.Name) == "" {
+		return "Tournament name is required"
+	}
+	if req.RegistrationStart.IsZero() || req.RegistrationEnd.IsZero() {
+		return "Tournament registration_start and registration_end are required"
+	}
+	if !req.RegistrationEnd.After(req.RegistrationStart) {
+		return "Tournament registration_end must be after registration_start"
+	}
+	if req.MaxAttempts < 1 {
+		return "Tournament max_attempts must be at least 1"
+	}
+	if len(req.Rounds) == 0 {
+		return "Tournament needs at least one round"
+	}
+
+	previousEnd := req.RegistrationEnd
+	for i, round := range req.Rounds {
+		if round.StartAt.IsZero() || round.EndAt.IsZero() {
+			return "Round start_at and end_at are required"
+		}
+		if round.StartAt.Before(previousEnd) {
+			return "Rounds must start after registration ends and after the previous round"
+		}
+		if !round.EndAt.After(round.StartAt) {
+			return "Round end_at must be after start_at"
+		}
+
+		last := i == len(req.Rounds)-1
+		if last && round.Advance != 0 {
+			return "The final round must not advance players"
+		}
+		if !last && round.Advance < 1 {
+			return "Rounds before the final round must advance at least 1 player"
+		}
+		previousEnd = round.EndAt
+	}
+
+	return ""
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"game2048/internal/database"
+	"game2048/internal/webhooks"
+	"game2048/pkg/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles webhook administration requests
+type WebhookHandler struct {
+	db database.Database
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(db database.Database) *WebhookHandler {
+	return &WebhookHandler{
+		db: db,
+	}
+}
+
+// ListWebhooks returns all webhooks without their secrets (requires webhooks:manage)
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	list, err := h.db.ListWebhooks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to list webhooks",
+		})
+		return
+	}
+
+	for i := range list {
+		list[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": list,
+		"events":   models.AllWebhookEvents(),
+	})
+}
+
+// CreateWebhook registers a webhook and returns its signing secret, which is not shown again (requires webhooks:manage)
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook request format",
+		})
+		return
+	}
+
+	if msg := validateWebhook(req.URL, req.Events); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to generate webhook secret",
+		})
+		return
+	}
+
+	webhook := &models.Webhook{
+		URL:    req.URL,
+		Secret: secret,
+		Events: req.Events,
+		Active: true,
+	}
+
+	if err := h.db.CreateWebhook(webhook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to create webhook",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"webhook": webhook,
+	})
+}
+
+// UpdateWebhook changes the URL, events or active flag of a webhook (requires webhooks:manage)
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	webhook, err := h.db.GetWebhook(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Webhook not found",
+		})
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid webhook request format",
+		})
+		return
+	}
+
+	if req.URL != nil {
+		webhook.URL = *req.URL
+	}
+	if req.Events != nil {
+		webhook.Events = req.Events
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if msg := validateWebhook(webhook.URL, webhook.Events); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": msg,
+		})
+		return
+	}
+
+	if err := h.db.UpdateWebhook(webhook); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to update webhook",
+		})
+		return
+	}
+
+	webhook.Secret = ""
+	c.JSON(http.StatusOK, gin.H{
+		"webhook": webhook,
+	})
+}
+
+// DeleteWebhook deletes a webhook and its delivery log (requires webhooks:manage)
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	webhook, err := h.db.GetWebhook(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Webhook not found",
+		})
+		return
+	}
+
+	if err := h.db.DeleteWebhook(webhook.ID.String()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to delete webhook",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted",
+	})
+}
+
+// ListDeliveries returns a page of webhook deliveries, most recent first.
+// The status=dead filter lists the dead-letter log (requires webhooks:manage).
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	status := models.WebhookDeliveryStatus(c.Query("status"))
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliveryDelivered, models.WebhookDeliveryDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid status. Must be one of: pending, delivered, dead",
+		})
+		return
+	}
+
+	limitStr := c.DefaultQuery("limit", "50")
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	offsetStr := c.DefaultQuery("offset", "0")
+	offset, err := strconv.Atoi(offsetStr)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	deliveries, err := h.db.GetWebhookDeliveries(c.Query("webhook_id"), status, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to get webhook deliveries",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"offset":     offset,
+		"limit":      limit,
+	})
+}
+
+// ReplayDelivery queues a delivery to be sent again right away with a fresh set of attempts.
+// Dead and delivered deliveries can both be replayed (requires webhooks:manage).
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	delivery, err := h.db.GetWebhookDelivery(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Webhook delivery not found",
+		})
+		return
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+
+	if err := h.db.UpdateWebhookDelivery(delivery); err != nil {
+		log.Printf("Failed to replay webhook delivery %s: %v", delivery.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to replay webhook delivery",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"delivery": delivery,
+	})
+}
+
+// validateWebhook returns an error message for an invalid webhook URL or event list, or an empty string
+func validateWebhook(rawURL string, events []models.WebhookEvent) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "Webhook url must be an absolute http or https URL"
+	}
+	if len(events) == 0 {
+		return "Webhook events are required"
+	}
+	for _, event := range events {
+		if !event.IsValid() {
+			return "Invalid webhook event: " + string(event)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"game2048/internal/database"
+)
+
+// guestCleanupInterval is how often expired guests are deleted
+const guestCleanupInterval = time.Hour
+
+// GuestCleanupJob periodically deletes guests whose guest token expired without them signing in, together with their games
+type GuestCleanupJob struct {
+	db       database.Database
+	ttl      time.Duration
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewGuestCleanupJob creates a new guest cleanup job, guests are deleted once they are older than the guest token lifetime
+func NewGuestCleanupJob(db database.Database, ttl time.Duration) *GuestCleanupJob {
+	return &GuestCleanupJob{
+		db:       db,
+		ttl:      ttl,
+		interval: guestCleanupInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run runs the job immediately and then on every interval until Stop is called
+func (j *GuestCleanupJob) Run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.RunOnce(time.Now())
+
+	for {
+		select {
+		case <-ticker.C:
+			j.RunOnce(time.Now())
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the job and waits for a running cleanup to complete
+func (j *GuestCleanupJob) Stop() {
+	close(j.stop)
+	<-j.done
+}
+
+// RunOnce deletes the guests whose tokens expired before now
+func (j *GuestCleanupJob) RunOnce(now time.Time) {
+	deleted, err := j.db.DeleteExpiredGuests(now.Add(-j.ttl))
+	if err != nil {
+		log.Printf("Failed to delete expired guests: %v", err)
+		return
+	}
+
+	if deleted > 0 {
+		log.Printf("Deleted %d expired guests", deleted)
+	}
+}
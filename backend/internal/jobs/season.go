@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"game2048/internal/config"
+	"game2048/internal/database"
+	"game2048/internal/events"
+	"game2048/internal/service"
+	"game2048/pkg/models"
+)
+
+// SeasonCloseJob periodically closes ended seasons: it archives their final standings and awards badges
+type SeasonCloseJob struct {
+	db         database.Database
+	games      *service.GameService
+	bus        *events.Bus
+	maxEntries int
+	interval   time.Duration
+	delay      time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSeasonCloseJob creates a new season close job.
+// It shares the interval and delay settings of the leaderboard snapshot job.
+func NewSeasonCloseJob(db database.Database, games *service.GameService, bus *events.Bus, cfg *config.Config) *SeasonCloseJob {
+	interval := time.Duration(cfg.Leaderboard.SnapshotInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	maxEntries := cfg.Leaderboard.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 100
+	}
+
+	return &SeasonCloseJob{
+		db:         db,
+		games:      games,
+		bus:        bus,
+		maxEntries: maxEntries,
+		interval:   interval,
+		delay:      time.Duration(cfg.Leaderboard.SnapshotDelay) * time.Second,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Run runs the job immediately and then on every interval until Stop is called
+func (j *SeasonCloseJob) Run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.RunOnce(time.Now())
+
+	for {
+		select {
+		case <-ticker.C:
+			j.RunOnce(time.Now())
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the job and waits for a running close to complete
+func (j *SeasonCloseJob) Stop() {
+	close(j.stop)
+	<-j.done
+}
+
+// RunOnce closes every season that ended before the grace delay
+func (j *SeasonCloseJob) RunOnce(now time.Time) {
+	// Give games started just before the end of a season time to finish
+	seasons, err := j.db.GetSeasonsToClose(now.Add(-j.delay))
+	if err != nil {
+		log.Printf("Failed to get seasons to close: %v", err)
+		return
+	}
+
+	closed := 0
+	for _, season := range seasons {
+		closedNow, err := j.db.CloseSeason(season.ID.String(), j.maxEntries)
+		if err != nil {
+			log.Printf("Failed to close season %s (%s): %v", season.Name, season.ID, err)
+			continue
+		}
+		if !closedNow {
+			// Closed and announced by an admin in the meantime
+			continue
+		}
+		log.Printf("Closed season %s (%s)", season.Name, season.ID)
+		closed++
+
+		closedSeason, err := j.db.GetSeason(season.ID.String())
+		if err != nil {
+			log.Printf("Failed to load closed season %s: %v", season.ID, err)
+			continue
+		}
+		j.bus.Publish(events.SeasonClosed{Season: *closedSeason, At: now})
+	}
+
+	if closed > 0 {
+		j.games.InvalidateLeaderboards(context.Background(), models.LeaderboardSeason)
+	}
+}
@@ -0,0 +1,127 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"game2048/internal/config"
+	"game2048/internal/database"
+	"game2048/pkg/models"
+)
+
+// snapshotBackfillPeriods is how many finished periods per leaderboard type are checked on each run,
+// so that periods missed while the server was down still get frozen
+const snapshotBackfillPeriods = 7
+
+// LeaderboardSnapshotJob periodically freezes finished leaderboard periods into their snapshot tables
+type LeaderboardSnapshotJob struct {
+	db         database.Database
+	calendar   models.PeriodCalendar
+	maxEntries int
+	interval   time.Duration
+	delay      time.Duration
+
+	// Periods already frozen (or found frozen) by this process, keyed by type and start date
+	frozen map[string]bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewLeaderboardSnapshotJob creates a new leaderboard snapshot job
+func NewLeaderboardSnapshotJob(db database.Database, cfg *config.Config) *LeaderboardSnapshotJob {
+	interval := time.Duration(cfg.Leaderboard.SnapshotInterval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+
+	maxEntries := cfg.Leaderboard.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = 100
+	}
+
+	return &LeaderboardSnapshotJob{
+		db:         db,
+		calendar:   cfg.PeriodCalendar(),
+		maxEntries: maxEntries,
+		interval:   interval,
+		delay:      time.Duration(cfg.Leaderboard.SnapshotDelay) * time.Second,
+		frozen:     make(map[string]bool),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Run runs the job immediately and then on every interval until Stop is called
+func (j *LeaderboardSnapshotJob) Run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.RunOnce(time.Now())
+
+	for {
+		select {
+		case <-ticker.C:
+			j.RunOnce(time.Now())
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the job and waits for a running snapshot to complete
+func (j *LeaderboardSnapshotJob) Stop() {
+	close(j.stop)
+	<-j.done
+}
+
+// RunOnce freezes every recently finished period that has not been frozen yet
+func (j *LeaderboardSnapshotJob) RunOnce(now time.Time) {
+	// Give games started just before the end of a period time to finish
+	cutoff := now.Add(-j.delay)
+
+	leaderboardTypes := []models.LeaderboardType{
+		models.LeaderboardDaily,
+		models.LeaderboardWeekly,
+		models.LeaderboardMonthly,
+	}
+
+	for _, lbType := range leaderboardTypes {
+		current, ok := j.calendar.PeriodContaining(lbType, cutoff)
+		if !ok {
+			continue
+		}
+
+		period := current
+		for i := 0; i < snapshotBackfillPeriods; i++ {
+			period = j.calendar.Previous(lbType, period)
+			j.freeze(lbType, period)
+		}
+	}
+}
+
+// freeze writes the snapshot of a single finished period unless it already exists
+func (j *LeaderboardSnapshotJob) freeze(lbType models.LeaderboardType, period models.LeaderboardPeriod) {
+	key := string(lbType) + ":" + period.Start.Format("2006-01-02")
+	if j.frozen[key] {
+		return
+	}
+
+	exists, err := j.db.HasLeaderboardSnapshot(lbType, period.Start)
+	if err != nil {
+		log.Printf("Failed to check %s leaderboard snapshot for %s: %v", lbType, period.Start.Format("2006-01-02"), err)
+		return
+	}
+
+	if !exists {
+		if err := j.db.SnapshotLeaderboard(lbType, period, j.maxEntries); err != nil {
+			log.Printf("Failed to snapshot %s leaderboard for %s: %v", lbType, period.Start.Format("2006-01-02"), err)
+			return
+		}
+		log.Printf("Snapshotted %s leaderboard for %s", lbType, period.Start.Format("2006-01-02"))
+	}
+
+	j.frozen[key] = true
+}
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"game2048/internal/database"
+)
+
+// refreshTokenCleanupInterval is how often expired refresh tokens are deleted
+const refreshTokenCleanupInterval = time.Hour
+
+// RefreshTokenCleanupJob periodically deletes expired refresh tokens.
+// Rotated tokens are kept until they expire so their reuse can still be detected.
+type RefreshTokenCleanupJob struct {
+	db       database.Database
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRefreshTokenCleanupJob creates a new refresh token cleanup job
+func NewRefreshTokenCleanupJob(db database.Database) *RefreshTokenCleanupJob {
+	return &RefreshTokenCleanupJob{
+		db:       db,
+		interval: refreshTokenCleanupInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run runs the job immediately and then on every interval until Stop is called
+func (j *RefreshTokenCleanupJob) Run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.RunOnce(time.Now())
+
+	for {
+		select {
+		case <-ticker.C:
+			j.RunOnce(time.Now())
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the job and waits for a running cleanup to complete
+func (j *RefreshTokenCleanupJob) Stop() {
+	close(j.stop)
+	<-j.done
+}
+
+// RunOnce deletes the refresh tokens that expired before now
+func (j *RefreshTokenCleanupJob) RunOnce(now time.Time) {
+	deleted, err := j.db.DeleteExpiredRefreshTokens(now)
+	if err != nil {
+		log.Printf("Failed to delete expired refresh tokens: %v", err)
+		return
+	}
+
+	if deleted > 0 {
+		log.Printf("Deleted %d expired refresh tokens", deleted)
+	}
+}
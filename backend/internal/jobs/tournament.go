@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"game2048/internal/config"
+	"game2048/internal/tournaments"
+)
+
+// TournamentJob periodically starts and ends tournament rounds and advances players through the brackets
+type TournamentJob struct {
+	tournaments *tournaments.Service
+	interval    time.Duration
+
+	// Cancelled by Stop, aborting round ends that wait for players' games
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewTournamentJob creates a new tournament job
+func NewTournamentJob(service *tournaments.Service, cfg *config.Config) *TournamentJob {
+	interval := time.Duration(cfg.Tournament.Interval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TournamentJob{
+		tournaments: service,
+		interval:    interval,
+		ctx:         ctx,
+		cancel:      cancel,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Run runs the job immediately and then on every interval until Stop is called
+func (j *TournamentJob) Run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.RunOnce(time.Now())
+
+	for {
+		select {
+		case <-ticker.C:
+			j.RunOnce(time.Now())
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the job and waits for a running advancement to complete
+func (j *TournamentJob) Stop() {
+	close(j.stop)
+	j.cancel()
+	<-j.done
+}
+
+// RunOnce starts and ends the tournament rounds that are due
+func (j *TournamentJob) RunOnce(now time.Time) {
+	j.tournaments.Advance(j.ctx, now)
+}
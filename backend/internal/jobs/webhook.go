@@ -0,0 +1,227 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"game2048/internal/config"
+	"game2048/internal/database"
+	"game2048/internal/webhooks"
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// webhookBatchSize is the maximum number of deliveries sent on each run
+const webhookBatchSize = 50
+
+// webhookMaxRetryDelay caps the exponential backoff between delivery attempts
+const webhookMaxRetryDelay = 6 * time.Hour
+
+// WebhookDeliveryJob periodically sends due webhook deliveries.
+// Failed deliveries are retried with exponential backoff and dead-lettered after the last attempt.
+// Webhooks are sent to concurrently, so a slow endpoint only delays its own deliveries.
+type WebhookDeliveryJob struct {
+	db          database.Database
+	client      *http.Client
+	workers     int
+	maxAttempts int
+	baseDelay   time.Duration
+	interval    time.Duration
+
+	// Cancelled by Stop, aborting deliveries in flight
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewWebhookDeliveryJob creates a new webhook delivery job
+func NewWebhookDeliveryJob(db database.Database, cfg *config.Config) *WebhookDeliveryJob {
+	interval := time.Duration(cfg.Webhook.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	timeout := time.Duration(cfg.Webhook.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	workers := cfg.Webhook.Workers
+	if workers <= 0 {
+		workers = 4
+	}
+
+	maxAttempts := cfg.Webhook.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+
+	baseDelay := time.Duration(cfg.Webhook.RetryBaseDelay) * time.Second
+	if baseDelay <= 0 {
+		baseDelay = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookDeliveryJob{
+		db:          db,
+		client:      &http.Client{Timeout: timeout},
+		workers:     workers,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		interval:    interval,
+		ctx:         ctx,
+		cancel:      cancel,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Run runs the job immediately and then on every interval until Stop is called
+func (j *WebhookDeliveryJob) Run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.RunOnce(time.Now())
+
+	for {
+		select {
+		case <-ticker.C:
+			j.RunOnce(time.Now())
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the job, aborting running deliveries, and waits for it to return.
+// Aborted deliveries do not count as attempts and are sent again on the next start.
+func (j *WebhookDeliveryJob) Stop() {
+	close(j.stop)
+	j.cancel()
+	<-j.done
+}
+
+// RunOnce sends the deliveries that are due.
+// Deliveries to one webhook are sent in order by a single worker, up to the configured number of webhooks at once.
+func (j *WebhookDeliveryJob) RunOnce(now time.Time) {
+	deliveries, err := j.db.GetDueWebhookDeliveries(now, webhookBatchSize)
+	if err != nil {
+		log.Printf("Failed to get due webhook deliveries: %v", err)
+		return
+	}
+
+	var webhookIDs []uuid.UUID
+	byWebhook := make(map[uuid.UUID][]*models.WebhookDelivery)
+	for i := range deliveries {
+		webhookID := deliveries[i].WebhookID
+		if _, ok := byWebhook[webhookID]; !ok {
+			webhookIDs = append(webhookIDs, webhookID)
+		}
+		byWebhook[webhookID] = append(byWebhook[webhookID], &deliveries[i])
+	}
+
+	queue := make(chan []*models.WebhookDelivery)
+	var wg sync.WaitGroup
+	for i := 0; i < min(j.workers, len(webhookIDs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pending := range queue {
+				j.deliverAll(pending)
+			}
+		}()
+	}
+
+	for _, webhookID := range webhookIDs {
+		queue <- byWebhook[webhookID]
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// deliverAll sends the due deliveries of one webhook in order.
+// After a timeout the remaining deliveries wait for the next run instead of timing out one by one.
+func (j *WebhookDeliveryJob) deliverAll(deliveries []*models.WebhookDelivery) {
+	for i, delivery := range deliveries {
+		if j.ctx.Err() != nil {
+			return
+		}
+		if !j.deliver(delivery) {
+			if remaining := len(deliveries) - i - 1; remaining > 0 {
+				log.Printf("Webhook %s timed out, postponing %d deliveries to the next run", delivery.WebhookID, remaining)
+			}
+			return
+		}
+	}
+}
+
+// deliver makes one attempt at a delivery and records its outcome.
+// It returns false if the webhook timed out.
+func (j *WebhookDeliveryJob) deliver(delivery *models.WebhookDelivery) bool {
+	webhook, err := j.db.GetWebhook(delivery.WebhookID.String())
+	if err != nil {
+		log.Printf("Failed to get webhook %s: %v", delivery.WebhookID, err)
+		return true
+	}
+
+	// Deliveries to disabled webhooks wait until the webhook is enabled again
+	if !webhook.Active {
+		delivery.NextAttemptAt = time.Now().Add(j.baseDelay)
+		if err := j.db.UpdateWebhookDelivery(delivery); err != nil {
+			log.Printf("Failed to postpone webhook delivery %s: %v", delivery.ID, err)
+		}
+		return true
+	}
+
+	statusCode, err := webhooks.Send(j.ctx, j.client, webhook, delivery, time.Now())
+	if err != nil && j.ctx.Err() != nil {
+		// Stopped while sending, the delivery stays due
+		return true
+	}
+	now := time.Now()
+
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= j.maxAttempts {
+			delivery.Status = models.WebhookDeliveryDead
+			log.Printf("Webhook delivery %s to %s failed %d times, giving up: %v", delivery.ID, webhook.URL, delivery.Attempts, err)
+		} else {
+			delivery.NextAttemptAt = now.Add(j.retryDelay(delivery.Attempts))
+		}
+	}
+
+	if err := j.db.UpdateWebhookDelivery(delivery); err != nil {
+		log.Printf("Failed to update webhook delivery %s: %v", delivery.ID, err)
+	}
+
+	var netErr net.Error
+	return !errors.As(err, &netErr) || !netErr.Timeout()
+}
+
+// retryDelay returns the delay after the given number of failed attempts, doubling with every attempt
+func (j *WebhookDeliveryJob) retryDelay(attempts int) time.Duration {
+	delay := j.baseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= webhookMaxRetryDelay {
+			return webhookMaxRetryDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"game2048/internal/config"
+	"game2048/internal/database"
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeWebhookDB serves webhooks with due deliveries from memory,
+// methods the job does not use panic through the nil embedded interface
+type fakeWebhookDB struct {
+	database.Database
+
+	webhooks   map[string]models.Webhook
+	deliveries []models.WebhookDelivery
+
+	mutex   sync.Mutex
+	updated []models.WebhookDelivery
+}
+
+func (d *fakeWebhookDB) GetDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	return append([]models.WebhookDelivery(nil), d.deliveries...), nil
+}
+
+func (d *fakeWebhookDB) GetWebhook(webhookID string) (*models.Webhook, error) {
+	webhook := d.webhooks[webhookID]
+	return &webhook, nil
+}
+
+// addWebhook registers an active webhook for a URL with a number of due deliveries
+func (d *fakeWebhookDB) addWebhook(url string, deliveries int) uuid.UUID {
+	if d.webhooks == nil {
+		d.webhooks = make(map[string]models.Webhook)
+	}
+
+	webhookID := uuid.New()
+	d.webhooks[webhookID.String()] = models.Webhook{ID: webhookID, URL: url, Secret: "secret", Active: true}
+	for i := 0; i < deliveries; i++ {
+		d.deliveries = append(d.deliveries, models.WebhookDelivery{
+			ID:        uuid.New(),
+			WebhookID: webhookID,
+			Event:     models.WebhookEvent("game.finished"),
+			Payload:   []byte(`{}`),
+			Status:    models.WebhookDeliveryPending,
+		})
+	}
+	return webhookID
+}
+
+func (d *fakeWebhookDB) UpdateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.updated = append(d.updated, *delivery)
+	return nil
+}
+
+func TestWebhookDeliveryJobStopAbortsSlowDeliveries(t *testing.T) {
+	received := make(chan struct{}, 10)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	db := &fakeWebhookDB{}
+	db.addWebhook(server.URL, 3)
+
+	cfg := &config.Config{}
+	cfg.Webhook.Timeout = 30
+	cfg.Webhook.PollInterval = 60
+	job := NewWebhookDeliveryJob(db, cfg)
+	go job.Run()
+
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("webhook was not called")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		job.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Stop waited for the slow delivery")
+	}
+
+	if len(received) != 0 {
+		t.Fatalf("deliveries were sent after Stop")
+	}
+	if len(db.updated) != 0 {
+		t.Fatalf("aborted deliveries were recorded as attempts: %+v", db.updated)
+	}
+}
+
+func TestWebhookDeliveryJobSlowWebhookDoesNotDelayOthers(t *testing.T) {
+	release := make(chan struct{})
+	var slowCalls atomic.Int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slowCalls.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer fast.Close()
+
+	db := &fakeWebhookDB{}
+	slowID := db.addWebhook(slow.URL, 3)
+	fastID := db.addWebhook(fast.URL, 3)
+
+	cfg := &config.Config{}
+	cfg.Webhook.Timeout = 1
+	job := NewWebhookDeliveryJob(db, cfg)
+
+	started := time.Now()
+	job.RunOnce(started)
+	if elapsed := time.Since(started); elapsed > 2500*time.Millisecond {
+		t.Fatalf("run took %v, the slow webhook timed out more than once", elapsed)
+	}
+
+	if calls := slowCalls.Load(); calls != 1 {
+		t.Fatalf("slow webhook was called %d times, want 1", calls)
+	}
+
+	statuses := make(map[uuid.UUID][]models.WebhookDeliveryStatus)
+	for _, delivery := range db.updated {
+		statuses[delivery.WebhookID] = append(statuses[delivery.WebhookID], delivery.Status)
+	}
+	if len(statuses[fastID]) != 3 {
+		t.Fatalf("fast webhook deliveries recorded %v, want 3 delivered", statuses[fastID])
+	}
+	for _, status := range statuses[fastID] {
+		if status != models.WebhookDeliveryDelivered {
+			t.Fatalf("fast webhook delivery is %s, want delivered", status)
+		}
+	}
+	if len(statuses[slowID]) != 1 || statuses[slowID][0] != models.WebhookDeliveryPending {
+		t.Fatalf("slow webhook deliveries recorded %v, want one failed attempt", statuses[slowID])
+	}
+}
@@ -0,0 +1,49 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+)
+
+// Badge layout, sized for an 11px sans-serif font
+const (
+	badgeHeight    = 20
+	badgeCharWidth = 7
+	badgePadding   = 10
+)
+
+// badgeTemplate is a flat two-part badge in the style of README status badges
+const badgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="%[2]d" role="img" aria-label="%[3]s: %[4]s">
+<title>%[3]s: %[4]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="%[2]d" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)">
+<rect width="%[5]d" height="%[2]d" fill="#8f7a66"/>
+<rect x="%[5]d" width="%[6]d" height="%[2]d" fill="#edc22e"/>
+<rect width="%[1]d" height="%[2]d" fill="url(#s)"/>
+</g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="14" textLength="%[9]d">%[3]s</text>
+<text x="%[8]d" y="14" textLength="%[10]d">%[4]s</text>
+</g>
+</svg>
+`
+
+// BadgeSVG renders a badge with a label on the left and a message on the right
+func BadgeSVG(label, message string) []byte {
+	labelText := len([]rune(label)) * badgeCharWidth
+	messageText := len([]rune(message)) * badgeCharWidth
+	labelWidth := labelText + 2*badgePadding
+	messageWidth := messageText + 2*badgePadding
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, badgeTemplate,
+		labelWidth+messageWidth, badgeHeight,
+		html.EscapeString(label), html.EscapeString(message),
+		labelWidth, messageWidth,
+		labelWidth/2, labelWidth+messageWidth/2,
+		labelText, messageText,
+	)
+	return buf.Bytes()
+}
@@ -0,0 +1,192 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strconv"
+
+	"game2048/pkg/models"
+)
+
+// Share card dimensions, matching the size recommended for Open Graph images
+const (
+	CardWidth  = 1200
+	CardHeight = 630
+)
+
+// Board layout on the card
+const (
+	boardX      = 45
+	boardY      = 45
+	boardSize   = 540
+	boardRadius = 12
+	tileGap     = 12
+	tileRadius  = 6
+	tileSize    = (boardSize - (models.BoardSize+1)*tileGap) / models.BoardSize
+)
+
+// Text panel layout on the card, to the right of the board
+const (
+	panelX     = boardX + boardSize + 50
+	panelWidth = CardWidth - panelX - 45
+)
+
+// Colors of the game's palette, as used by the web client
+var (
+	backgroundColor = color.RGBA{0xfa, 0xf8, 0xef, 0xff}
+	boardColor      = color.RGBA{0xbb, 0xad, 0xa0, 0xff}
+	emptyTileColor  = color.RGBA{0xcd, 0xc1, 0xb4, 0xff}
+	darkTextColor   = color.RGBA{0x77, 0x6e, 0x65, 0xff}
+	lightTextColor  = color.RGBA{0xf9, 0xf6, 0xf2, 0xff}
+	mutedTextColor  = color.RGBA{0x8f, 0x7a, 0x66, 0xff}
+	scoreColor      = color.RGBA{0xf3, 0x9c, 0x12, 0xff}
+	superTileColor  = color.RGBA{0x3c, 0x3a, 0x32, 0xff}
+)
+
+// tileColors maps tile values to their background color
+var tileColors = map[int]color.RGBA{
+	2:     {0xee, 0xe4, 0xda, 0xff},
+	4:     {0xed, 0xe0, 0xc8, 0xff},
+	8:     {0xf2, 0xb1, 0x79, 0xff},
+	16:    {0xf5, 0x95, 0x63, 0xff},
+	32:    {0xf6, 0x7c, 0x5f, 0xff},
+	64:    {0xf6, 0x5e, 0x3b, 0xff},
+	128:   {0xed, 0xcf, 0x72, 0xff},
+	256:   {0xed, 0xcc, 0x61, 0xff},
+	512:   {0xed, 0xc8, 0x50, 0xff},
+	1024:  {0xed, 0xc5, 0x3f, 0xff},
+	2048:  {0xed, 0xc2, 0x2e, 0xff},
+	4096:  {0x3c, 0x3a, 0x32, 0xff},
+	8192:  {0x00, 0x00, 0x00, 0xff},
+	16384: {0xff, 0x6b, 0x6b, 0xff},
+}
+
+// Card describes the content of a share card
+type Card struct {
+	Board      models.Board
+	Score      int
+	PlayerName string
+	Victory    bool
+
+	// NameFont draws the player name if it covers it, otherwise the bitmap font draws the characters it can
+	NameFont *NameFont
+}
+
+// DrawCard draws a share card showing the board, score and player name
+func DrawCard(card Card) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, CardWidth, CardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(backgroundColor), image.Point{}, draw.Src)
+
+	fillRoundedRect(img, image.Rect(boardX, boardY, boardX+boardSize, boardY+boardSize), boardRadius, boardColor)
+	for row := 0; row < models.BoardSize; row++ {
+		for col := 0; col < models.BoardSize; col++ {
+			x := boardX + tileGap + col*(tileSize+tileGap)
+			y := boardY + tileGap + row*(tileSize+tileGap)
+			drawTile(img, image.Rect(x, y, x+tileSize, y+tileSize), card.Board[row][col])
+		}
+	}
+
+	y := boardY + 10
+	drawText(img, panelX, y, "2048", 16, darkTextColor)
+	y += textHeight(16) + 50
+
+	if card.NameFont != nil && card.NameFont.covers(card.PlayerName) {
+		card.NameFont.draw(img, panelX, y, card.PlayerName, panelWidth, darkTextColor)
+	} else {
+		name := fitText(drawableName(card.PlayerName), 6, panelWidth)
+		drawText(img, panelX, y, name, 6, darkTextColor)
+	}
+	y += textHeight(6) + 50
+
+	drawText(img, panelX, y, "SCORE", 4, mutedTextColor)
+	y += textHeight(4) + 15
+	score := FormatScore(card.Score)
+	scoreScale := 10
+	for scoreScale > 1 && textWidth(score, scoreScale) > panelWidth {
+		scoreScale--
+	}
+	drawText(img, panelX, y+(textHeight(10)-textHeight(scoreScale))/2, score, scoreScale, scoreColor)
+	y += textHeight(10) + 50
+
+	result := "GAME OVER"
+	if card.Victory {
+		result = "VICTORY!"
+	}
+	drawText(img, panelX, y, result, 5, mutedTextColor)
+
+	return img
+}
+
+// EncodeCard draws a share card and writes it to w as PNG
+func EncodeCard(w io.Writer, card Card) error {
+	return png.Encode(w, DrawCard(card))
+}
+
+// drawTile draws a single tile with its value centered
+func drawTile(img *image.RGBA, rect image.Rectangle, value int) {
+	if value == 0 {
+		fillRoundedRect(img, rect, tileRadius, emptyTileColor)
+		return
+	}
+
+	bg, ok := tileColors[value]
+	if !ok {
+		bg = superTileColor
+	}
+	fillRoundedRect(img, rect, tileRadius, bg)
+
+	fg := lightTextColor
+	if value <= 4 {
+		fg = darkTextColor
+	}
+
+	// Use the largest scale that keeps the value well inside the tile
+	text := strconv.Itoa(value)
+	scale := 8
+	for scale > 1 && (textWidth(text, scale) > rect.Dx()*3/4 || textHeight(scale) > rect.Dy()/2) {
+		scale--
+	}
+
+	x := rect.Min.X + (rect.Dx()-textWidth(text, scale))/2
+	y := rect.Min.Y + (rect.Dy()-textHeight(scale))/2
+	drawText(img, x, y, text, scale, fg)
+}
+
+// fillRoundedRect fills rect with c, leaving out the corners outside the given radius
+func fillRoundedRect(img *image.RGBA, rect image.Rectangle, radius int, c color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			// Distance into the corner square, if the pixel is in one
+			dx, dy := 0, 0
+			if x < rect.Min.X+radius {
+				dx = rect.Min.X + radius - x
+			} else if x >= rect.Max.X-radius {
+				dx = x - (rect.Max.X - radius - 1)
+			}
+			if y < rect.Min.Y+radius {
+				dy = rect.Min.Y + radius - y
+			} else if y >= rect.Max.Y-radius {
+				dy = y - (rect.Max.Y - radius - 1)
+			}
+			if dx*dx+dy*dy > radius*radius {
+				continue
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// FormatScore formats a score with thousands separators
+func FormatScore(score int) string {
+	s := strconv.Itoa(score)
+	if score < 0 {
+		return s
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,101 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// nameFontSize is the size in pixels of player names drawn with a NameFont, about the height of the bitmap font at scale 6
+const nameFontSize = 48
+
+// NameFont draws player names with a TrueType or OpenType font, for names the bitmap font cannot draw such as CJK names.
+// It is safe for concurrent use.
+type NameFont struct {
+	face  font.Face
+	mutex sync.Mutex // font.Face is not safe for concurrent use
+}
+
+// LoadNameFont loads a TrueType or OpenType font file, using the first font of a collection such as a .ttc file
+func LoadNameFont(path string) (*NameFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font: %w", err)
+	}
+
+	collection, err := opentype.ParseCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+	if collection.NumFonts() == 0 {
+		return nil, fmt.Errorf("font collection is empty")
+	}
+
+	f, err := collection.Font(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    nameFontSize,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create font face: %w", err)
+	}
+
+	return &NameFont{face: face}, nil
+}
+
+// covers reports whether the font has a glyph for every character of the text
+func (f *NameFont) covers(text string) bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if _, ok := f.face.GlyphAdvance(r); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// draw draws text with its top left corner at (x, y), shortened with a trailing ellipsis to at most maxWidth pixels
+func (f *NameFont) draw(img draw.Image, x, y int, text string, maxWidth int, c color.Color) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: f.face,
+	}
+
+	limit := fixed.I(maxWidth)
+	if drawer.MeasureString(text) > limit {
+		runes := []rune(text)
+		for len(runes) > 0 {
+			runes = runes[:len(runes)-1]
+			text = strings.TrimRight(string(runes), " ") + "..."
+			if drawer.MeasureString(text) <= limit {
+				break
+			}
+		}
+	}
+
+	drawer.Dot = fixed.Point26_6{X: fixed.I(x), Y: fixed.I(y) + f.face.Metrics().Ascent}
+	drawer.DrawString(text)
+}
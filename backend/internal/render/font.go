@@ -0,0 +1,155 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Glyph dimensions of the bitmap font in font pixels
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphSpacing = 1
+)
+
+// fallbackGlyph is drawn for characters the font does not cover
+const fallbackGlyph = '?'
+
+// fallbackName is drawn instead of a player name without any letter or digit the font covers
+const fallbackName = "PLAYER"
+
+// glyphs is a 5x7 bitmap font covering digits, letters and common punctuation.
+// Each row is five bits wide with the leftmost pixel in the highest bit.
+// Lowercase letters are drawn with their uppercase glyph.
+var glyphs = map[rune][glyphHeight]uint8{
+	' ':  {0, 0, 0, 0, 0, 0, 0},
+	'0':  {0b01110, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b01110},
+	'1':  {0b00100, 0b01100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'2':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0b01000, 0b11111},
+	'3':  {0b11111, 0b00010, 0b00100, 0b00010, 0b00001, 0b10001, 0b01110},
+	'4':  {0b00010, 0b00110, 0b01010, 0b10010, 0b11111, 0b00010, 0b00010},
+	'5':  {0b11111, 0b10000, 0b11110, 0b00001, 0b00001, 0b10001, 0b01110},
+	'6':  {0b00110, 0b01000, 0b10000, 0b11110, 0b10001, 0b10001, 0b01110},
+	'7':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b01000, 0b01000},
+	'8':  {0b01110, 0b10001, 0b10001, 0b01110, 0b10001, 0b10001, 0b01110},
+	'9':  {0b01110, 0b10001, 0b10001, 0b01111, 0b00001, 0b00010, 0b01100},
+	'A':  {0b01110, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'B':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10001, 0b10001, 0b11110},
+	'C':  {0b01110, 0b10001, 0b10000, 0b10000, 0b10000, 0b10001, 0b01110},
+	'D':  {0b11100, 0b10010, 0b10001, 0b10001, 0b10001, 0b10010, 0b11100},
+	'E':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b11111},
+	'F':  {0b11111, 0b10000, 0b10000, 0b11110, 0b10000, 0b10000, 0b10000},
+	'G':  {0b01110, 0b10001, 0b10000, 0b10111, 0b10001, 0b10001, 0b01111},
+	'H':  {0b10001, 0b10001, 0b10001, 0b11111, 0b10001, 0b10001, 0b10001},
+	'I':  {0b01110, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'J':  {0b00111, 0b00010, 0b00010, 0b00010, 0b00010, 0b10010, 0b01100},
+	'K':  {0b10001, 0b10010, 0b10100, 0b11000, 0b10100, 0b10010, 0b10001},
+	'L':  {0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b11111},
+	'M':  {0b10001, 0b11011, 0b10101, 0b10101, 0b10001, 0b10001, 0b10001},
+	'N':  {0b10001, 0b10001, 0b11001, 0b10101, 0b10011, 0b10001, 0b10001},
+	'O':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'P':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10000, 0b10000, 0b10000},
+	'Q':  {0b01110, 0b10001, 0b10001, 0b10001, 0b10101, 0b10010, 0b01101},
+	'R':  {0b11110, 0b10001, 0b10001, 0b11110, 0b10100, 0b10010, 0b10001},
+	'S':  {0b01111, 0b10000, 0b10000, 0b01110, 0b00001, 0b00001, 0b11110},
+	'T':  {0b11111, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0b00100},
+	'U':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01110},
+	'V':  {0b10001, 0b10001, 0b10001, 0b10001, 0b10001, 0b01010, 0b00100},
+	'W':  {0b10001, 0b10001, 0b10001, 0b10101, 0b10101, 0b10101, 0b01010},
+	'X':  {0b10001, 0b10001, 0b01010, 0b00100, 0b01010, 0b10001, 0b10001},
+	'Y':  {0b10001, 0b10001, 0b10001, 0b01010, 0b00100, 0b00100, 0b00100},
+	'Z':  {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0b11111},
+	'-':  {0, 0, 0, 0b11111, 0, 0, 0},
+	'_':  {0, 0, 0, 0, 0, 0, 0b11111},
+	'.':  {0, 0, 0, 0, 0, 0b01100, 0b01100},
+	',':  {0, 0, 0, 0, 0b01100, 0b00100, 0b01000},
+	':':  {0, 0b01100, 0b01100, 0, 0b01100, 0b01100, 0},
+	'!':  {0b00100, 0b00100, 0b00100, 0b00100, 0b00100, 0, 0b00100},
+	'?':  {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0, 0b00100},
+	'\'': {0b00100, 0b00100, 0b01000, 0, 0, 0, 0},
+	'/':  {0, 0b00001, 0b00010, 0b00100, 0b01000, 0b10000, 0},
+	'(':  {0b00010, 0b00100, 0b01000, 0b01000, 0b01000, 0b00100, 0b00010},
+	')':  {0b01000, 0b00100, 0b00010, 0b00010, 0b00010, 0b00100, 0b01000},
+	'#':  {0b01010, 0b01010, 0b11111, 0b01010, 0b11111, 0b01010, 0b01010},
+	'+':  {0, 0b00100, 0b00100, 0b11111, 0b00100, 0b00100, 0},
+	'@':  {0b01110, 0b10001, 0b00001, 0b01101, 0b10101, 0b10101, 0b01110},
+}
+
+// glyphFor returns the bitmap of a character, falling back to a question mark
+func glyphFor(r rune) [glyphHeight]uint8 {
+	if g, ok := glyphs[unicode.ToUpper(r)]; ok {
+		return g
+	}
+	return glyphs[fallbackGlyph]
+}
+
+// drawableName returns the part of a player name the font can draw.
+// Accents are dropped from letters and other characters the font does not cover are left out,
+// names without a letter or digit left, such as names in non-Latin scripts, become fallbackName.
+func drawableName(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		if _, ok := glyphs[unicode.ToUpper(r)]; ok {
+			b.WriteRune(r)
+		}
+	}
+
+	drawable := strings.Join(strings.Fields(b.String()), " ")
+	if !strings.ContainsFunc(drawable, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		return fallbackName
+	}
+	return drawable
+}
+
+// textWidth returns the width in image pixels of text drawn at the given scale
+func textWidth(text string, scale int) int {
+	n := len([]rune(text))
+	if n == 0 {
+		return 0
+	}
+	return (n*(glyphWidth+glyphSpacing) - glyphSpacing) * scale
+}
+
+// textHeight returns the height in image pixels of text drawn at the given scale
+func textHeight(scale int) int {
+	return glyphHeight * scale
+}
+
+// drawText draws text with its top left corner at (x, y), each font pixel being a scale x scale square
+func drawText(img draw.Image, x, y int, text string, scale int, c color.Color) {
+	src := image.NewUniform(c)
+	for _, r := range text {
+		glyph := glyphFor(r)
+		for row, bits := range glyph {
+			for col := 0; col < glyphWidth; col++ {
+				if bits&(1<<(glyphWidth-1-col)) == 0 {
+					continue
+				}
+				px := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+				draw.Draw(img, px, src, image.Point{}, draw.Src)
+			}
+		}
+		x += (glyphWidth + glyphSpacing) * scale
+	}
+}
+
+// fitText shortens text with a trailing ellipsis until it is at most maxWidth wide at the given scale
+func fitText(text string, scale, maxWidth int) string {
+	if textWidth(text, scale) <= maxWidth {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		candidate := strings.TrimRight(string(runes), " ") + "..."
+		if textWidth(candidate, scale) <= maxWidth {
+			return candidate
+		}
+	}
+	return ""
+}
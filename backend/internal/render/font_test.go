@@ -0,0 +1,60 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestDrawableName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Alice", "Alice"},
+		{"José Müller", "Jose Muller"},
+		{"张三", fallbackName},
+		{"Li 李", "Li"},
+		{"  ", fallbackName},
+		{"...", fallbackName},
+	}
+
+	for _, tt := range tests {
+		if got := drawableName(tt.name); got != tt.want {
+			t.Errorf("drawableName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if strings.ContainsRune(drawableName(tt.name), fallbackGlyph) {
+			t.Errorf("drawableName(%q) contains the fallback glyph", tt.name)
+		}
+	}
+}
+
+func TestNameFont(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goregular.ttf")
+	if err := os.WriteFile(path, goregular.TTF, 0o644); err != nil {
+		t.Fatalf("writing font: %v", err)
+	}
+
+	nameFont, err := LoadNameFont(path)
+	if err != nil {
+		t.Fatalf("LoadNameFont: %v", err)
+	}
+
+	if !nameFont.covers("José Müller") {
+		t.Errorf("font does not cover a Latin name")
+	}
+	if nameFont.covers("张三") {
+		t.Errorf("font covers a CJK name it has no glyphs for")
+	}
+
+	// Names the font does not cover fall back to the bitmap font
+	DrawCard(Card{PlayerName: "张三", NameFont: nameFont})
+	DrawCard(Card{PlayerName: strings.Repeat("José ", 40), NameFont: nameFont})
+
+	if _, err := LoadNameFont(filepath.Join(t.TempDir(), "missing.ttf")); err == nil {
+		t.Errorf("LoadNameFont of a missing file succeeded")
+	}
+}
@@ -0,0 +1,505 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"game2048/internal/cache"
+	"game2048/internal/database"
+	"game2048/internal/events"
+	"game2048/internal/game"
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// gameSessionTTL is how long an active game is kept in the cache after its last action
+const gameSessionTTL = time.Hour
+
+// gameLockStripes is the number of locks serializing game actions, users are spread over them by ID
+const gameLockStripes = 64
+
+var (
+	ErrInvalidDirection = errors.New("invalid direction")
+	ErrNoActiveGame     = errors.New("no active game found")
+	ErrGameNotFound     = errors.New("game not found")
+	ErrGameFinished     = errors.New("game is already finished")
+	ErrInvalidMove      = errors.New("invalid move - no tiles moved")
+	ErrRoundEnded       = errors.New("tournament round has ended")
+)
+
+// GameService implements game actions independently of the transport.
+// It is used by the WebSocket hub, the REST handlers and the background jobs.
+// Methods give up when their context is cancelled before the action is applied.
+// Applied actions are published on the event bus for side effects such as leaderboards and achievements.
+type GameService struct {
+	engine *game.Engine
+	db     database.Database
+	cache  cache.Cache
+	bus    *events.Bus
+
+	// Serializes actions of the same user, who may play over WebSocket and REST at once.
+	// Each stripe is a one-slot semaphore so that waiting for it can be cancelled.
+	locks [gameLockStripes]chan struct{}
+}
+
+// NewGameService creates a new game service, the cache is optional
+func NewGameService(engine *game.Engine, db database.Database, redisCache cache.Cache, bus *events.Bus) *GameService {
+	s := &GameService{
+		engine: engine,
+		db:     db,
+		cache:  redisCache,
+		bus:    bus,
+	}
+	for i := range s.locks {
+		s.locks[i] = make(chan struct{}, 1)
+	}
+	return s
+}
+
+// NewGame starts a new game for the user, replacing the current one
+func (s *GameService) NewGame(ctx context.Context, userID string) (*models.GameState, error) {
+	return s.newGame(ctx, userID, models.GameModeClassic, 0, nil)
+}
+
+// NewSeededGame starts a new game of the given mode whose tiles only depend on the seed, replacing the current one.
+// The prepare function, if any, runs while the user's game actions are locked, before the game is stored.
+// Returning an error from it aborts the new game and keeps the current one.
+func (s *GameService) NewSeededGame(ctx context.Context, userID, mode string, seed int64, prepare func(*models.GameState) error) (*models.GameState, error) {
+	return s.newGame(ctx, userID, mode, seed, prepare)
+}
+
+// newGame starts a new game, spawning tiles from the seed if it is not 0
+func (s *GameService) newGame(ctx context.Context, userID, mode string, seed int64, prepare func(*models.GameState) error) (*models.GameState, error) {
+	unlock, err := s.lock(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	now := time.Now()
+	gameState := &models.GameState{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Score:     0,
+		GameOver:  false,
+		Victory:   false,
+		Mode:      mode,
+		Seed:      seed,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if seed != 0 {
+		gameState.Board = s.engine.NewSeededGame(seed)
+	} else {
+		gameState.Board = s.engine.NewGame()
+	}
+
+	if prepare != nil {
+		if err := prepare(gameState); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep a replaced unfinished game in the history, it only lived in the cache so far
+	if s.cache != nil {
+		if previous, err := s.cache.GetGameSession(userID); err == nil && previous != nil &&
+			!previous.GameOver && !previous.Victory && previous.Score > 0 {
+			s.saveGame(previous)
+		}
+	}
+
+	// Active games live in the cache, the database only keeps them when there is no cache
+	if s.cache != nil {
+		if err := s.cache.SetGameSession(userID, gameState, gameSessionTTL); err != nil {
+			log.Printf("Failed to cache new game session: %v", err)
+			return nil, err
+		}
+	} else {
+		if err := s.db.CreateGame(gameState); err != nil {
+			log.Printf("Failed to create new game: %v", err)
+			return nil, err
+		}
+	}
+
+	// Published while locked, so that the events of a user are queued in order
+	s.bus.Publish(events.GameStarted{UserID: userID, Game: *gameState, At: now})
+
+	return gameState, nil
+}
+
+// CurrentGame returns the user's current game, or nil if the user has none
+func (s *GameService) CurrentGame(ctx context.Context, userID string) (*models.GameState, error) {
+	unlock, err := s.lock(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return s.currentGame(ctx, userID)
+}
+
+// GetGame returns one of the user's games, either the current one or a game stored in the database
+func (s *GameService) GetGame(ctx context.Context, userID string, gameID uuid.UUID) (*models.GameState, error) {
+	current, err := s.CurrentGame(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.ID == gameID {
+		return current, nil
+	}
+
+	gameState, err := s.db.GetGame(gameID.String(), userID)
+	if err != nil {
+		return nil, ErrGameNotFound
+	}
+	return gameState, nil
+}
+
+// Move applies a move to the user's current game and returns the updated state.
+// A non-nil game ID must match the current game, uuid.Nil moves whichever game is current.
+func (s *GameService) Move(ctx context.Context, userID string, gameID uuid.UUID, direction models.Direction) (*models.GameState, error) {
+	if direction != models.DirectionUp &&
+		direction != models.DirectionDown &&
+		direction != models.DirectionLeft &&
+		direction != models.DirectionRight {
+		return nil, ErrInvalidDirection
+	}
+
+	unlock, err := s.lock(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	gameState, err := s.currentGame(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if gameState == nil {
+		return nil, ErrNoActiveGame
+	}
+
+	if gameID != uuid.Nil && gameState.ID != gameID {
+		// Finished games are no longer current
+		if _, err := s.db.GetGame(gameID.String(), userID); err == nil {
+			return nil, ErrGameFinished
+		}
+		return nil, ErrGameNotFound
+	}
+
+	// Check if game is already over
+	if gameState.GameOver || gameState.Victory {
+		return nil, ErrGameFinished
+	}
+
+	// Tournament attempts are played until their round ends, even before the tournament job ends them
+	if gameState.Mode == models.GameModeTournament {
+		round, err := s.db.GetTournamentAttemptRound(gameState.ID.String())
+		if err != nil {
+			return nil, err
+		}
+		if round != nil && !time.Now().Before(round.EndAt) {
+			return nil, ErrRoundEnded
+		}
+	}
+
+	// Last point where the move can be abandoned, from here on it is applied and saved
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Execute move, seeded games spawn the same tiles for everyone making the same moves
+	var newBoard models.Board
+	var scoreGained int
+	var moved bool
+	if gameState.Seed != 0 {
+		newBoard, scoreGained, moved = s.engine.MoveSeeded(gameState.Board, direction, gameState.Seed)
+	} else {
+		newBoard, scoreGained, moved = s.engine.Move(gameState.Board, direction)
+	}
+	if !moved {
+		return nil, ErrInvalidMove
+	}
+
+	// Update game state
+	gameState.Board = newBoard
+	gameState.Score += scoreGained
+
+	if s.engine.IsVictory(gameState.Board) {
+		gameState.Victory = true
+	}
+
+	if s.engine.IsGameOver(gameState.Board) {
+		gameState.GameOver = true
+	}
+
+	finished := gameState.GameOver || gameState.Victory
+
+	if s.cache != nil {
+		if err := s.cache.SetGameSession(userID, gameState, gameSessionTTL); err != nil {
+			log.Printf("Failed to cache game session: %v", err)
+		}
+	}
+
+	// Without a cache every move is saved, otherwise only finished games are (for leaderboard purposes)
+	if finished || s.cache == nil {
+		s.saveGame(gameState)
+	}
+
+	now := time.Now()
+	s.bus.Publish(events.MoveApplied{
+		UserID:      userID,
+		Game:        *gameState,
+		Direction:   direction,
+		ScoreGained: scoreGained,
+		At:          now,
+	})
+
+	if finished {
+		log.Printf("Game finished for user %s with score %d", gameState.UserID, gameState.Score)
+		s.bus.Publish(events.GameFinished{UserID: userID, Game: *gameState, At: now})
+	}
+
+	return gameState, nil
+}
+
+// EndGame ends one of the user's unfinished games as if no moves were left, keeping its score.
+// It is used when a game runs out of time, such as a tournament attempt at the end of its round.
+// Ending a finished game is a no-op.
+func (s *GameService) EndGame(ctx context.Context, userID string, gameID uuid.UUID) error {
+	unlock, err := s.lock(ctx, userID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := s.currentGame(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	gameState := current
+	if gameState == nil || gameState.ID != gameID {
+		// Replaced games may still be stored unfinished
+		gameState, err = s.db.GetGame(gameID.String(), userID)
+		if err != nil {
+			return ErrGameNotFound
+		}
+	}
+
+	if gameState.GameOver || gameState.Victory {
+		return nil
+	}
+
+	gameState.GameOver = true
+	if s.cache != nil && current != nil && current.ID == gameID {
+		if err := s.cache.SetGameSession(userID, gameState, gameSessionTTL); err != nil {
+			log.Printf("Failed to cache game session: %v", err)
+		}
+	}
+	s.saveGame(gameState)
+
+	log.Printf("Game %s of user %s ended with score %d", gameState.ID, userID, gameState.Score)
+	s.bus.Publish(events.GameFinished{UserID: userID, Game: *gameState, At: time.Now()})
+
+	return nil
+}
+
+// currentGame loads the user's current game from the cache, falling back to the database.
+// The database is not queried once the context is cancelled.
+func (s *GameService) currentGame(ctx context.Context, userID string) (*models.GameState, error) {
+	if s.cache != nil {
+		gameState, err := s.cache.GetGameSession(userID)
+		if err == nil && gameState != nil {
+			return gameState, nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Cache miss or no cache, try the user's active game in the database
+	gameState, err := s.db.GetUserActiveGame(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if gameState != nil && s.cache != nil {
+		if err := s.cache.SetGameSession(userID, gameState, gameSessionTTL); err != nil {
+			log.Printf("Failed to cache game session: %v", err)
+		}
+	}
+
+	return gameState, nil
+}
+
+// saveGame writes a game to the database, creating it if it was only cached so far
+func (s *GameService) saveGame(gameState *models.GameState) {
+	if err := s.db.UpdateGame(gameState); err != nil {
+		log.Printf("Failed to update game state, trying to create: %v", err)
+		if err := s.db.CreateGame(gameState); err != nil {
+			// The game state is still cached
+			log.Printf("Failed to create game state in database: %v", err)
+		}
+	}
+}
+
+// MergeGuest moves a guest's games to the account the guest signed in with and deletes the guest.
+// The guest's current game becomes the account's current game, so a game started as a guest can be finished signed in.
+func (s *GameService) MergeGuest(ctx context.Context, guestID, userID string) error {
+	unlock, err := s.lockUsers(ctx, guestID, userID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// The guest's current game may only live in the cache so far, store it so that it is moved with the others
+	var current *models.GameState
+	if s.cache != nil {
+		if current, err = s.cache.GetGameSession(guestID); err == nil && current != nil {
+			s.saveGame(current)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.db.MergeGuestUser(guestID, userID); err != nil {
+		return err
+	}
+
+	if s.cache != nil {
+		if err := s.cache.DeleteGameSession(guestID); err != nil {
+			log.Printf("Failed to delete game session of guest %s: %v", guestID, err)
+		}
+
+		if current != nil && !current.GameOver && !current.Victory {
+			// Keep a replaced unfinished game of the account in its history
+			if previous, err := s.cache.GetGameSession(userID); err == nil && previous != nil &&
+				!previous.GameOver && !previous.Victory && previous.Score > 0 {
+				s.saveGame(previous)
+			}
+
+			current.UserID = userID
+			if err := s.cache.SetGameSession(userID, current, gameSessionTTL); err != nil {
+				log.Printf("Failed to cache merged game session: %v", err)
+			}
+		}
+	}
+
+	// The guest's finished games now count towards the account's best scores
+	s.InvalidateLeaderboards(ctx, models.AllLeaderboardTypes()...)
+
+	log.Printf("Merged games of guest %s into user %s", guestID, userID)
+	return nil
+}
+
+// MergeUsers moves everything of a source account to a target account and deletes the source, for admins merging duplicate accounts.
+// The target keeps its current game, the source's current game is stored so that it moves with its other games.
+func (s *GameService) MergeUsers(ctx context.Context, sourceID, targetID string) error {
+	unlock, err := s.lockUsers(ctx, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if s.cache != nil {
+		if current, err := s.cache.GetGameSession(sourceID); err == nil && current != nil && current.Score > 0 {
+			s.saveGame(current)
+		}
+	}
+
+	if err := s.db.MergeUsers(sourceID, targetID); err != nil {
+		return err
+	}
+
+	if s.cache != nil {
+		if err := s.cache.DeleteGameSession(sourceID); err != nil {
+			log.Printf("Failed to delete game session of user %s: %v", sourceID, err)
+		}
+	}
+
+	s.InvalidateLeaderboards(ctx, models.AllLeaderboardTypes()...)
+
+	log.Printf("Merged user %s into user %s", sourceID, targetID)
+	return nil
+}
+
+// InvalidateLeaderboards drops cached leaderboards of the given types so they are refreshed on the next request.
+// It is called when a game finishes and by jobs that change leaderboards, such as closing a season.
+func (s *GameService) InvalidateLeaderboards(ctx context.Context, leaderboardTypes ...models.LeaderboardType) {
+	if s.cache == nil {
+		return
+	}
+
+	for _, lbType := range leaderboardTypes {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := s.cache.InvalidateLeaderboard(lbType); err != nil {
+			log.Printf("Failed to invalidate %s leaderboard cache: %v", lbType, err)
+		}
+	}
+}
+
+// HandleEvent drops the cached leaderboards when a game finishes, it is subscribed to the event bus
+func (s *GameService) HandleEvent(event events.Event) {
+	if _, ok := event.(events.GameFinished); ok {
+		s.InvalidateLeaderboards(context.Background(), models.AllLeaderboardTypes()...)
+	}
+}
+
+// lock serializes game actions of a user and returns the matching unlock function.
+// It fails with the context error if the context is cancelled while waiting.
+func (s *GameService) lock(ctx context.Context, userID string) (func(), error) {
+	return s.lockStripe(ctx, stripe(userID))
+}
+
+// lockUsers serializes game actions of two users at once.
+// Stripes are taken in index order so that concurrent callers cannot deadlock, a shared stripe is taken once.
+func (s *GameService) lockUsers(ctx context.Context, first, second string) (func(), error) {
+	a, b := stripe(first), stripe(second)
+	if a == b {
+		return s.lockStripe(ctx, a)
+	}
+	if a > b {
+		a, b = b, a
+	}
+
+	unlockA, err := s.lockStripe(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	unlockB, err := s.lockStripe(ctx, b)
+	if err != nil {
+		unlockA()
+		return nil, err
+	}
+	return func() { unlockB(); unlockA() }, nil
+}
+
+// lockStripe takes one of the locks, failing with the context error if the context is cancelled while waiting
+func (s *GameService) lockStripe(ctx context.Context, index uint32) (func(), error) {
+	sem := s.locks[index]
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// stripe returns the index of the lock of a user
+func stripe(userID string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return h.Sum32() % gameLockStripes
+}
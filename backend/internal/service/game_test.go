@@ -0,0 +1,691 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"game2048/internal/cache"
+	"game2048/internal/database"
+	"game2048/internal/events"
+	"game2048/internal/game"
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeDB keeps games in memory, methods the service does not use panic through the nil embedded interface
+type fakeDB struct {
+	database.Database
+
+	mutex   sync.Mutex
+	games   map[uuid.UUID]models.GameState
+	guests  map[string]bool
+	rounds  map[uuid.UUID]models.TournamentRound
+	updates int
+}
+
+func newFakeDB() *fakeDB {
+	return &fakeDB{
+		games:  make(map[uuid.UUID]models.GameState),
+		guests: make(map[string]bool),
+		rounds: make(map[uuid.UUID]models.TournamentRound),
+	}
+}
+
+func (d *fakeDB) CreateGame(gameState *models.GameState) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.games[gameState.ID] = *gameState
+	return nil
+}
+
+func (d *fakeDB) UpdateGame(gameState *models.GameState) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if _, ok := d.games[gameState.ID]; !ok {
+		return fmt.Errorf("game not found")
+	}
+	d.updates++
+	d.games[gameState.ID] = *gameState
+	return nil
+}
+
+func (d *fakeDB) GetGame(gameID, userID string) (*models.GameState, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for id, gameState := range d.games {
+		if id.String() == gameID && gameState.UserID == userID {
+			return &gameState, nil
+		}
+	}
+	return nil, fmt.Errorf("game not found")
+}
+
+func (d *fakeDB) GetUserActiveGame(userID string) (*models.GameState, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var active *models.GameState
+	for _, gameState := range d.games {
+		if gameState.UserID != userID || gameState.GameOver || gameState.Victory {
+			continue
+		}
+		if active == nil || gameState.UpdatedAt.After(active.UpdatedAt) {
+			active = &gameState
+		}
+	}
+	return active, nil
+}
+
+func (d *fakeDB) MergeGuestUser(guestID, userID string) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if !d.guests[guestID] {
+		return fmt.Errorf("guest not found")
+	}
+	for id, gameState := range d.games {
+		if gameState.UserID == guestID {
+			gameState.UserID = userID
+			d.games[id] = gameState
+		}
+	}
+	delete(d.guests, guestID)
+	return nil
+}
+
+func (d *fakeDB) GetTournamentAttemptRound(gameID string) (*models.TournamentRound, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for id, round := range d.rounds {
+		if id.String() == gameID {
+			return &round, nil
+		}
+	}
+	return nil, nil
+}
+
+// stored returns a stored game
+func (d *fakeDB) stored(id uuid.UUID) (models.GameState, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	gameState, ok := d.games[id]
+	return gameState, ok
+}
+
+// fakeCache keeps game sessions in memory, methods the service does not use panic through the nil embedded interface
+type fakeCache struct {
+	cache.Cache
+
+	mutex       sync.Mutex
+	sessions    map[string]models.GameState
+	invalidated []models.LeaderboardType
+	setErr      error
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{sessions: make(map[string]models.GameState)}
+}
+
+func (c *fakeCache) SetGameSession(userID string, gameState *models.GameState, expiration time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.setErr != nil {
+		return c.setErr
+	}
+	c.sessions[userID] = *gameState
+	return nil
+}
+
+func (c *fakeCache) GetGameSession(userID string) (*models.GameState, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	gameState, ok := c.sessions[userID]
+	if !ok {
+		return nil, fmt.Errorf("game session not found")
+	}
+	return &gameState, nil
+}
+
+func (c *fakeCache) DeleteGameSession(userID string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.sessions, userID)
+	return nil
+}
+
+func (c *fakeCache) InvalidateLeaderboard(leaderboardType models.LeaderboardType) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.invalidated = append(c.invalidated, leaderboardType)
+	return nil
+}
+
+// session returns the cached game of a user
+func (c *fakeCache) session(userID string) (models.GameState, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	gameState, ok := c.sessions[userID]
+	return gameState, ok
+}
+
+// recorder collects the events published on a bus
+type recorder struct {
+	bus    *events.Bus
+	mutex  sync.Mutex
+	events []events.Event
+}
+
+func newRecorder() *recorder {
+	r := &recorder{bus: events.NewBus()}
+	r.bus.Subscribe("recorder", 0, func(event events.Event) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		r.events = append(r.events, event)
+	})
+	return r
+}
+
+// drain closes the bus and returns every event published so far
+func (r *recorder) drain(t *testing.T) []events.Event {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := r.bus.Close(ctx); err != nil {
+		t.Fatalf("closing event bus: %v", err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.events
+}
+
+// newTestService creates a service on fakes, the cache is left out when withCache is false
+func newTestService(withCache bool) (*GameService, *fakeDB, *fakeCache, *recorder) {
+	db := newFakeDB()
+	rec := newRecorder()
+	if !withCache {
+		return NewGameService(game.NewEngine(), db, nil, rec.bus), db, nil, rec
+	}
+	c := newFakeCache()
+	return NewGameService(game.NewEngine(), db, c, rec.bus), db, c, rec
+}
+
+// boardWith returns a board whose first row holds the given tiles
+func boardWith(tiles ...int) models.Board {
+	var board models.Board
+	copy(board[0][:], tiles)
+	return board
+}
+
+func TestNewGameIsCached(t *testing.T) {
+	s, db, c, rec := newTestService(true)
+
+	gameState, err := s.NewGame(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("NewGame: %v", err)
+	}
+	if gameState.UserID != "user" || gameState.Mode != models.GameModeClassic || gameState.Seed != 0 {
+		t.Fatalf("unexpected game %+v", gameState)
+	}
+
+	if cached, ok := c.session("user"); !ok || cached.ID != gameState.ID {
+		t.Fatalf("new game is not the cached session")
+	}
+	if _, ok := db.stored(gameState.ID); ok {
+		t.Fatalf("new game was stored although there is a cache")
+	}
+
+	published := rec.drain(t)
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1", len(published))
+	}
+	if started, ok := published[0].(events.GameStarted); !ok || started.Game.ID != gameState.ID {
+		t.Fatalf("published %#v, want GameStarted", published[0])
+	}
+}
+
+func TestNewGameWithoutCacheIsStored(t *testing.T) {
+	s, db, _, _ := newTestService(false)
+
+	gameState, err := s.NewGame(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("NewGame: %v", err)
+	}
+	if _, ok := db.stored(gameState.ID); !ok {
+		t.Fatalf("new game was not stored")
+	}
+}
+
+func TestNewGameStoresReplacedUnfinishedGame(t *testing.T) {
+	s, db, c, _ := newTestService(true)
+
+	previous := &models.GameState{ID: uuid.New(), UserID: "user", Score: 20, Board: boardWith(2, 4)}
+	c.SetGameSession("user", previous, time.Hour)
+
+	if _, err := s.NewGame(context.Background(), "user"); err != nil {
+		t.Fatalf("NewGame: %v", err)
+	}
+	if stored, ok := db.stored(previous.ID); !ok || stored.Score != 20 {
+		t.Fatalf("replaced game was not kept in the history")
+	}
+}
+
+func TestNewGameFailsWhenNotCached(t *testing.T) {
+	s, _, c, rec := newTestService(true)
+
+	c.setErr = errors.New("cache down")
+	if _, err := s.NewGame(context.Background(), "user"); err == nil {
+		t.Fatalf("NewGame succeeded although the game could not be cached")
+	}
+	if published := rec.drain(t); len(published) != 0 {
+		t.Fatalf("published %d events for a game that was not started", len(published))
+	}
+}
+
+func TestNewSeededGame(t *testing.T) {
+	s, _, _, _ := newTestService(true)
+
+	first, err := s.NewSeededGame(context.Background(), "first", models.GameModeClassic, 42, nil)
+	if err != nil {
+		t.Fatalf("NewSeededGame: %v", err)
+	}
+	second, err := s.NewSeededGame(context.Background(), "second", models.GameModeClassic, 42, nil)
+	if err != nil {
+		t.Fatalf("NewSeededGame: %v", err)
+	}
+
+	if first.Seed != 42 || first.Board != second.Board {
+		t.Fatalf("games with the same seed differ: %v and %v", first.Board, second.Board)
+	}
+}
+
+func TestNewSeededGamePrepareAborts(t *testing.T) {
+	s, db, c, rec := newTestService(true)
+
+	current, err := s.NewGame(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("NewGame: %v", err)
+	}
+
+	errAbort := errors.New("no attempts left")
+	var prepared *models.GameState
+	_, err = s.NewSeededGame(context.Background(), "user", models.GameModeClassic, 7, func(gameState *models.GameState) error {
+		prepared = gameState
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("NewSeededGame returned %v, want the prepare error", err)
+	}
+
+	if prepared == nil || prepared.Seed != 7 {
+		t.Fatalf("prepare did not see the new game")
+	}
+	if cached, _ := c.session("user"); cached.ID != current.ID {
+		t.Fatalf("aborted game replaced the current one")
+	}
+	if _, ok := db.stored(prepared.ID); ok {
+		t.Fatalf("aborted game was stored")
+	}
+	if published := rec.drain(t); len(published) != 1 {
+		t.Fatalf("published %d events, want only the first GameStarted", len(published))
+	}
+}
+
+func TestMoveRejectsInvalidDirection(t *testing.T) {
+	s, _, _, _ := newTestService(true)
+
+	if _, err := s.Move(context.Background(), "user", uuid.Nil, models.Direction("sideways")); !errors.Is(err, ErrInvalidDirection) {
+		t.Fatalf("Move returned %v, want ErrInvalidDirection", err)
+	}
+}
+
+func TestMoveWithoutGame(t *testing.T) {
+	s, _, _, _ := newTestService(true)
+
+	if _, err := s.Move(context.Background(), "user", uuid.Nil, models.DirectionLeft); !errors.Is(err, ErrNoActiveGame) {
+		t.Fatalf("Move returned %v, want ErrNoActiveGame", err)
+	}
+}
+
+func TestMoveInvalidMove(t *testing.T) {
+	s, db, c, rec := newTestService(true)
+
+	gameState := &models.GameState{ID: uuid.New(), UserID: "user", Board: boardWith(2)}
+	c.SetGameSession("user", gameState, time.Hour)
+
+	if _, err := s.Move(context.Background(), "user", gameState.ID, models.DirectionLeft); !errors.Is(err, ErrInvalidMove) {
+		t.Fatalf("Move returned %v, want ErrInvalidMove", err)
+	}
+	if _, ok := db.stored(gameState.ID); ok {
+		t.Fatalf("game was stored after an invalid move")
+	}
+	if published := rec.drain(t); len(published) != 0 {
+		t.Fatalf("published %d events after an invalid move", len(published))
+	}
+}
+
+func TestMoveRejectsTournamentAttemptAfterRoundEnd(t *testing.T) {
+	s, db, c, rec := newTestService(true)
+
+	ended := &models.GameState{ID: uuid.New(), UserID: "user", Mode: models.GameModeTournament, Board: boardWith(2, 2)}
+	db.rounds[ended.ID] = models.TournamentRound{Number: 1, EndAt: time.Now().Add(-time.Second)}
+	c.SetGameSession("user", ended, time.Hour)
+
+	if _, err := s.Move(context.Background(), "user", ended.ID, models.DirectionLeft); !errors.Is(err, ErrRoundEnded) {
+		t.Fatalf("Move returned %v, want ErrRoundEnded", err)
+	}
+	if cached, _ := c.session("user"); cached.Score != 0 {
+		t.Fatalf("move was applied after the round ended")
+	}
+	if published := rec.drain(t); len(published) != 0 {
+		t.Fatalf("published %d events after the round ended", len(published))
+	}
+
+	running := &models.GameState{ID: uuid.New(), UserID: "other", Mode: models.GameModeTournament, Board: boardWith(2, 2)}
+	db.rounds[running.ID] = models.TournamentRound{Number: 1, EndAt: time.Now().Add(time.Hour)}
+	c.SetGameSession("other", running, time.Hour)
+
+	if _, err := s.Move(context.Background(), "other", running.ID, models.DirectionLeft); err != nil {
+		t.Fatalf("Move during the round: %v", err)
+	}
+}
+
+func TestMoveKeepsUnfinishedGameInCache(t *testing.T) {
+	s, db, c, rec := newTestService(true)
+
+	gameState := &models.GameState{ID: uuid.New(), UserID: "user", Board: boardWith(2, 2)}
+	c.SetGameSession("user", gameState, time.Hour)
+
+	moved, err := s.Move(context.Background(), "user", gameState.ID, models.DirectionLeft)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if moved.Score != 4 || moved.Board[0][0] != 4 {
+		t.Fatalf("unexpected game after move: score %d, board %v", moved.Score, moved.Board)
+	}
+
+	if cached, _ := c.session("user"); cached.Score != 4 {
+		t.Fatalf("cached game was not updated")
+	}
+	if _, ok := db.stored(gameState.ID); ok {
+		t.Fatalf("unfinished game was stored although there is a cache")
+	}
+
+	published := rec.drain(t)
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1", len(published))
+	}
+	if applied, ok := published[0].(events.MoveApplied); !ok || applied.ScoreGained != 4 {
+		t.Fatalf("published %#v, want MoveApplied", published[0])
+	}
+}
+
+func TestMoveWithoutCachePersistsEveryMove(t *testing.T) {
+	s, db, _, _ := newTestService(false)
+
+	gameState, err := s.NewGame(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("NewGame: %v", err)
+	}
+
+	// One of the directions moves any new board
+	var moved *models.GameState
+	for _, direction := range []models.Direction{models.DirectionLeft, models.DirectionRight, models.DirectionUp, models.DirectionDown} {
+		if moved, err = s.Move(context.Background(), "user", gameState.ID, direction); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+
+	stored, _ := db.stored(gameState.ID)
+	if stored.Board != moved.Board || db.updates != 1 {
+		t.Fatalf("move was not persisted")
+	}
+}
+
+func TestMoveFinishesGame(t *testing.T) {
+	s, db, c, rec := newTestService(true)
+
+	gameState := &models.GameState{ID: uuid.New(), UserID: "user", Score: 100, Board: boardWith(8192, 8192)}
+	c.SetGameSession("user", gameState, time.Hour)
+
+	finished, err := s.Move(context.Background(), "user", gameState.ID, models.DirectionLeft)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if !finished.Victory {
+		t.Fatalf("game with the victory tile is not won")
+	}
+
+	stored, ok := db.stored(gameState.ID)
+	if !ok || !stored.Victory || stored.Score != 100+models.VictoryTile {
+		t.Fatalf("finished game was not stored: %+v", stored)
+	}
+
+	published := rec.drain(t)
+	if len(published) != 2 {
+		t.Fatalf("published %d events, want 2", len(published))
+	}
+	if _, ok := published[0].(events.MoveApplied); !ok {
+		t.Fatalf("published %#v first, want MoveApplied", published[0])
+	}
+	if done, ok := published[1].(events.GameFinished); !ok || done.Game.ID != gameState.ID || !done.Game.Victory {
+		t.Fatalf("published %#v second, want GameFinished", published[1])
+	}
+
+	if _, err := s.Move(context.Background(), "user", gameState.ID, models.DirectionRight); !errors.Is(err, ErrGameFinished) {
+		t.Fatalf("Move on a finished game returned %v, want ErrGameFinished", err)
+	}
+}
+
+func TestMoveOtherGame(t *testing.T) {
+	s, db, c, _ := newTestService(true)
+
+	current := &models.GameState{ID: uuid.New(), UserID: "user", Board: boardWith(2, 2)}
+	c.SetGameSession("user", current, time.Hour)
+	finished := &models.GameState{ID: uuid.New(), UserID: "user", GameOver: true}
+	db.CreateGame(finished)
+
+	if _, err := s.Move(context.Background(), "user", finished.ID, models.DirectionLeft); !errors.Is(err, ErrGameFinished) {
+		t.Fatalf("Move on a stored game returned %v, want ErrGameFinished", err)
+	}
+	if _, err := s.Move(context.Background(), "user", uuid.New(), models.DirectionLeft); !errors.Is(err, ErrGameNotFound) {
+		t.Fatalf("Move on an unknown game returned %v, want ErrGameNotFound", err)
+	}
+}
+
+func TestCurrentGameFallsBackToDatabase(t *testing.T) {
+	s, db, c, _ := newTestService(true)
+
+	active := &models.GameState{ID: uuid.New(), UserID: "user", Score: 8, Board: boardWith(8), UpdatedAt: time.Now()}
+	db.CreateGame(active)
+
+	current, err := s.CurrentGame(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("CurrentGame: %v", err)
+	}
+	if current == nil || current.ID != active.ID {
+		t.Fatalf("CurrentGame returned %+v, want the stored active game", current)
+	}
+	if cached, ok := c.session("user"); !ok || cached.ID != active.ID {
+		t.Fatalf("game loaded from the database was not cached")
+	}
+}
+
+func TestCurrentGameNone(t *testing.T) {
+	s, _, _, _ := newTestService(true)
+
+	current, err := s.CurrentGame(context.Background(), "user")
+	if err != nil || current != nil {
+		t.Fatalf("CurrentGame returned %+v, %v, want no game", current, err)
+	}
+}
+
+func TestEndGame(t *testing.T) {
+	s, db, c, rec := newTestService(true)
+
+	gameState := &models.GameState{ID: uuid.New(), UserID: "user", Score: 64, Board: boardWith(32, 32)}
+	c.SetGameSession("user", gameState, time.Hour)
+
+	if err := s.EndGame(context.Background(), "user", gameState.ID); err != nil {
+		t.Fatalf("EndGame: %v", err)
+	}
+
+	if cached, _ := c.session("user"); !cached.GameOver {
+		t.Fatalf("cached game was not ended")
+	}
+	if stored, ok := db.stored(gameState.ID); !ok || !stored.GameOver || stored.Score != 64 {
+		t.Fatalf("ended game was not stored with its score")
+	}
+
+	// Ending it again is a no-op
+	if err := s.EndGame(context.Background(), "user", gameState.ID); err != nil {
+		t.Fatalf("EndGame on a finished game: %v", err)
+	}
+
+	published := rec.drain(t)
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1", len(published))
+	}
+	if _, ok := published[0].(events.GameFinished); !ok {
+		t.Fatalf("published %#v, want GameFinished", published[0])
+	}
+}
+
+func TestEndGameReplacedGame(t *testing.T) {
+	s, db, c, _ := newTestService(true)
+
+	replaced := &models.GameState{ID: uuid.New(), UserID: "user", Score: 16}
+	db.CreateGame(replaced)
+	current := &models.GameState{ID: uuid.New(), UserID: "user", Board: boardWith(2)}
+	c.SetGameSession("user", current, time.Hour)
+
+	if err := s.EndGame(context.Background(), "user", replaced.ID); err != nil {
+		t.Fatalf("EndGame: %v", err)
+	}
+	if stored, _ := db.stored(replaced.ID); !stored.GameOver {
+		t.Fatalf("replaced game was not ended")
+	}
+	if cached, _ := c.session("user"); cached.ID != current.ID || cached.GameOver {
+		t.Fatalf("current game was changed")
+	}
+
+	if err := s.EndGame(context.Background(), "user", uuid.New()); !errors.Is(err, ErrGameNotFound) {
+		t.Fatalf("EndGame on an unknown game returned %v, want ErrGameNotFound", err)
+	}
+}
+
+func TestMergeGuest(t *testing.T) {
+	s, db, c, _ := newTestService(true)
+
+	db.guests["guest"] = true
+	finished := &models.GameState{ID: uuid.New(), UserID: "guest", Score: 512, GameOver: true}
+	db.CreateGame(finished)
+	current := &models.GameState{ID: uuid.New(), UserID: "guest", Score: 32, Board: boardWith(16, 16)}
+	c.SetGameSession("guest", current, time.Hour)
+	previous := &models.GameState{ID: uuid.New(), UserID: "user", Score: 8, Board: boardWith(4, 4)}
+	c.SetGameSession("user", previous, time.Hour)
+
+	if err := s.MergeGuest(context.Background(), "guest", "user"); err != nil {
+		t.Fatalf("MergeGuest: %v", err)
+	}
+
+	if stored, _ := db.stored(finished.ID); stored.UserID != "user" {
+		t.Fatalf("finished guest game was not moved")
+	}
+	if stored, ok := db.stored(current.ID); !ok || stored.UserID != "user" {
+		t.Fatalf("current guest game was not stored and moved")
+	}
+	if stored, ok := db.stored(previous.ID); !ok || stored.Score != 8 {
+		t.Fatalf("replaced game of the account was not kept in its history")
+	}
+
+	if _, ok := c.session("guest"); ok {
+		t.Fatalf("guest session was not deleted")
+	}
+	if cached, _ := c.session("user"); cached.ID != current.ID || cached.UserID != "user" {
+		t.Fatalf("guest game did not become the account's current game")
+	}
+	if len(c.invalidated) != len(models.AllLeaderboardTypes()) {
+		t.Fatalf("invalidated %d leaderboards, want all", len(c.invalidated))
+	}
+}
+
+func TestMergeGuestUnknownGuest(t *testing.T) {
+	s, _, c, _ := newTestService(true)
+
+	if err := s.MergeGuest(context.Background(), "guest", "user"); err == nil {
+		t.Fatalf("MergeGuest of an unknown guest succeeded")
+	}
+	if len(c.invalidated) != 0 {
+		t.Fatalf("leaderboards were invalidated after a failed merge")
+	}
+}
+
+func TestActionsGiveUpWhenCancelledWhileLocked(t *testing.T) {
+	s, _, c, _ := newTestService(true)
+
+	gameState := &models.GameState{ID: uuid.New(), UserID: "user", Board: boardWith(2, 2)}
+	c.SetGameSession("user", gameState, time.Hour)
+
+	unlock, err := s.lock(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := s.Move(ctx, "user", gameState.ID, models.DirectionLeft); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Move returned %v, want the context error", err)
+	}
+	if _, err := s.NewGame(ctx, "user"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("NewGame returned %v, want the context error", err)
+	}
+	if err := s.MergeGuest(ctx, "guest", "user"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("MergeGuest returned %v, want the context error", err)
+	}
+
+	if cached, _ := c.session("user"); cached.Score != 0 || cached.ID != gameState.ID {
+		t.Fatalf("cancelled actions changed the game")
+	}
+}
+
+func TestCurrentGameSkipsDatabaseWhenCancelled(t *testing.T) {
+	s, db, _, _ := newTestService(true)
+
+	db.CreateGame(&models.GameState{ID: uuid.New(), UserID: "user", Board: boardWith(2)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Take the lock first so that the cancelled context only matters for the database fallback
+	unlock, err := s.lock(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer unlock()
+
+	if _, err := s.currentGame(ctx, "user"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("currentGame returned %v, want the context error", err)
+	}
+}
@@ -0,0 +1,441 @@
+package tournaments
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"game2048/internal/database"
+	"game2048/internal/service"
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTournamentNotFound = errors.New("tournament not found")
+	ErrRegistrationClosed = errors.New("registration is closed")
+	ErrNotRegistered      = errors.New("not registered for the tournament")
+	ErrEliminated         = errors.New("eliminated from the tournament")
+	ErrNoActiveRound      = errors.New("no round is being played")
+	ErrNoAttemptsLeft     = errors.New("no attempts left in this round")
+	ErrAlreadyStarted     = errors.New("tournament has already started")
+)
+
+// WebSocket message types sent to participants
+const (
+	MessageRoundStarted = "tournament_round_started"
+	MessageRoundEnded   = "tournament_round_ended"
+)
+
+// Notifier delivers round notifications to participants
+type Notifier interface {
+	NotifyTournamentRound(userID, messageType string, message models.TournamentRoundMessage)
+}
+
+// Service implements tournament registration, attempts and the advancement of rounds.
+// Attempts are regular games of the tournament mode, played with the round's seed through the game service.
+type Service struct {
+	db       database.Database
+	games    *service.GameService
+	notifier Notifier
+}
+
+// NewService creates a new tournament service, the notifier is optional
+func NewService(db database.Database, games *service.GameService, notifier Notifier) *Service {
+	return &Service{
+		db:       db,
+		games:    games,
+		notifier: notifier,
+	}
+}
+
+// Create creates a tournament with a random seed for every round.
+// Rounds are numbered in the order they are given.
+func (s *Service) Create(req *models.TournamentRequest) (*models.Tournament, error) {
+	tournament := &models.Tournament{
+		Name:              strings.TrimSpace(req.Name),
+		Description:       strings.TrimSpace(req.Description),
+		RegistrationStart: req.RegistrationStart,
+		RegistrationEnd:   req.RegistrationEnd,
+		MaxAttempts:       req.MaxAttempts,
+		Status:            models.TournamentUpcoming,
+	}
+
+	for i, roundReq := range req.Rounds {
+		seed, err := newSeed()
+		if err != nil {
+			return nil, err
+		}
+		tournament.Rounds = append(tournament.Rounds, models.TournamentRound{
+			Number:  i + 1,
+			StartAt: roundReq.StartAt,
+			EndAt:   roundReq.EndAt,
+			Seed:    seed,
+			Advance: roundReq.Advance,
+			Status:  models.TournamentRoundScheduled,
+		})
+	}
+
+	if err := s.db.CreateTournament(tournament); err != nil {
+		return nil, err
+	}
+
+	return tournament, nil
+}
+
+// Delete deletes a tournament that has not started yet
+func (s *Service) Delete(tournamentID string) error {
+	tournament, err := s.get(tournamentID)
+	if err != nil {
+		return err
+	}
+
+	if tournament.Status != models.TournamentUpcoming {
+		return ErrAlreadyStarted
+	}
+
+	return s.db.DeleteTournament(tournament.ID.String())
+}
+
+// Register registers a user for a tournament while its registration is open, registering twice is a no-op
+func (s *Service) Register(tournamentID, userID string) error {
+	tournament, err := s.get(tournamentID)
+	if err != nil {
+		return err
+	}
+
+	if !tournament.RegistrationOpen(time.Now()) {
+		return ErrRegistrationClosed
+	}
+
+	return s.db.RegisterTournamentParticipant(tournament.ID.String(), userID)
+}
+
+// Unregister withdraws a user's registration while the tournament's registration is open
+func (s *Service) Unregister(tournamentID, userID string) error {
+	tournament, err := s.get(tournamentID)
+	if err != nil {
+		return err
+	}
+
+	if !tournament.RegistrationOpen(time.Now()) {
+		return ErrRegistrationClosed
+	}
+
+	return s.db.UnregisterTournamentParticipant(tournament.ID.String(), userID)
+}
+
+// Play starts an attempt in the round being played, replacing the user's current game.
+// The attempt is played like any other game and ranked on the round once finished.
+func (s *Service) Play(ctx context.Context, tournamentID, userID string) (*models.GameState, error) {
+	tournament, err := s.get(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	round := tournament.ActiveRound()
+	if round == nil || !time.Now().Before(round.EndAt) {
+		return nil, ErrNoActiveRound
+	}
+
+	participant, err := s.db.GetTournamentParticipant(tournament.ID.String(), userID)
+	if err != nil {
+		return nil, ErrNotRegistered
+	}
+	if participant.EliminatedRound != nil {
+		return nil, ErrEliminated
+	}
+
+	// Counting and recording the attempt while the user's games are locked keeps concurrent requests within the limit
+	return s.games.NewSeededGame(ctx, userID, models.GameModeTournament, round.Seed, func(game *models.GameState) error {
+		used, err := s.db.CountTournamentAttempts(tournament.ID.String(), round.Number, userID)
+		if err != nil {
+			return err
+		}
+		if used >= tournament.MaxAttempts {
+			return ErrNoAttemptsLeft
+		}
+
+		return s.db.CreateTournamentAttempt(&models.TournamentAttempt{
+			GameID:       game.ID,
+			TournamentID: tournament.ID,
+			Round:        round.Number,
+			UserID:       userID,
+		})
+	})
+}
+
+// Status returns a user's registration, attempts and round rank in a tournament
+func (s *Service) Status(tournamentID, userID string) (*models.TournamentStatusResponse, error) {
+	tournament, err := s.get(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.TournamentStatusResponse{}
+
+	participant, err := s.db.GetTournamentParticipant(tournament.ID.String(), userID)
+	if err != nil {
+		return status, nil
+	}
+	status.Registered = true
+	status.Participant = participant
+
+	round := tournament.ActiveRound()
+	if round == nil {
+		return status, nil
+	}
+	status.Round = round
+
+	used, err := s.db.CountTournamentAttempts(tournament.ID.String(), round.Number, userID)
+	if err != nil {
+		return nil, err
+	}
+	status.AttemptsUsed = used
+	if participant.EliminatedRound == nil && used < tournament.MaxAttempts {
+		status.AttemptsRemaining = tournament.MaxAttempts - used
+	}
+
+	user, err := s.db.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	status.RoundEntry, err = s.db.GetUserRank(round.Query().ViewedBy(user), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// Standings returns a page of a round's standings, ranked by each player's best finished attempt, with the total
+func (s *Service) Standings(round *models.TournamentRound, limit, offset int) ([]models.LeaderboardEntry, int, error) {
+	query := round.Query()
+
+	entries, err := s.db.GetLeaderboard(query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := s.db.GetLeaderboardCount(query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// Advance starts and ends the rounds that are due and finishes tournaments after their final round.
+// It is run periodically by the tournament job.
+func (s *Service) Advance(ctx context.Context, now time.Time) {
+	tournaments, err := s.db.GetUnfinishedTournaments()
+	if err != nil {
+		log.Printf("Failed to get unfinished tournaments: %v", err)
+		return
+	}
+
+	for i := range tournaments {
+		if ctx.Err() != nil {
+			return
+		}
+		s.advanceTournament(ctx, &tournaments[i], now)
+	}
+}
+
+// advanceTournament moves a tournament through its due rounds in order.
+// A run that was delayed past several round boundaries catches up in one go.
+func (s *Service) advanceTournament(ctx context.Context, tournament *models.Tournament, now time.Time) {
+	for i := range tournament.Rounds {
+		round := &tournament.Rounds[i]
+
+		if round.Status == models.TournamentRoundScheduled {
+			if now.Before(round.StartAt) {
+				return
+			}
+			if err := s.startRound(tournament, round); err != nil {
+				log.Printf("Failed to start round %d of tournament %s: %v", round.Number, tournament.ID, err)
+				return
+			}
+		}
+
+		if round.Status == models.TournamentRoundActive {
+			if now.Before(round.EndAt) {
+				return
+			}
+			if err := s.endRound(ctx, tournament, round); err != nil {
+				log.Printf("Failed to end round %d of tournament %s: %v", round.Number, tournament.ID, err)
+				return
+			}
+		}
+	}
+
+	if err := s.db.UpdateTournamentStatus(tournament.ID.String(), models.TournamentFinished); err != nil {
+		log.Printf("Failed to finish tournament %s: %v", tournament.ID, err)
+		return
+	}
+	log.Printf("Finished tournament %s (%s)", tournament.Name, tournament.ID)
+}
+
+// startRound opens a round for attempts and notifies the players still in the tournament
+func (s *Service) startRound(tournament *models.Tournament, round *models.TournamentRound) error {
+	if tournament.Status == models.TournamentUpcoming {
+		if err := s.db.UpdateTournamentStatus(tournament.ID.String(), models.TournamentRunning); err != nil {
+			return err
+		}
+		tournament.Status = models.TournamentRunning
+	}
+
+	if err := s.db.UpdateTournamentRoundStatus(tournament.ID.String(), round.Number, models.TournamentRoundActive); err != nil {
+		return err
+	}
+	round.Status = models.TournamentRoundActive
+	log.Printf("Started round %d of tournament %s (%s)", round.Number, tournament.Name, tournament.ID)
+
+	participants, err := s.db.GetTournamentParticipants(tournament.ID.String())
+	if err != nil {
+		log.Printf("Failed to get participants of tournament %s: %v", tournament.ID, err)
+		return nil
+	}
+
+	for _, participant := range participants {
+		if participant.EliminatedRound == nil {
+			s.notify(participant.UserID, MessageRoundStarted, roundMessage(tournament, round))
+		}
+	}
+
+	return nil
+}
+
+// endRound ends the attempts still being played, ranks the round and eliminates the players who did not advance.
+// After the final round the players are given their final ranks.
+func (s *Service) endRound(ctx context.Context, tournament *models.Tournament, round *models.TournamentRound) error {
+	tournamentID := tournament.ID.String()
+
+	// Attempts still being played count with the score they reached
+	attempts, err := s.db.GetUnfinishedTournamentAttempts(tournamentID, round.Number)
+	if err != nil {
+		return err
+	}
+	for _, attempt := range attempts {
+		if err := s.games.EndGame(ctx, attempt.UserID, attempt.GameID); err != nil && err != service.ErrGameNotFound {
+			log.Printf("Failed to end tournament attempt %s: %v", attempt.GameID, err)
+		}
+	}
+
+	// Players still in the tournament before the round is ranked, they are told how it went
+	participants, err := s.db.GetTournamentParticipants(tournamentID)
+	if err != nil {
+		return err
+	}
+
+	total, err := s.db.GetLeaderboardCount(round.Query())
+	if err != nil {
+		return err
+	}
+	standings, err := s.db.GetLeaderboard(round.Query(), total, 0)
+	if err != nil {
+		return err
+	}
+
+	ranks := make(map[string]int, len(standings))
+	for _, entry := range standings {
+		ranks[entry.UserID] = entry.Rank
+	}
+
+	// Only players who finished an attempt can advance, and in the final round they are the ones ranked
+	advancing := make([]string, 0, len(standings))
+	for _, entry := range standings {
+		if !round.IsFinal() && len(advancing) >= round.Advance {
+			break
+		}
+		advancing = append(advancing, entry.UserID)
+	}
+
+	if err := s.db.EliminateTournamentParticipants(tournamentID, round.Number, advancing); err != nil {
+		return err
+	}
+	if round.IsFinal() {
+		if err := s.db.SetTournamentFinalRanks(tournamentID, ranks); err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.UpdateTournamentRoundStatus(tournamentID, round.Number, models.TournamentRoundFinished); err != nil {
+		return err
+	}
+	round.Status = models.TournamentRoundFinished
+	log.Printf("Ended round %d of tournament %s (%s) with %d ranked players", round.Number, tournament.Name, tournament.ID, len(standings))
+
+	advanced := make(map[string]bool, len(advancing))
+	for _, userID := range advancing {
+		advanced[userID] = true
+	}
+
+	for _, participant := range participants {
+		if participant.EliminatedRound != nil {
+			continue
+		}
+
+		message := roundMessage(tournament, round)
+		if rank, ok := ranks[participant.UserID]; ok {
+			message.Rank = &rank
+		}
+		if !round.IsFinal() {
+			advancedToNext := advanced[participant.UserID]
+			message.Advanced = &advancedToNext
+		}
+		s.notify(participant.UserID, MessageRoundEnded, message)
+	}
+
+	return nil
+}
+
+// get loads a tournament, mapping missing tournaments to ErrTournamentNotFound
+func (s *Service) get(tournamentID string) (*models.Tournament, error) {
+	if _, err := uuid.Parse(tournamentID); err != nil {
+		return nil, ErrTournamentNotFound
+	}
+
+	tournament, err := s.db.GetTournament(tournamentID)
+	if err != nil {
+		return nil, ErrTournamentNotFound
+	}
+	return tournament, nil
+}
+
+// notify sends a round notification if a notifier is set
+func (s *Service) notify(userID, messageType string, message models.TournamentRoundMessage) {
+	if s.notifier != nil {
+		s.notifier.NotifyTournamentRound(userID, messageType, message)
+	}
+}
+
+// roundMessage returns the notification of a round without a player's result
+func roundMessage(tournament *models.Tournament, round *models.TournamentRound) models.TournamentRoundMessage {
+	return models.TournamentRoundMessage{
+		TournamentID:   tournament.ID,
+		TournamentName: tournament.Name,
+		Round:          round.Number,
+		StartAt:        round.StartAt,
+		EndAt:          round.EndAt,
+		Final:          round.IsFinal(),
+	}
+}
+
+// newSeed returns a random non-zero round seed, 0 would mean unseeded games
+func newSeed() (int64, error) {
+	var buf [8]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return 0, err
+		}
+		if seed := int64(binary.LittleEndian.Uint64(buf[:])); seed != 0 {
+			return seed, nil
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"game2048/internal/database"
+	"game2048/internal/events"
+	"game2048/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// seasonStandingsLimit is the number of final standings included in season_closed payloads
+const seasonStandingsLimit = 10
+
+// leaderLeaderboards are the leaderboards whose new #1 is announced, besides the active season
+var leaderLeaderboards = []models.LeaderboardType{
+	models.LeaderboardDaily,
+	models.LeaderboardWeekly,
+	models.LeaderboardMonthly,
+	models.LeaderboardAll,
+}
+
+// Publisher turns events on the bus into webhook deliveries.
+// It only queues deliveries, they are sent by the webhook delivery job.
+type Publisher struct {
+	db       database.Database
+	calendar models.PeriodCalendar
+}
+
+// NewPublisher creates a new webhook publisher
+func NewPublisher(db database.Database, calendar models.PeriodCalendar) *Publisher {
+	return &Publisher{
+		db:       db,
+		calendar: calendar,
+	}
+}
+
+// HandleEvent queues deliveries for the webhook events caused by a bus event.
+// It is subscribed to the event bus, which delivers events one at a time in publishing order.
+func (p *Publisher) HandleEvent(event events.Event) {
+	switch event := event.(type) {
+	case events.GameFinished:
+		p.gameFinished(event)
+
+	case events.SeasonClosed:
+		p.seasonClosed(event)
+	}
+}
+
+// gameFinished queues personal_best and leaderboard_leader events for a finished game
+func (p *Publisher) gameFinished(event events.GameFinished) {
+	// Tournament attempts are ranked on their round, not on leaderboards or personal bests
+	if event.Game.Mode == models.GameModeTournament {
+		return
+	}
+
+	webhooks, err := p.activeWebhooks()
+	if err != nil || len(webhooks) == 0 {
+		return
+	}
+
+	if subscribed(webhooks, models.WebhookPersonalBest) {
+		p.personalBest(webhooks, event)
+	}
+
+	if subscribed(webhooks, models.WebhookLeaderboardLeader) {
+		p.leaderboardLeaders(webhooks, event)
+	}
+}
+
+// personalBest queues a personal_best event if the game beat the user's previous best score.
+// A user's first finished game is not a personal best, and guests and users hidden by moderators are not announced.
+func (p *Publisher) personalBest(webhooks []models.Webhook, event events.GameFinished) {
+	user, err := p.db.GetUser(event.UserID)
+	if err == nil && (user.IsGuest || user.Hidden()) {
+		return
+	}
+
+	game := event.Game
+	previous, ok, err := p.db.GetPreviousBestScore(event.UserID, game.ID.String())
+	if err != nil {
+		log.Printf("Failed to get previous best score of user %s: %v", event.UserID, err)
+		return
+	}
+	if !ok || game.Score <= previous {
+		return
+	}
+
+	data := models.PersonalBestData{
+		UserID:       event.UserID,
+		GameID:       game.ID,
+		Score:        game.Score,
+		PreviousBest: previous,
+	}
+	if user != nil {
+		data.UserName = user.Name
+	}
+
+	p.enqueue(webhooks, models.WebhookPersonalBest, event.At, data)
+}
+
+// leaderboardLeaders queues a leaderboard_leader event for every leaderboard the game now leads
+func (p *Publisher) leaderboardLeaders(webhooks []models.Webhook, event events.GameFinished) {
+	// Games count towards the periods they were started in
+	queries := make([]models.LeaderboardQuery, 0, len(leaderLeaderboards)+1)
+	for _, lbType := range leaderLeaderboards {
+		queries = append(queries, p.calendar.Query(lbType, event.Game.CreatedAt))
+	}
+
+	season, err := p.db.GetActiveSeason(event.Game.CreatedAt)
+	if err != nil {
+		log.Printf("Failed to get active season: %v", err)
+	} else if season != nil {
+		queries = append(queries, season.Query())
+	}
+
+	for _, query := range queries {
+		top, err := p.db.GetLeaderboard(query, 2, 0)
+		if err != nil {
+			log.Printf("Failed to get %s leaderboard: %v", query.Type, err)
+			continue
+		}
+		if len(top) == 0 || top[0].GameID != event.Game.ID {
+			continue
+		}
+
+		data := models.LeaderboardLeaderData{
+			Type:     query.Type,
+			Period:   query.Period,
+			SeasonID: query.SeasonID,
+			Leader:   top[0],
+		}
+		if len(top) > 1 {
+			data.RunnerUp = &top[1]
+		}
+
+		p.enqueue(webhooks, models.WebhookLeaderboardLeader, event.At, data)
+	}
+}
+
+// seasonClosed queues a season_closed event with the top final standings
+func (p *Publisher) seasonClosed(event events.SeasonClosed) {
+	webhooks, err := p.activeWebhooks()
+	if err != nil || !subscribed(webhooks, models.WebhookSeasonClosed) {
+		return
+	}
+
+	standings, err := p.db.GetSeasonStandings(event.Season.ID.String(), seasonStandingsLimit, 0)
+	if err != nil {
+		log.Printf("Failed to get standings of season %s: %v", event.Season.ID, err)
+		return
+	}
+
+	p.enqueue(webhooks, models.WebhookSeasonClosed, event.At, models.SeasonClosedData{
+		Season:    event.Season,
+		Standings: standings,
+	})
+}
+
+// activeWebhooks returns the webhooks receiving events
+func (p *Publisher) activeWebhooks() ([]models.Webhook, error) {
+	webhooks, err := p.db.ListWebhooks()
+	if err != nil {
+		log.Printf("Failed to list webhooks: %v", err)
+		return nil, err
+	}
+
+	active := webhooks[:0]
+	for _, webhook := range webhooks {
+		if webhook.Active {
+			active = append(active, webhook)
+		}
+	}
+	return active, nil
+}
+
+// enqueue queues one delivery of the event for every webhook subscribed to it.
+// Every webhook receives the same payload, identified by a single event ID.
+func (p *Publisher) enqueue(webhooks []models.Webhook, event models.WebhookEvent, at time.Time, data interface{}) {
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        uuid.New(),
+		Event:     event,
+		CreatedAt: at,
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("Failed to encode %s webhook payload: %v", event, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event) {
+			continue
+		}
+
+		delivery := &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: at,
+		}
+		if err := p.db.CreateWebhookDelivery(delivery); err != nil {
+			log.Printf("Failed to queue %s delivery to webhook %s: %v", event, webhook.ID, err)
+		}
+	}
+}
+
+// subscribed reports whether any of the webhooks receives the event
+func subscribed(webhooks []models.Webhook, event models.WebhookEvent) bool {
+	for i := range webhooks {
+		if webhooks[i].Subscribes(event) {
+			return true
+		}
+	}
+	return false
+}
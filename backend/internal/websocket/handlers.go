@@ -2,9 +2,12 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"time"
 
+	"game2048/internal/database"
+	"game2048/internal/service"
 	"game2048/pkg/models"
 
 	"github.com/google/uuid"
@@ -25,91 +28,23 @@ func (c *Client) handleMove(data interface{}) {
 		return
 	}
 
-	// Validate direction
-	if moveRequest.Direction != models.DirectionUp &&
-		moveRequest.Direction != models.DirectionDown &&
-		moveRequest.Direction != models.DirectionLeft &&
-		moveRequest.Direction != models.DirectionRight {
-		c.sendError("Invalid direction")
-		return
-	}
-
-	// Get current game state
-	gameState, err := c.getCurrentGameState()
+	// Moves always apply to the current game, which may have been started over REST
+	gameState, err := c.hub.games.Move(c.ctx, c.userID, uuid.Nil, moveRequest.Direction)
 	if err != nil {
-		c.sendError("Failed to get game state")
-		return
-	}
-
-	if gameState == nil {
-		c.sendError("No active game found. Start a new game first.")
-		return
-	}
-
-	// Check if game is already over
-	if gameState.GameOver || gameState.Victory {
-		c.sendError("Game is already finished")
-		return
-	}
-
-	// Execute move
-	newBoard, scoreGained, moved := c.hub.gameEngine.Move(gameState.Board, moveRequest.Direction)
-	if !moved {
-		c.sendError("Invalid move - no tiles moved")
+		c.sendError(moveErrorMessage(err))
 		return
 	}
 
-	// Update game state
-	gameState.Board = newBoard
-	gameState.Score += scoreGained
-
-	// Check for victory
-	if c.hub.gameEngine.IsVictory(gameState.Board) {
-		gameState.Victory = true
-	}
-
-	// Check for game over
-	if c.hub.gameEngine.IsGameOver(gameState.Board) {
-		gameState.GameOver = true
-	}
-
-	// Save updated game state to cache
-	if c.hub.cache != nil {
-		// Cache for 1 hour
-		if err := c.hub.cache.SetGameSession(c.userID, gameState, time.Hour); err != nil {
-			log.Printf("Failed to cache game session: %v", err)
-		}
-	}
-
-	// Only save to database if game is finished (for leaderboard purposes)
-	if gameState.GameOver || gameState.Victory {
-		// Try to update first, if it fails (game not in DB), create it
-		if err := c.hub.db.UpdateGame(gameState); err != nil {
-			log.Printf("Failed to update game state, trying to create: %v", err)
-			// Game doesn't exist in database, create it
-			if err := c.hub.db.CreateGame(gameState); err != nil {
-				log.Printf("Failed to create game state in database: %v", err)
-				// Don't return error here, game state is still cached
-			} else {
-				log.Printf("Successfully created finished game in database")
-			}
-		} else {
-			log.Printf("Successfully updated finished game in database")
-		}
-	}
+	c.gameID = gameState.ID
 
 	// Send response
 	response := models.GameResponse{
+		GameID:   gameState.ID,
 		Board:    gameState.Board,
 		Score:    gameState.Score,
 		GameOver: gameState.GameOver,
 		Victory:  gameState.Victory,
-	}
-
-	if gameState.Victory {
-		response.Message = "Congratulations! You merged two 8192 tiles and won!"
-	} else if gameState.GameOver {
-		response.Message = "Game Over! No more moves available."
+		Message:  gameState.FinishMessage(),
 	}
 
 	message := models.WebSocketMessage{
@@ -118,50 +53,40 @@ func (c *Client) handleMove(data interface{}) {
 	}
 
 	c.sendMessage(message)
+}
 
-	// If game is finished, update leaderboards
-	if gameState.GameOver || gameState.Victory {
-		go c.updateLeaderboards(gameState)
+// moveErrorMessage returns the client-facing message for a move error
+func moveErrorMessage(err error) string {
+	switch err {
+	case service.ErrInvalidDirection:
+		return "Invalid direction"
+	case service.ErrNoActiveGame:
+		return "No active game found. Start a new game first."
+	case service.ErrGameFinished:
+		return "Game is already finished"
+	case service.ErrRoundEnded:
+		return "Tournament round has ended"
+	case service.ErrInvalidMove:
+		return "Invalid move - no tiles moved"
+	default:
+		return "Failed to get game state"
 	}
 }
 
 // handleNewGame handles new game requests
 func (c *Client) handleNewGame(data interface{}) {
-	// Create new game
-	board := c.hub.gameEngine.NewGame()
-	gameID := uuid.New()
-
-	gameState := &models.GameState{
-		ID:       gameID,
-		UserID:   c.userID,
-		Board:    board,
-		Score:    0,
-		GameOver: false,
-		Victory:  false,
-	}
-
-	// Save new game state to cache
-	if c.hub.cache != nil {
-		// Cache for 1 hour
-		if err := c.hub.cache.SetGameSession(c.userID, gameState, time.Hour); err != nil {
-			log.Printf("Failed to cache new game session: %v", err)
-			c.sendError("Failed to create new game")
-			return
-		}
-	} else {
-		// Fallback to database if no cache
-		if err := c.hub.db.CreateGame(gameState); err != nil {
-			log.Printf("Failed to create new game: %v", err)
-			c.sendError("Failed to create new game")
-			return
-		}
+	gameState, err := c.hub.games.NewGame(c.ctx, c.userID)
+	if err != nil {
+		c.sendError("Failed to create new game")
+		return
 	}
 
 	// Update client's game ID
-	c.gameID = gameID
+	c.gameID = gameState.ID
 
 	// Send response
 	response := models.GameResponse{
+		GameID:   gameState.ID,
 		Board:    gameState.Board,
 		Score:    gameState.Score,
 		GameOver: gameState.GameOver,
@@ -196,13 +121,90 @@ func (c *Client) handleGetLeaderboard(data interface{}) {
 	if leaderboardRequest.Type != models.LeaderboardDaily &&
 		leaderboardRequest.Type != models.LeaderboardWeekly &&
 		leaderboardRequest.Type != models.LeaderboardMonthly &&
-		leaderboardRequest.Type != models.LeaderboardAll {
+		leaderboardRequest.Type != models.LeaderboardAll &&
+		leaderboardRequest.Type != models.LeaderboardSeason {
 		c.sendError("Invalid leaderboard type")
 		return
 	}
 
-	// Get leaderboard entries
-	entries, err := c.hub.db.GetLeaderboard(leaderboardRequest.Type, 100)
+	// Apply paging defaults (limit 100, max 100)
+	limit := leaderboardRequest.Limit
+	if limit < 1 || limit > 100 {
+		limit = 100
+	}
+	offset := leaderboardRequest.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := c.hub.calendar.Query(leaderboardRequest.Type, time.Now())
+
+	// Season leaderboards use the requested season, or the active one when none is given
+	var season *models.Season
+	if leaderboardRequest.Type == models.LeaderboardSeason {
+		if leaderboardRequest.SeasonID != "" {
+			season, err = c.hub.db.GetSeason(leaderboardRequest.SeasonID)
+			if errors.Is(err, database.ErrSeasonNotFound) {
+				c.sendError("Season not found")
+				return
+			}
+			if err != nil {
+				log.Printf("Failed to get season %s: %v", leaderboardRequest.SeasonID, err)
+				c.sendError("Failed to load season")
+				return
+			}
+		} else {
+			season, err = c.hub.db.GetActiveSeason(time.Now())
+			if err != nil {
+				log.Printf("Failed to get active season: %v", err)
+				c.sendError("Failed to load season")
+				return
+			}
+			if season == nil {
+				c.sendError("No active season")
+				return
+			}
+		}
+		query = season.Query()
+	}
+
+	// Friends and group leaderboards rank only the user's friends or the members of one of their groups
+	switch leaderboardRequest.Group {
+	case "":
+	case models.LeaderboardGroupFriends:
+		query.FriendsOf = c.userID
+	default:
+		member, err := c.hub.db.IsGroupMember(leaderboardRequest.Group, c.userID)
+		if err != nil || !member {
+			c.sendError("Group not found")
+			return
+		}
+		query.GroupID = leaderboardRequest.Group
+	}
+
+	// Shadow-banned players see themselves ranked, as on their own rank
+	user, err := c.hub.db.GetUser(c.userID)
+	if err != nil {
+		log.Printf("Failed to get user %s: %v", c.userID, err)
+		c.sendError("Failed to get leaderboard")
+		return
+	}
+	query = query.ViewedBy(user)
+
+	var entries []models.LeaderboardEntry
+	var total int
+	if season != nil && season.IsClosed() && !query.IsFiltered() {
+		// Closed seasons are served from their frozen final standings
+		entries, err = c.hub.db.GetSeasonStandings(season.ID.String(), limit, offset)
+		if err == nil {
+			total, err = c.hub.db.GetSeasonStandingsCount(season.ID.String())
+		}
+	} else {
+		entries, err = c.hub.db.GetLeaderboard(query, limit, offset)
+		if err == nil {
+			total, err = c.hub.db.GetLeaderboardCount(query)
+		}
+	}
 	if err != nil {
 		log.Printf("Failed to get leaderboard: %v", err)
 		c.sendError("Failed to get leaderboard")
@@ -213,6 +215,12 @@ func (c *Client) handleGetLeaderboard(data interface{}) {
 	response := models.LeaderboardResponse{
 		Type:     leaderboardRequest.Type,
 		Rankings: entries,
+		Total:    total,
+		Offset:   offset,
+		Limit:    limit,
+		Period:   query.Period,
+		Season:   season,
+		Group:    leaderboardRequest.Group,
 	}
 
 	message := models.WebSocketMessage{
@@ -223,77 +231,11 @@ func (c *Client) handleGetLeaderboard(data interface{}) {
 	c.sendMessage(message)
 }
 
-// getCurrentGameState gets the current game state for the client
-func (c *Client) getCurrentGameState() (*models.GameState, error) {
-	// Try to get from Redis cache first
-	if c.hub.cache != nil {
-		gameState, err := c.hub.cache.GetGameSession(c.userID)
-		if err == nil && gameState != nil {
-			c.gameID = gameState.ID
-			return gameState, nil
-		}
-	}
-
-	// Cache miss or no cache, try database as fallback
-	if c.gameID == uuid.Nil {
-		// Try to get user's active game
-		gameState, err := c.hub.db.GetUserActiveGame(c.userID)
-		if err != nil {
-			return nil, err
-		}
-		if gameState != nil {
-			c.gameID = gameState.ID
-			// Cache the game state
-			if c.hub.cache != nil {
-				if err := c.hub.cache.SetGameSession(c.userID, gameState, time.Hour); err != nil {
-					log.Printf("Failed to cache game session: %v", err)
-				}
-			}
-		}
-		return gameState, nil
-	}
-
-	// Get game by ID from database
-	gameState, err := c.hub.db.GetGame(c.gameID.String(), c.userID)
-	if err == nil && gameState != nil && c.hub.cache != nil {
-		// Cache the game state
-		if err := c.hub.cache.SetGameSession(c.userID, gameState, time.Hour); err != nil {
-			log.Printf("Failed to cache game session: %v", err)
-		}
-	}
-	return gameState, err
-}
-
-// updateLeaderboards updates the leaderboard cache when a game finishes
-func (c *Client) updateLeaderboards(gameState *models.GameState) {
-	log.Printf("Game finished for user %s with score %d", c.userID, gameState.Score)
-
-	// Invalidate leaderboard caches so they will be refreshed on next request
-	if c.hub.cache != nil {
-		leaderboardTypes := []models.LeaderboardType{
-			models.LeaderboardDaily,
-			models.LeaderboardWeekly,
-			models.LeaderboardMonthly,
-			models.LeaderboardAll,
-		}
-
-		for _, lbType := range leaderboardTypes {
-			if err := c.hub.cache.InvalidateLeaderboard(lbType); err != nil {
-				log.Printf("Failed to invalidate %s leaderboard cache: %v", lbType, err)
-			} else {
-				log.Printf("Invalidated %s leaderboard cache", lbType)
-			}
-		}
-	}
-
-	// Optionally broadcast leaderboard updates to connected clients
-	// This could be expensive with many concurrent games, so we'll skip it for now
-	// go c.hub.broadcastLeaderboardUpdate(models.LeaderboardAll)
-}
-
 // broadcastLeaderboardUpdate broadcasts leaderboard updates to all connected clients
 func (h *Hub) broadcastLeaderboardUpdate(leaderboardType models.LeaderboardType) {
-	entries, err := h.db.GetLeaderboard(leaderboardType, 100)
+	query := h.calendar.Query(leaderboardType, time.Now())
+
+	entries, err := h.db.GetLeaderboard(query, 100, 0)
 	if err != nil {
 		log.Printf("Failed to get leaderboard for broadcast: %v", err)
 		return
@@ -302,6 +244,8 @@ func (h *Hub) broadcastLeaderboardUpdate(leaderboardType models.LeaderboardType)
 	response := models.LeaderboardResponse{
 		Type:     leaderboardType,
 		Rankings: entries,
+		Limit:    100,
+		Period:   query.Period,
 	}
 
 	message := models.WebSocketMessage{
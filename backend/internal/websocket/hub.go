@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -10,7 +11,7 @@ import (
 	"game2048/internal/auth"
 	"game2048/internal/cache"
 	"game2048/internal/database"
-	"game2048/internal/game"
+	"game2048/internal/service"
 	"game2048/pkg/models"
 
 	"github.com/gin-gonic/gin"
@@ -32,8 +33,8 @@ type Hub struct {
 	// Unregister requests from clients
 	unregister chan *Client
 
-	// Game engine
-	gameEngine *game.Engine
+	// Game actions shared with the REST API
+	games *service.GameService
 
 	// Database
 	db database.Database
@@ -44,6 +45,9 @@ type Hub struct {
 	// Auth service
 	authService *auth.AuthService
 
+	// Calendar for leaderboard periods
+	calendar models.PeriodCalendar
+
 	// Mutex for thread safety
 	mutex sync.RWMutex
 }
@@ -59,11 +63,21 @@ type Client struct {
 	// User ID
 	userID string
 
+	// Credentials the connection was last authenticated with, renewed by auth messages
+	authMutex sync.Mutex
+	tokenID   string
+	expiresAt time.Time
+	apiToken  bool // Authenticated with a personal API token, game actions count against its rate limit
+
 	// Current game ID
 	gameID uuid.UUID
 
 	// Hub reference
 	hub *Hub
+
+	// Cancelled when the connection closes, aborting game actions still waiting to run
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // WebSocket upgrader
@@ -78,16 +92,17 @@ var upgrader = websocket.Upgrader{
 }
 
 // NewHub creates a new WebSocket hub
-func NewHub(gameEngine *game.Engine, db database.Database, authService *auth.AuthService, redisCache cache.Cache) *Hub {
+func NewHub(games *service.GameService, db database.Database, authService *auth.AuthService, redisCache cache.Cache, calendar models.PeriodCalendar) *Hub {
 	return &Hub{
 		clients:     make(map[*Client]bool),
 		broadcast:   make(chan []byte),
 		register:    make(chan *Client),
 		unregister:  make(chan *Client),
-		gameEngine:  gameEngine,
+		games:       games,
 		db:          db,
 		cache:       redisCache,
 		authService: authService,
+		calendar:    calendar,
 	}
 }
 
@@ -144,11 +159,48 @@ func (h *Hub) HandleWebSocket(c *gin.Context) {
 		return
 	}
 
-	// Validate JWT token
-	userID, err := h.authService.ValidateJWT(token)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication token"})
-		return
+	// Validate the token of a signed-in user or a guest, or an API token allowed to play
+	var claims *auth.TokenClaims
+	apiToken := auth.IsAPIToken(token)
+	if apiToken {
+		stored, err := h.authService.AuthenticateAPIToken(token, models.ScopePlayGames)
+		switch err {
+		case nil:
+			claims = h.authService.APITokenClaims(stored)
+		case auth.ErrAPITokenScope:
+			c.JSON(http.StatusForbidden, gin.H{"error": "API token is missing scope: " + string(models.ScopePlayGames)})
+			return
+		case auth.ErrAPITokenRateLimited:
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "API token rate limit exceeded"})
+			return
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication token"})
+			return
+		}
+	} else {
+		var err error
+		claims, err = h.authService.ParsePlayerToken(token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authentication token"})
+			return
+		}
+	}
+
+	// Suspended and banned users cannot play, guests cannot be moderated
+	if !claims.Guest {
+		user, err := h.authService.CheckAccount(claims.UserID)
+		switch err {
+		case nil:
+		case auth.ErrAccountSuspended:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended", "suspended_until": user.SuspendedUntil})
+			return
+		case auth.ErrAccountBanned:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account banned"})
+			return
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
 	}
 
 	// Upgrade HTTP connection to WebSocket
@@ -159,11 +211,17 @@ func (h *Hub) HandleWebSocket(c *gin.Context) {
 	}
 
 	// Create new client
+	ctx, cancel := context.WithCancel(context.Background())
 	client := &Client{
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		userID: userID,
-		hub:    h,
+		conn:      conn,
+		send:      make(chan []byte, 256),
+		userID:    claims.UserID,
+		tokenID:   claims.TokenID,
+		expiresAt: claims.ExpiresAt,
+		apiToken:  apiToken,
+		hub:       h,
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 
 	// Register client
@@ -176,40 +234,16 @@ func (h *Hub) HandleWebSocket(c *gin.Context) {
 
 // sendCurrentGameState sends the current game state to a newly connected client
 func (h *Hub) sendCurrentGameState(client *Client) {
-	// Try to get game state from Redis cache first
-	var gameState *models.GameState
-	var err error
-
-	if h.cache != nil {
-		gameState, err = h.cache.GetGameSession(client.userID)
-		if err != nil {
-			// Cache miss or error, try database as fallback
-			gameState, err = h.db.GetUserActiveGame(client.userID)
-			if err != nil {
-				log.Printf("Error getting active game for user %s: %v", client.userID, err)
-				return
-			}
-
-			// If found in database, cache it for future use
-			if gameState != nil && h.cache != nil {
-				// Cache for 1 hour
-				if err := h.cache.SetGameSession(client.userID, gameState, time.Hour); err != nil {
-					log.Printf("Failed to cache game session: %v", err)
-				}
-			}
-		}
-	} else {
-		// No cache available, use database
-		gameState, err = h.db.GetUserActiveGame(client.userID)
-		if err != nil {
-			log.Printf("Error getting active game for user %s: %v", client.userID, err)
-			return
-		}
+	gameState, err := h.games.CurrentGame(client.ctx, client.userID)
+	if err != nil {
+		log.Printf("Error getting active game for user %s: %v", client.userID, err)
+		return
 	}
 
 	if gameState != nil {
 		client.gameID = gameState.ID
 		response := models.GameResponse{
+			GameID:   gameState.ID,
 			Board:    gameState.Board,
 			Score:    gameState.Score,
 			GameOver: gameState.GameOver,
@@ -225,6 +259,70 @@ func (h *Hub) sendCurrentGameState(client *Client) {
 	}
 }
 
+// NotifyAchievement sends an unlocked achievement to every connection of the user
+func (h *Hub) NotifyAchievement(userID string, achievement models.AchievementUnlockedMessage) {
+	h.sendToUser(userID, models.WebSocketMessage{
+		Type: "achievement_unlocked",
+		Data: achievement,
+	})
+}
+
+// NotifyTournamentRound sends a tournament round start or end to every connection of the user
+func (h *Hub) NotifyTournamentRound(userID, messageType string, message models.TournamentRoundMessage) {
+	h.sendToUser(userID, models.WebSocketMessage{
+		Type: messageType,
+		Data: message,
+	})
+}
+
+// DisconnectToken closes the connections authenticated with a revoked token
+func (h *Hub) DisconnectToken(tokenID string) {
+	h.disconnect(func(client *Client) bool {
+		current, _ := client.credentials()
+		return current == tokenID
+	})
+}
+
+// DisconnectUser closes every connection of a user whose sessions were all revoked
+func (h *Hub) DisconnectUser(userID string) {
+	h.disconnect(func(client *Client) bool {
+		return client.userID == userID
+	})
+}
+
+// disconnect closes the matching connections, their read pumps then unregister them
+func (h *Hub) disconnect(match func(client *Client) bool) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for client := range h.clients {
+		if match(client) {
+			client.conn.Close()
+		}
+	}
+}
+
+// sendToUser sends a message to every connection of a user, skipping connections that cannot keep up
+func (h *Hub) sendToUser(userID string, message models.WebSocketMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for client := range h.clients {
+		if client.userID != userID {
+			continue
+		}
+		select {
+		case client.send <- data:
+		default:
+			log.Printf("Dropping %s message for slow client %s", message.Type, userID)
+		}
+	}
+}
+
 // sendMessage sends a message to the client
 func (c *Client) sendMessage(message models.WebSocketMessage) {
 	data, err := json.Marshal(message)
@@ -246,6 +344,7 @@ func (c *Client) sendMessage(message models.WebSocketMessage) {
 // readPump pumps messages from the websocket connection to the hub
 func (c *Client) readPump() {
 	defer func() {
+		c.cancel()
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
@@ -315,6 +414,17 @@ func (c *Client) writePump() {
 
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+
+			// Connections must not outlive their credentials
+			if c.expired(time.Now()) {
+				if data, err := json.Marshal(authExpiredMessage); err == nil {
+					c.conn.WriteMessage(websocket.TextMessage, data)
+				}
+				c.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "authentication expired"))
+				return
+			}
+
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -324,6 +434,23 @@ func (c *Client) writePump() {
 
 // handleMessage handles incoming WebSocket messages
 func (c *Client) handleMessage(message models.WebSocketMessage) {
+	if message.Type == "auth" {
+		c.handleAuth(message.Data)
+		return
+	}
+
+	// Game actions need current credentials, the write pump closes connections left expired
+	if c.expired(time.Now()) {
+		c.sendMessage(authExpiredMessage)
+		return
+	}
+
+	// Bots are held to the rate limit of their API token for every action, not only when connecting
+	if tokenID, apiToken := c.apiTokenID(); apiToken && !c.hub.authService.AllowAPITokenRequest(tokenID) {
+		c.sendError("API token rate limit exceeded")
+		return
+	}
+
 	switch message.Type {
 	case "move":
 		c.handleMove(message.Data)
@@ -336,6 +463,63 @@ func (c *Client) handleMessage(message models.WebSocketMessage) {
 	}
 }
 
+// handleAuth re-authenticates the connection with a fresh access token of the same user
+func (c *Client) handleAuth(data interface{}) {
+	var req models.WebSocketAuthRequest
+	dataBytes, _ := json.Marshal(data)
+	if err := json.Unmarshal(dataBytes, &req); err != nil || req.Token == "" {
+		c.sendError("Missing authentication token")
+		return
+	}
+
+	claims, err := c.hub.authService.ParseJWT(req.Token)
+	if err != nil {
+		c.sendError("Invalid authentication token")
+		return
+	}
+	if claims.UserID != c.userID {
+		c.sendError("Authentication token belongs to another user")
+		return
+	}
+
+	c.authMutex.Lock()
+	c.tokenID = claims.TokenID
+	c.expiresAt = claims.ExpiresAt
+	c.apiToken = false
+	c.authMutex.Unlock()
+
+	c.sendMessage(models.WebSocketMessage{
+		Type: "authenticated",
+		Data: models.WebSocketAuthResponse{ExpiresAt: claims.ExpiresAt},
+	})
+}
+
+// credentials returns the ID and expiry of the token the connection was last authenticated with
+func (c *Client) credentials() (string, time.Time) {
+	c.authMutex.Lock()
+	defer c.authMutex.Unlock()
+	return c.tokenID, c.expiresAt
+}
+
+// apiTokenID returns the ID of the API token the connection is authenticated with, if it is
+func (c *Client) apiTokenID() (string, bool) {
+	c.authMutex.Lock()
+	defer c.authMutex.Unlock()
+	return c.tokenID, c.apiToken
+}
+
+// expired reports whether the credentials of the connection have expired
+func (c *Client) expired(now time.Time) bool {
+	_, expiresAt := c.credentials()
+	return !now.Before(expiresAt)
+}
+
+// authExpiredMessage tells the client to re-authenticate, or to reconnect with a fresh token once the connection is closed
+var authExpiredMessage = models.WebSocketMessage{
+	Type: "auth_expired",
+	Data: models.ErrorResponse{Message: "Authentication expired"},
+}
+
 // sendError sends an error message to the client
 func (c *Client) sendError(errorMessage string) {
 	response := models.ErrorResponse{
@@ -0,0 +1,50 @@
+-- Create seasons table
+CREATE TABLE IF NOT EXISTS seasons (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reward_top_n INTEGER NOT NULL DEFAULT 0,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (end_at > start_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_seasons_period ON seasons(start_at, end_at);
+
+CREATE TRIGGER update_seasons_updated_at
+    BEFORE UPDATE ON seasons
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Final standings frozen when a season closes
+CREATE TABLE IF NOT EXISTS season_standings (
+    season_id UUID NOT NULL REFERENCES seasons(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_name VARCHAR(255) NOT NULL,
+    user_avatar VARCHAR(500),
+    score INTEGER NOT NULL,
+    rank INTEGER NOT NULL,
+    game_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (season_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_season_standings_rank ON season_standings(season_id, rank);
+
+-- Badges awarded to users, e.g. for a top season finish
+CREATE TABLE IF NOT EXISTS user_badges (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    season_id UUID NOT NULL REFERENCES seasons(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    rank INTEGER NOT NULL,
+    awarded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(season_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_badges_user_id ON user_badges(user_id);
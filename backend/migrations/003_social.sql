@@ -0,0 +1,38 @@
+-- Users following other users, the friends leaderboard shows a user and everyone they follow
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows(followee_id);
+
+-- Named groups of players, such as teams or companies, joined with an invite code
+CREATE TABLE IF NOT EXISTS groups (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    owner_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    invite_code VARCHAR(32) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_groups_owner_id ON groups(owner_id);
+
+CREATE TRIGGER update_groups_updated_at
+    BEFORE UPDATE ON groups
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS group_members (
+    group_id UUID NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_members_user_id ON group_members(user_id);
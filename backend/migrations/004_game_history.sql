@@ -0,0 +1,5 @@
+-- Game mode, regular games are classic
+ALTER TABLE games ADD COLUMN IF NOT EXISTS mode VARCHAR(32) NOT NULL DEFAULT 'classic';
+
+-- Index for listing a user's game history
+CREATE INDEX IF NOT EXISTS idx_games_user_history ON games(user_id, created_at DESC);
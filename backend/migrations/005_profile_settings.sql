@@ -0,0 +1,2 @@
+-- Users can hide their public profile page
+ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_hidden BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+-- Achievements unlocked by users, the achievements themselves are defined in code.
+-- The primary key makes unlocking idempotent, an achievement is only ever unlocked once per user.
+-- The game is not a foreign key because active games may not be stored yet when an achievement is unlocked.
+CREATE TABLE IF NOT EXISTS user_achievements (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    achievement_id VARCHAR(64) NOT NULL,
+    game_id UUID,
+    unlocked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (user_id, achievement_id)
+);
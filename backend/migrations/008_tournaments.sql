@@ -0,0 +1,57 @@
+-- Seeded games spawn tiles from the seed and the board, 0 for random tiles
+ALTER TABLE games ADD COLUMN IF NOT EXISTS seed BIGINT NOT NULL DEFAULT 0;
+
+-- Admin-organized tournaments played over scheduled rounds
+CREATE TABLE IF NOT EXISTS tournaments (
+    id UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    registration_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    registration_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    max_attempts INTEGER NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'upcoming',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tournaments_status ON tournaments(status);
+
+CREATE TRIGGER update_tournaments_updated_at
+    BEFORE UPDATE ON tournaments
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Rounds of a tournament; advance is the number of players reaching the next round, 0 for the final
+CREATE TABLE IF NOT EXISTS tournament_rounds (
+    tournament_id UUID NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    seed BIGINT NOT NULL,
+    advance INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'scheduled',
+    PRIMARY KEY (tournament_id, number)
+);
+
+-- Registered players, eliminated_round is set when they are knocked out
+CREATE TABLE IF NOT EXISTS tournament_participants (
+    tournament_id UUID NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    registered_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    eliminated_round INTEGER,
+    final_rank INTEGER,
+    PRIMARY KEY (tournament_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tournament_participants_user_id ON tournament_participants(user_id);
+
+-- Games played as tournament attempts. Active games may only be cached, so there is no foreign key to games.
+CREATE TABLE IF NOT EXISTS tournament_attempts (
+    game_id UUID PRIMARY KEY,
+    tournament_id UUID NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    round INTEGER NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tournament_attempts_round ON tournament_attempts(tournament_id, round, user_id);
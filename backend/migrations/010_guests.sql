@@ -0,0 +1,5 @@
+-- Guests play without signing in, they are users without a provider account.
+-- Their games move to the account they sign in with, unclaimed guests are deleted when their token expires.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_guest BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_users_guest_created_at ON users(created_at) WHERE is_guest = TRUE;
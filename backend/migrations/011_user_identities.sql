@@ -0,0 +1,24 @@
+-- Provider identities a user signs in with, one account can have several.
+-- The users row keeps the identity the account was created with, which is also listed here.
+CREATE TABLE IF NOT EXISTS user_identities (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    avatar VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(provider, provider_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities(user_id);
+
+-- Existing accounts start with the identity they were created with
+INSERT INTO user_identities (id, user_id, provider, provider_id, email, name, avatar, created_at, last_used_at)
+SELECT gen_random_uuid(), id, provider, provider_id, email, name, COALESCE(avatar, ''), created_at, updated_at
+FROM users
+WHERE is_guest = FALSE
+ON CONFLICT (provider, provider_id) DO NOTHING;
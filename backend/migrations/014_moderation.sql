@@ -0,0 +1,19 @@
+-- Moderation status of users: suspended and banned users cannot sign in or play,
+-- banned and shadow-banned users are hidden from leaderboards. Suspensions end at suspended_until.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS moderation_status VARCHAR(16) NOT NULL DEFAULT 'active';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_moderation_status ON users(moderation_status) WHERE moderation_status <> 'active';
+
+-- Audit trail of every moderation status set, with the moderator and the reason
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id UUID PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    moderator_id VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(16) NOT NULL,
+    suspended_until TIMESTAMP WITH TIME ZONE,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_user_id ON moderation_actions(user_id, created_at DESC);
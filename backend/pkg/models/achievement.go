@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Achievement describes an achievement players can unlock
+type Achievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// UserAchievement represents an achievement unlocked by a user.
+// The game ID is the game the achievement was unlocked in, if any.
+type UserAchievement struct {
+	UserID        string     `json:"user_id" db:"user_id"`
+	AchievementID string     `json:"achievement_id" db:"achievement_id"`
+	GameID        *uuid.UUID `json:"game_id,omitempty" db:"game_id"`
+	UnlockedAt    time.Time  `json:"unlocked_at" db:"unlocked_at"`
+}
+
+// AchievementStatus represents an achievement together with whether the user has unlocked it
+type AchievementStatus struct {
+	Achievement
+	Unlocked   bool       `json:"unlocked"`
+	GameID     *uuid.UUID `json:"game_id,omitempty"`
+	UnlockedAt *time.Time `json:"unlocked_at,omitempty"`
+}
+
+// AchievementUnlockedMessage is sent over the WebSocket when the player unlocks an achievement
+type AchievementUnlockedMessage struct {
+	Achievement
+	GameID     *uuid.UUID `json:"game_id,omitempty"`
+	UnlockedAt time.Time  `json:"unlocked_at"`
+}
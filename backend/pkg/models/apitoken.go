@@ -0,0 +1,99 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenScope limits what a personal API token can be used for, session tokens are not scoped
+type TokenScope string
+
+const (
+	ScopePlayGames   TokenScope = "games:play"
+	ScopeReadGames   TokenScope = "games:read"
+	ScopeSocial      TokenScope = "social"
+	ScopeTournaments TokenScope = "tournaments"
+)
+
+// AllTokenScopes returns every scope a personal API token can have
+func AllTokenScopes() []TokenScope {
+	return []TokenScope{ScopePlayGames, ScopeReadGames, ScopeSocial, ScopeTournaments}
+}
+
+// IsValid reports whether the scope is one API tokens can have
+func (s TokenScope) IsValid() bool {
+	for _, scope := range AllTokenScopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIToken is a personal access token a user created for a bot or script, only the token's hash is stored.
+// The token value is only returned when the token is created.
+type APIToken struct {
+	ID         uuid.UUID    `json:"id" db:"id"`
+	UserID     string       `json:"user_id" db:"user_id"`
+	Name       string       `json:"name" db:"name"`
+	TokenHash  string       `json:"-" db:"token_hash"`
+	Prefix     string       `json:"prefix" db:"prefix"`
+	Scopes     []TokenScope `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time   `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// HasScope reports whether the token has one of the scopes
+func (t *APIToken) HasScope(scopes ...TokenScope) bool {
+	for _, s := range t.Scopes {
+		for _, scope := range scopes {
+			if s == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Active reports whether the token can still be used
+func (t *APIToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
+
+// CreateAPITokenRequest represents a request to create a personal API token
+type CreateAPITokenRequest struct {
+	Name   string       `json:"name" binding:"required,max=100"`
+	Scopes []TokenScope `json:"scopes" binding:"required,min=1"`
+	// Days until the token expires, the token does not expire when omitted
+	ExpiresInDays int `json:"expires_in_days" binding:"min=0,max=365"`
+}
+
+// CreatedAPIToken is returned once when a token is created, with the token value to copy
+type CreatedAPIToken struct {
+	*APIToken
+	Token string `json:"token"`
+}
+
+// JoinTokenScopes encodes token scopes for the scopes column
+func JoinTokenScopes(scopes []TokenScope) string {
+	names := make([]string, len(scopes))
+	for i, scope := range scopes {
+		names[i] = string(scope)
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseTokenScopes decodes the scopes column of an API token
+func ParseTokenScopes(value string) []TokenScope {
+	scopes := []TokenScope{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			scopes = append(scopes, TokenScope(name))
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a server-side record of an issued refresh token, only the token's hash is stored.
+// Every refresh rotates the token: the presented token is marked rotated and a new one of the same
+// family is issued. Presenting a rotated token again means it was stolen, so the whole family is revoked.
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    string     `json:"user_id" db:"user_id"`
+	FamilyID  uuid.UUID  `json:"family_id" db:"family_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty" db:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// UserIdentity is a provider account a user signs in with.
+// The primary identity is the one stored on the user, its profile is shown as the user's.
+type UserIdentity struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	UserID     string    `json:"user_id" db:"user_id"`
+	Provider   string    `json:"provider" db:"provider"`
+	ProviderID string    `json:"provider_id" db:"provider_id"`
+	Email      string    `json:"email" db:"email"`
+	Name       string    `json:"name" db:"name"`
+	Avatar     string    `json:"avatar" db:"avatar"`
+	Primary    bool      `json:"primary" db:"-"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at" db:"last_used_at"`
+}
+
+// MergeUsersRequest asks to merge the source account into the account of the request path
+type MergeUsersRequest struct {
+	SourceUserID string `json:"source_user_id" binding:"required"`
+}
+
+// AuthTokens are the tokens issued on login and on every refresh
+type AuthTokens struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+
+	// Lifetimes of the tokens, in seconds
+	ExpiresIn        int `json:"expires_in"`
+	RefreshExpiresIn int `json:"refresh_expires_in"`
+}
+
+// GuestSession is returned when guest play starts, the token authenticates the guest like an access token
+type GuestSession struct {
+	Token     string `json:"token"`
+	ExpiresIn int    `json:"expires_in"`
+	User      *User  `json:"user"`
+}
+
+// RefreshRequest represents a request to exchange a refresh token for new tokens.
+// Browsers send the refresh token cookie instead.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// WebSocketAuthRequest re-authenticates a WebSocket connection with a fresh access token
+type WebSocketAuthRequest struct {
+	Token string `json:"token"`
+}
+
+// WebSocketAuthResponse confirms the credentials of a WebSocket connection and when they expire
+type WebSocketAuthResponse struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -24,8 +24,23 @@ type GameState struct {
 	Score     int       `json:"score" db:"score"`
 	GameOver  bool      `json:"game_over" db:"game_over"`
 	Victory   bool      `json:"victory" db:"victory"`
+	Mode      string    `json:"mode" db:"mode"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// Seed makes new tiles depend only on the seed and the board, 0 for randomly spawned tiles
+	Seed int64 `json:"seed,omitempty" db:"seed"`
+}
+
+// FinishMessage returns the message shown when the game ends, or an empty string while it is running
+func (g *GameState) FinishMessage() string {
+	if g.Victory {
+		return "Congratulations! You merged two 8192 tiles and won!"
+	}
+	if g.GameOver {
+		return "Game Over! No more moves available."
+	}
+	return ""
 }
 
 // Board represents a 4x4 game board
@@ -33,14 +48,20 @@ type Board [4][4]int
 
 // User represents a user in the system
 type User struct {
-	ID         string    `json:"id" db:"id"`
-	Email      string    `json:"email" db:"email"`
-	Name       string    `json:"name" db:"name"`
-	Avatar     string    `json:"avatar" db:"avatar"`
-	Provider   string    `json:"provider" db:"provider"`
-	ProviderID string    `json:"provider_id" db:"provider_id"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	ID            string    `json:"id" db:"id"`
+	Email         string    `json:"email" db:"email"`
+	Name          string    `json:"name" db:"name"`
+	Avatar        string    `json:"avatar" db:"avatar"`
+	Provider      string    `json:"provider" db:"provider"`
+	ProviderID    string    `json:"provider_id" db:"provider_id"`
+	ProfileHidden bool      `json:"profile_hidden" db:"profile_hidden"`
+	IsGuest       bool      `json:"is_guest" db:"is_guest"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+
+	// Moderation is only shown to moderators, so shadow-banned users cannot tell
+	ModerationStatus ModerationStatus `json:"-" db:"moderation_status"`
+	SuspendedUntil   *time.Time       `json:"-" db:"suspended_until"`
 }
 
 // LeaderboardEntry represents an entry in the leaderboard
@@ -62,8 +83,20 @@ const (
 	LeaderboardWeekly  LeaderboardType = "weekly"
 	LeaderboardMonthly LeaderboardType = "monthly"
 	LeaderboardAll     LeaderboardType = "all"
+	LeaderboardSeason  LeaderboardType = "season"
 )
 
+// AllLeaderboardTypes returns every leaderboard type
+func AllLeaderboardTypes() []LeaderboardType {
+	return []LeaderboardType{
+		LeaderboardDaily,
+		LeaderboardWeekly,
+		LeaderboardMonthly,
+		LeaderboardAll,
+		LeaderboardSeason,
+	}
+}
+
 // WebSocketMessage represents a message sent over WebSocket
 type WebSocketMessage struct {
 	Type string      `json:"type"`
@@ -82,22 +115,44 @@ type NewGameRequest struct {
 
 // LeaderboardRequest represents a leaderboard request
 type LeaderboardRequest struct {
-	Type LeaderboardType `json:"type"`
+	Type     LeaderboardType `json:"type"`
+	SeasonID string          `json:"season_id,omitempty"`
+	Group    string          `json:"group,omitempty"`
+	Limit    int             `json:"limit,omitempty"`
+	Offset   int             `json:"offset,omitempty"`
 }
 
 // GameResponse represents the response sent to client after a move
 type GameResponse struct {
-	Board    Board  `json:"board"`
-	Score    int    `json:"score"`
-	GameOver bool   `json:"game_over"`
-	Victory  bool   `json:"victory"`
-	Message  string `json:"message,omitempty"`
+	GameID   uuid.UUID `json:"game_id"`
+	Board    Board     `json:"board"`
+	Score    int       `json:"score"`
+	GameOver bool      `json:"game_over"`
+	Victory  bool      `json:"victory"`
+	Message  string    `json:"message,omitempty"`
 }
 
 // LeaderboardResponse represents the leaderboard response
 type LeaderboardResponse struct {
 	Type     LeaderboardType    `json:"type"`
 	Rankings []LeaderboardEntry `json:"rankings"`
+	Total    int                `json:"total"`
+	Offset   int                `json:"offset"`
+	Limit    int                `json:"limit"`
+	Period   *LeaderboardPeriod `json:"period,omitempty"`
+	Season   *Season            `json:"season,omitempty"`
+	Group    string             `json:"group,omitempty"`
+}
+
+// UserRankResponse represents a user's position on a leaderboard with the entries around it
+type UserRankResponse struct {
+	Type   LeaderboardType    `json:"type"`
+	Entry  *LeaderboardEntry  `json:"entry"`
+	Above  []LeaderboardEntry `json:"above"`
+	Below  []LeaderboardEntry `json:"below"`
+	Total  int                `json:"total"`
+	Period *LeaderboardPeriod `json:"period,omitempty"`
+	Group  string             `json:"group,omitempty"`
 }
 
 // ErrorResponse represents an error response
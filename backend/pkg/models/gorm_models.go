@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,14 +12,19 @@ import (
 
 // User represents a user in the system using GORM
 type GormUser struct {
-	ID         string    `gorm:"primaryKey;type:varchar(255)" json:"id"`
-	Email      string    `gorm:"type:varchar(255);not null" json:"email"`
-	Name       string    `gorm:"type:varchar(255);not null" json:"name"`
-	Avatar     string    `gorm:"type:varchar(500)" json:"avatar"`
-	Provider   string    `gorm:"type:varchar(50);not null" json:"provider"`
-	ProviderID string    `gorm:"type:varchar(255);not null" json:"provider_id"`
-	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID            string    `gorm:"primaryKey;type:varchar(255)" json:"id"`
+	Email         string    `gorm:"type:varchar(255);not null" json:"email"`
+	Name          string    `gorm:"type:varchar(255);not null" json:"name"`
+	Avatar        string    `gorm:"type:varchar(500)" json:"avatar"`
+	Provider      string    `gorm:"type:varchar(50);not null" json:"provider"`
+	ProviderID    string    `gorm:"type:varchar(255);not null" json:"provider_id"`
+	ProfileHidden bool      `gorm:"not null;default:false" json:"profile_hidden"`
+	IsGuest       bool      `gorm:"not null;default:false;index" json:"is_guest"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	ModerationStatus string     `gorm:"type:varchar(16);not null;default:active" json:"moderation_status"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"`
 
 	// Relationships
 	Games []GormGame `gorm:"foreignKey:UserID" json:"games,omitempty"`
@@ -32,14 +38,19 @@ func (GormUser) TableName() string {
 // ToUser converts GormUser to User
 func (gu *GormUser) ToUser() *User {
 	return &User{
-		ID:         gu.ID,
-		Email:      gu.Email,
-		Name:       gu.Name,
-		Avatar:     gu.Avatar,
-		Provider:   gu.Provider,
-		ProviderID: gu.ProviderID,
-		CreatedAt:  gu.CreatedAt,
-		UpdatedAt:  gu.UpdatedAt,
+		ID:            gu.ID,
+		Email:         gu.Email,
+		Name:          gu.Name,
+		Avatar:        gu.Avatar,
+		Provider:      gu.Provider,
+		ProviderID:    gu.ProviderID,
+		ProfileHidden: gu.ProfileHidden,
+		IsGuest:       gu.IsGuest,
+		CreatedAt:     gu.CreatedAt,
+		UpdatedAt:     gu.UpdatedAt,
+
+		ModerationStatus: ModerationStatus(gu.ModerationStatus),
+		SuspendedUntil:   gu.SuspendedUntil,
 	}
 }
 
@@ -51,8 +62,12 @@ func (gu *GormUser) FromUser(u *User) {
 	gu.Avatar = u.Avatar
 	gu.Provider = u.Provider
 	gu.ProviderID = u.ProviderID
+	gu.ProfileHidden = u.ProfileHidden
+	gu.IsGuest = u.IsGuest
 	gu.CreatedAt = u.CreatedAt
 	gu.UpdatedAt = u.UpdatedAt
+	gu.ModerationStatus = string(u.ModerationStatus)
+	gu.SuspendedUntil = u.SuspendedUntil
 }
 
 // GormGame represents a game session using GORM
@@ -63,6 +78,8 @@ type GormGame struct {
 	Score     int       `gorm:"not null;default:0;index:idx_games_score" json:"score"`
 	GameOver  bool      `gorm:"not null;default:false" json:"game_over"`
 	Victory   bool      `gorm:"not null;default:false" json:"victory"`
+	Mode      string    `gorm:"type:varchar(32);not null;default:classic" json:"mode"`
+	Seed      int64     `gorm:"not null;default:0" json:"seed"`
 	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_games_created_at" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
@@ -113,6 +130,8 @@ func (gg *GormGame) ToGameState() *GameState {
 		Score:     gg.Score,
 		GameOver:  gg.GameOver,
 		Victory:   gg.Victory,
+		Mode:      gg.Mode,
+		Seed:      gg.Seed,
 		CreatedAt: gg.CreatedAt,
 		UpdatedAt: gg.UpdatedAt,
 	}
@@ -126,6 +145,8 @@ func (gg *GormGame) FromGameState(gs *GameState) {
 	gg.Score = gs.Score
 	gg.GameOver = gs.GameOver
 	gg.Victory = gs.Victory
+	gg.Mode = gs.Mode
+	gg.Seed = gs.Seed
 	gg.CreatedAt = gs.CreatedAt
 	gg.UpdatedAt = gs.UpdatedAt
 }
@@ -201,3 +222,642 @@ type GormMonthlyLeaderboard struct {
 func (GormMonthlyLeaderboard) TableName() string {
 	return "leaderboard_monthly"
 }
+
+// GormSeason represents an admin-defined season using GORM
+type GormSeason struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Name       string     `gorm:"type:varchar(255);not null" json:"name"`
+	StartAt    time.Time  `gorm:"not null;index:idx_seasons_period" json:"start_at"`
+	EndAt      time.Time  `gorm:"not null;index:idx_seasons_period" json:"end_at"`
+	RewardTopN int        `gorm:"not null;default:0" json:"reward_top_n"`
+	ClosedAt   *time.Time `json:"closed_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GormSeason
+func (GormSeason) TableName() string {
+	return "seasons"
+}
+
+// ToSeason converts GormSeason to Season
+func (gs *GormSeason) ToSeason() *Season {
+	return &Season{
+		ID:         gs.ID,
+		Name:       gs.Name,
+		StartAt:    gs.StartAt,
+		EndAt:      gs.EndAt,
+		RewardTopN: gs.RewardTopN,
+		ClosedAt:   gs.ClosedAt,
+		CreatedAt:  gs.CreatedAt,
+		UpdatedAt:  gs.UpdatedAt,
+	}
+}
+
+// FromSeason converts Season to GormSeason
+func (gs *GormSeason) FromSeason(s *Season) {
+	gs.ID = s.ID
+	gs.Name = s.Name
+	gs.StartAt = s.StartAt
+	gs.EndAt = s.EndAt
+	gs.RewardTopN = s.RewardTopN
+	gs.ClosedAt = s.ClosedAt
+	gs.CreatedAt = s.CreatedAt
+	gs.UpdatedAt = s.UpdatedAt
+}
+
+// Final standings archived when a season closes
+type GormSeasonStanding struct {
+	SeasonID   uuid.UUID `gorm:"type:uuid;not null;primaryKey;index:idx_season_standings_rank,priority:1" json:"season_id"`
+	UserID     string    `gorm:"type:varchar(255);not null;primaryKey" json:"user_id"`
+	UserName   string    `gorm:"type:varchar(255);not null" json:"user_name"`
+	UserAvatar string    `gorm:"type:varchar(500)" json:"user_avatar"`
+	Score      int       `gorm:"not null" json:"score"`
+	Rank       int       `gorm:"not null;index:idx_season_standings_rank,priority:2" json:"rank"`
+	GameID     uuid.UUID `gorm:"type:uuid;not null" json:"game_id"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (GormSeasonStanding) TableName() string {
+	return "season_standings"
+}
+
+// GormUserBadge represents a badge awarded to a user using GORM
+type GormUserBadge struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    string    `gorm:"type:varchar(255);not null;index;uniqueIndex:idx_user_badges_season_user" json:"user_id"`
+	SeasonID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_user_badges_season_user" json:"season_id"`
+	Title     string    `gorm:"type:varchar(255);not null" json:"title"`
+	Rank      int       `gorm:"not null" json:"rank"`
+	AwardedAt time.Time `gorm:"not null" json:"awarded_at"`
+}
+
+// TableName specifies the table name for GormUserBadge
+func (GormUserBadge) TableName() string {
+	return "user_badges"
+}
+
+// ToUserBadge converts GormUserBadge to UserBadge
+func (gb *GormUserBadge) ToUserBadge() *UserBadge {
+	return &UserBadge{
+		ID:        gb.ID,
+		UserID:    gb.UserID,
+		SeasonID:  gb.SeasonID,
+		Title:     gb.Title,
+		Rank:      gb.Rank,
+		AwardedAt: gb.AwardedAt,
+	}
+}
+
+// GormUserAchievement represents an achievement unlocked by a user using GORM
+type GormUserAchievement struct {
+	UserID        string     `gorm:"type:varchar(255);not null;primaryKey" json:"user_id"`
+	AchievementID string     `gorm:"type:varchar(64);not null;primaryKey" json:"achievement_id"`
+	GameID        *uuid.UUID `gorm:"type:uuid" json:"game_id"`
+	UnlockedAt    time.Time  `gorm:"not null" json:"unlocked_at"`
+}
+
+// TableName specifies the table name for GormUserAchievement
+func (GormUserAchievement) TableName() string {
+	return "user_achievements"
+}
+
+// ToUserAchievement converts GormUserAchievement to UserAchievement
+func (ga *GormUserAchievement) ToUserAchievement() *UserAchievement {
+	return &UserAchievement{
+		UserID:        ga.UserID,
+		AchievementID: ga.AchievementID,
+		GameID:        ga.GameID,
+		UnlockedAt:    ga.UnlockedAt,
+	}
+}
+
+// GormFollow represents a user following another user using GORM
+type GormFollow struct {
+	FollowerID string    `gorm:"type:varchar(255);not null;primaryKey" json:"follower_id"`
+	FolloweeID string    `gorm:"type:varchar(255);not null;primaryKey;index" json:"followee_id"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GormFollow
+func (GormFollow) TableName() string {
+	return "follows"
+}
+
+// GormGroup represents a named group of players using GORM
+type GormGroup struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name       string    `gorm:"type:varchar(255);not null" json:"name"`
+	OwnerID    string    `gorm:"type:varchar(255);not null;index" json:"owner_id"`
+	InviteCode string    `gorm:"type:varchar(32);not null;uniqueIndex" json:"invite_code"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Filled by queries that count members, not a column
+	MemberCount int `gorm:"->;-:migration" json:"member_count"`
+}
+
+// TableName specifies the table name for GormGroup
+func (GormGroup) TableName() string {
+	return "groups"
+}
+
+// ToGroup converts GormGroup to Group
+func (gg *GormGroup) ToGroup() *Group {
+	return &Group{
+		ID:          gg.ID,
+		Name:        gg.Name,
+		OwnerID:     gg.OwnerID,
+		InviteCode:  gg.InviteCode,
+		MemberCount: gg.MemberCount,
+		CreatedAt:   gg.CreatedAt,
+		UpdatedAt:   gg.UpdatedAt,
+	}
+}
+
+// FromGroup converts Group to GormGroup
+func (gg *GormGroup) FromGroup(g *Group) {
+	gg.ID = g.ID
+	gg.Name = g.Name
+	gg.OwnerID = g.OwnerID
+	gg.InviteCode = g.InviteCode
+	gg.CreatedAt = g.CreatedAt
+	gg.UpdatedAt = g.UpdatedAt
+}
+
+// GormGroupMember represents a group membership using GORM
+type GormGroupMember struct {
+	GroupID  uuid.UUID `gorm:"type:uuid;not null;primaryKey" json:"group_id"`
+	UserID   string    `gorm:"type:varchar(255);not null;primaryKey;index" json:"user_id"`
+	JoinedAt time.Time `gorm:"autoCreateTime" json:"joined_at"`
+}
+
+// TableName specifies the table name for GormGroupMember
+func (GormGroupMember) TableName() string {
+	return "group_members"
+}
+
+// GormWebhook represents a webhook endpoint using GORM
+type GormWebhook struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	URL       string    `gorm:"type:varchar(2048);not null" json:"url"`
+	Secret    string    `gorm:"type:varchar(128);not null" json:"secret"`
+	Events    string    `gorm:"type:varchar(255);not null" json:"events"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GormWebhook
+func (GormWebhook) TableName() string {
+	return "webhooks"
+}
+
+// ToWebhook converts GormWebhook to Webhook
+func (gw *GormWebhook) ToWebhook() *Webhook {
+	return &Webhook{
+		ID:        gw.ID,
+		URL:       gw.URL,
+		Secret:    gw.Secret,
+		Events:    ParseWebhookEvents(gw.Events),
+		Active:    gw.Active,
+		CreatedAt: gw.CreatedAt,
+		UpdatedAt: gw.UpdatedAt,
+	}
+}
+
+// FromWebhook converts Webhook to GormWebhook
+func (gw *GormWebhook) FromWebhook(w *Webhook) {
+	gw.ID = w.ID
+	gw.URL = w.URL
+	gw.Secret = w.Secret
+	gw.Events = JoinWebhookEvents(w.Events)
+	gw.Active = w.Active
+	gw.CreatedAt = w.CreatedAt
+	gw.UpdatedAt = w.UpdatedAt
+}
+
+// GormWebhookDelivery represents a webhook delivery using GORM
+type GormWebhookDelivery struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	WebhookID      uuid.UUID  `gorm:"type:uuid;not null;index:idx_webhook_deliveries_webhook_id" json:"webhook_id"`
+	Event          string     `gorm:"type:varchar(64);not null" json:"event"`
+	Payload        string     `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string     `gorm:"type:varchar(16);not null;default:pending" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	LastError      string     `gorm:"type:text;not null;default:''" json:"last_error"`
+	LastStatusCode int        `gorm:"not null;default:0" json:"last_status_code"`
+	NextAttemptAt  time.Time  `gorm:"not null;index:idx_webhook_deliveries_due" json:"next_attempt_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime;index:idx_webhook_deliveries_webhook_id" json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// TableName specifies the table name for GormWebhookDelivery
+func (GormWebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// ToWebhookDelivery converts GormWebhookDelivery to WebhookDelivery
+func (gd *GormWebhookDelivery) ToWebhookDelivery() *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:             gd.ID,
+		WebhookID:      gd.WebhookID,
+		Event:          WebhookEvent(gd.Event),
+		Payload:        json.RawMessage(gd.Payload),
+		Status:         WebhookDeliveryStatus(gd.Status),
+		Attempts:       gd.Attempts,
+		LastError:      gd.LastError,
+		LastStatusCode: gd.LastStatusCode,
+		NextAttemptAt:  gd.NextAttemptAt,
+		CreatedAt:      gd.CreatedAt,
+		DeliveredAt:    gd.DeliveredAt,
+	}
+}
+
+// FromWebhookDelivery converts WebhookDelivery to GormWebhookDelivery
+func (gd *GormWebhookDelivery) FromWebhookDelivery(d *WebhookDelivery) {
+	gd.ID = d.ID
+	gd.WebhookID = d.WebhookID
+	gd.Event = string(d.Event)
+	gd.Payload = string(d.Payload)
+	gd.Status = string(d.Status)
+	gd.Attempts = d.Attempts
+	gd.LastError = d.LastError
+	gd.LastStatusCode = d.LastStatusCode
+	gd.NextAttemptAt = d.NextAttemptAt
+	gd.CreatedAt = d.CreatedAt
+	gd.DeliveredAt = d.DeliveredAt
+}
+
+// JoinWebhookEvents encodes webhook events for the events column
+func JoinWebhookEvents(events []WebhookEvent) string {
+	names := make([]string, len(events))
+	for i, event := range events {
+		names[i] = string(event)
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseWebhookEvents decodes the events column of a webhook
+func ParseWebhookEvents(value string) []WebhookEvent {
+	events := []WebhookEvent{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			events = append(events, WebhookEvent(name))
+		}
+	}
+	return events
+}
+
+// GormTournament represents a tournament using GORM
+type GormTournament struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name              string    `gorm:"type:varchar(255);not null" json:"name"`
+	Description       string    `gorm:"type:text;not null;default:''" json:"description"`
+	RegistrationStart time.Time `gorm:"not null" json:"registration_start"`
+	RegistrationEnd   time.Time `gorm:"not null" json:"registration_end"`
+	MaxAttempts       int       `gorm:"not null" json:"max_attempts"`
+	Status            string    `gorm:"type:varchar(16);not null;default:upcoming;index:idx_tournaments_status" json:"status"`
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Filled by queries that count participants, not a column
+	ParticipantCount int `gorm:"->;-:migration" json:"participant_count"`
+}
+
+// TableName specifies the table name for GormTournament
+func (GormTournament) TableName() string {
+	return "tournaments"
+}
+
+// ToTournament converts GormTournament to Tournament, without its rounds
+func (gt *GormTournament) ToTournament() *Tournament {
+	return &Tournament{
+		ID:                gt.ID,
+		Name:              gt.Name,
+		Description:       gt.Description,
+		RegistrationStart: gt.RegistrationStart,
+		RegistrationEnd:   gt.RegistrationEnd,
+		MaxAttempts:       gt.MaxAttempts,
+		Status:            TournamentStatus(gt.Status),
+		ParticipantCount:  gt.ParticipantCount,
+		CreatedAt:         gt.CreatedAt,
+		UpdatedAt:         gt.UpdatedAt,
+	}
+}
+
+// FromTournament converts Tournament to GormTournament
+func (gt *GormTournament) FromTournament(t *Tournament) {
+	gt.ID = t.ID
+	gt.Name = t.Name
+	gt.Description = t.Description
+	gt.RegistrationStart = t.RegistrationStart
+	gt.RegistrationEnd = t.RegistrationEnd
+	gt.MaxAttempts = t.MaxAttempts
+	gt.Status = string(t.Status)
+	gt.CreatedAt = t.CreatedAt
+	gt.UpdatedAt = t.UpdatedAt
+}
+
+// GormTournamentRound represents a tournament round using GORM
+type GormTournamentRound struct {
+	TournamentID uuid.UUID `gorm:"type:uuid;not null;primaryKey" json:"tournament_id"`
+	Number       int       `gorm:"not null;primaryKey" json:"number"`
+	StartAt      time.Time `gorm:"not null" json:"start_at"`
+	EndAt        time.Time `gorm:"not null" json:"end_at"`
+	Seed         int64     `gorm:"not null" json:"seed"`
+	Advance      int       `gorm:"not null;default:0" json:"advance"`
+	Status       string    `gorm:"type:varchar(16);not null;default:scheduled" json:"status"`
+}
+
+// TableName specifies the table name for GormTournamentRound
+func (GormTournamentRound) TableName() string {
+	return "tournament_rounds"
+}
+
+// ToTournamentRound converts GormTournamentRound to TournamentRound
+func (gr *GormTournamentRound) ToTournamentRound() *TournamentRound {
+	return &TournamentRound{
+		TournamentID: gr.TournamentID,
+		Number:       gr.Number,
+		StartAt:      gr.StartAt,
+		EndAt:        gr.EndAt,
+		Seed:         gr.Seed,
+		Advance:      gr.Advance,
+		Status:       TournamentRoundStatus(gr.Status),
+	}
+}
+
+// FromTournamentRound converts TournamentRound to GormTournamentRound
+func (gr *GormTournamentRound) FromTournamentRound(r *TournamentRound) {
+	gr.TournamentID = r.TournamentID
+	gr.Number = r.Number
+	gr.StartAt = r.StartAt
+	gr.EndAt = r.EndAt
+	gr.Seed = r.Seed
+	gr.Advance = r.Advance
+	gr.Status = string(r.Status)
+}
+
+// GormTournamentParticipant represents a tournament registration using GORM
+type GormTournamentParticipant struct {
+	TournamentID    uuid.UUID `gorm:"type:uuid;not null;primaryKey" json:"tournament_id"`
+	UserID          string    `gorm:"type:varchar(255);not null;primaryKey;index" json:"user_id"`
+	RegisteredAt    time.Time `gorm:"autoCreateTime" json:"registered_at"`
+	EliminatedRound *int      `json:"eliminated_round"`
+	FinalRank       *int      `json:"final_rank"`
+}
+
+// TableName specifies the table name for GormTournamentParticipant
+func (GormTournamentParticipant) TableName() string {
+	return "tournament_participants"
+}
+
+// GormTournamentAttempt represents a tournament attempt using GORM
+type GormTournamentAttempt struct {
+	GameID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"game_id"`
+	TournamentID uuid.UUID `gorm:"type:uuid;not null;index:idx_tournament_attempts_round,priority:1" json:"tournament_id"`
+	Round        int       `gorm:"not null;index:idx_tournament_attempts_round,priority:2" json:"round"`
+	UserID       string    `gorm:"type:varchar(255);not null;index:idx_tournament_attempts_round,priority:3" json:"user_id"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName specifies the table name for GormTournamentAttempt
+func (GormTournamentAttempt) TableName() string {
+	return "tournament_attempts"
+}
+
+// GormRefreshToken represents a refresh token using GORM
+type GormRefreshToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    string     `gorm:"type:varchar(255);not null;index" json:"user_id"`
+	FamilyID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// TableName specifies the table name for GormRefreshToken
+func (GormRefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// ToRefreshToken converts GormRefreshToken to RefreshToken
+func (gt *GormRefreshToken) ToRefreshToken() *RefreshToken {
+	return &RefreshToken{
+		ID:        gt.ID,
+		UserID:    gt.UserID,
+		FamilyID:  gt.FamilyID,
+		TokenHash: gt.TokenHash,
+		ExpiresAt: gt.ExpiresAt,
+		CreatedAt: gt.CreatedAt,
+		RotatedAt: gt.RotatedAt,
+		RevokedAt: gt.RevokedAt,
+	}
+}
+
+// FromRefreshToken converts RefreshToken to GormRefreshToken
+func (gt *GormRefreshToken) FromRefreshToken(t *RefreshToken) {
+	gt.ID = t.ID
+	gt.UserID = t.UserID
+	gt.FamilyID = t.FamilyID
+	gt.TokenHash = t.TokenHash
+	gt.ExpiresAt = t.ExpiresAt
+	gt.CreatedAt = t.CreatedAt
+	gt.RotatedAt = t.RotatedAt
+	gt.RevokedAt = t.RevokedAt
+}
+
+// GormUserIdentity represents a provider identity of a user using GORM
+type GormUserIdentity struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     string    `gorm:"type:varchar(255);not null;index" json:"user_id"`
+	Provider   string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_user_identities_provider,priority:1" json:"provider"`
+	ProviderID string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider,priority:2" json:"provider_id"`
+	Email      string    `gorm:"type:varchar(255);not null;default:''" json:"email"`
+	Name       string    `gorm:"type:varchar(255);not null;default:''" json:"name"`
+	Avatar     string    `gorm:"type:varchar(500);not null;default:''" json:"avatar"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// TableName specifies the table name for GormUserIdentity
+func (GormUserIdentity) TableName() string {
+	return "user_identities"
+}
+
+// ToUserIdentity converts GormUserIdentity to UserIdentity
+func (gi *GormUserIdentity) ToUserIdentity() *UserIdentity {
+	return &UserIdentity{
+		ID:         gi.ID,
+		UserID:     gi.UserID,
+		Provider:   gi.Provider,
+		ProviderID: gi.ProviderID,
+		Email:      gi.Email,
+		Name:       gi.Name,
+		Avatar:     gi.Avatar,
+		CreatedAt:  gi.CreatedAt,
+		LastUsedAt: gi.LastUsedAt,
+	}
+}
+
+// FromUserIdentity converts UserIdentity to GormUserIdentity
+func (gi *GormUserIdentity) FromUserIdentity(i *UserIdentity) {
+	gi.ID = i.ID
+	gi.UserID = i.UserID
+	gi.Provider = i.Provider
+	gi.ProviderID = i.ProviderID
+	gi.Email = i.Email
+	gi.Name = i.Name
+	gi.Avatar = i.Avatar
+	gi.CreatedAt = i.CreatedAt
+	gi.LastUsedAt = i.LastUsedAt
+}
+
+// GormRole represents a role using GORM
+type GormRole struct {
+	Name        string    `gorm:"type:varchar(64);primaryKey" json:"name"`
+	Description string    `gorm:"type:varchar(255);not null;default:''" json:"description"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName specifies the table name for GormRole
+func (GormRole) TableName() string {
+	return "roles"
+}
+
+// ToRole converts GormRole to Role, the permissions are loaded separately
+func (gr *GormRole) ToRole() *Role {
+	return &Role{
+		Name:        gr.Name,
+		Description: gr.Description,
+		CreatedAt:   gr.CreatedAt,
+		UpdatedAt:   gr.UpdatedAt,
+	}
+}
+
+// GormRolePermission represents a permission of a role using GORM
+type GormRolePermission struct {
+	Role       string `gorm:"type:varchar(64);not null;primaryKey" json:"role"`
+	Permission string `gorm:"type:varchar(64);not null;primaryKey" json:"permission"`
+}
+
+// TableName specifies the table name for GormRolePermission
+func (GormRolePermission) TableName() string {
+	return "role_permissions"
+}
+
+// GormUserRole represents a role granted to a user using GORM
+type GormUserRole struct {
+	UserID    string    `gorm:"type:varchar(255);not null;primaryKey" json:"user_id"`
+	Role      string    `gorm:"type:varchar(64);not null;primaryKey;index" json:"role"`
+	GrantedBy string    `gorm:"type:varchar(255);not null;default:''" json:"granted_by"`
+	GrantedAt time.Time `gorm:"autoCreateTime" json:"granted_at"`
+}
+
+// TableName specifies the table name for GormUserRole
+func (GormUserRole) TableName() string {
+	return "user_roles"
+}
+
+// ToUserRole converts GormUserRole to UserRole
+func (gr *GormUserRole) ToUserRole() *UserRole {
+	return &UserRole{
+		UserID:    gr.UserID,
+		Role:      gr.Role,
+		GrantedBy: gr.GrantedBy,
+		GrantedAt: gr.GrantedAt,
+	}
+}
+
+// GormAPIToken represents a personal API token using GORM
+type GormAPIToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     string     `gorm:"type:varchar(255);not null;index" json:"user_id"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	TokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"`
+	Scopes     string     `gorm:"type:varchar(255);not null" json:"scopes"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// TableName specifies the table name for GormAPIToken
+func (GormAPIToken) TableName() string {
+	return "api_tokens"
+}
+
+// ToAPIToken converts GormAPIToken to APIToken
+func (gt *GormAPIToken) ToAPIToken() *APIToken {
+	return &APIToken{
+		ID:         gt.ID,
+		UserID:     gt.UserID,
+		Name:       gt.Name,
+		TokenHash:  gt.TokenHash,
+		Prefix:     gt.Prefix,
+		Scopes:     ParseTokenScopes(gt.Scopes),
+		CreatedAt:  gt.CreatedAt,
+		ExpiresAt:  gt.ExpiresAt,
+		LastUsedAt: gt.LastUsedAt,
+		RevokedAt:  gt.RevokedAt,
+	}
+}
+
+// FromAPIToken converts APIToken to GormAPIToken
+func (gt *GormAPIToken) FromAPIToken(t *APIToken) {
+	gt.ID = t.ID
+	gt.UserID = t.UserID
+	gt.Name = t.Name
+	gt.TokenHash = t.TokenHash
+	gt.Prefix = t.Prefix
+	gt.Scopes = JoinTokenScopes(t.Scopes)
+	gt.CreatedAt = t.CreatedAt
+	gt.ExpiresAt = t.ExpiresAt
+	gt.LastUsedAt = t.LastUsedAt
+	gt.RevokedAt = t.RevokedAt
+}
+
+// GormModerationAction represents an entry of the moderation audit trail using GORM
+type GormModerationAction struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID         string     `gorm:"type:varchar(255);not null;index" json:"user_id"`
+	ModeratorID    string     `gorm:"type:varchar(255);not null;default:''" json:"moderator_id"`
+	Status         string     `gorm:"type:varchar(16);not null" json:"status"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	Reason         string     `gorm:"type:text;not null;default:''" json:"reason"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName specifies the table name for GormModerationAction
+func (GormModerationAction) TableName() string {
+	return "moderation_actions"
+}
+
+// ToModerationAction converts GormModerationAction to ModerationAction
+func (ga *GormModerationAction) ToModerationAction() *ModerationAction {
+	return &ModerationAction{
+		ID:             ga.ID,
+		UserID:         ga.UserID,
+		ModeratorID:    ga.ModeratorID,
+		Status:         ModerationStatus(ga.Status),
+		SuspendedUntil: ga.SuspendedUntil,
+		Reason:         ga.Reason,
+		CreatedAt:      ga.CreatedAt,
+	}
+}
+
+// FromModerationAction converts ModerationAction to GormModerationAction
+func (ga *GormModerationAction) FromModerationAction(a *ModerationAction) {
+	ga.ID = a.ID
+	ga.UserID = a.UserID
+	ga.ModeratorID = a.ModeratorID
+	ga.Status = string(a.Status)
+	ga.SuspendedUntil = a.SuspendedUntil
+	ga.Reason = a.Reason
+	ga.CreatedAt = a.CreatedAt
+}
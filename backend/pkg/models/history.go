@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GameModeClassic is the mode of regular games
+const GameModeClassic = "classic"
+
+// GameAbandonAfter is how long an unfinished game may sit idle before it counts as abandoned
+const GameAbandonAfter = time.Hour
+
+// GameStatus describes how a game ended
+type GameStatus string
+
+const (
+	GameStatusActive    GameStatus = "active"
+	GameStatusFinished  GameStatus = "finished"
+	GameStatusAbandoned GameStatus = "abandoned"
+)
+
+// GameStatusOf returns the status of a game at the given time
+func GameStatusOf(gameOver, victory bool, updatedAt, now time.Time) GameStatus {
+	if gameOver || victory {
+		return GameStatusFinished
+	}
+	if now.Sub(updatedAt) > GameAbandonAfter {
+		return GameStatusAbandoned
+	}
+	return GameStatusActive
+}
+
+// GameHistoryFilter selects games from a user's history, zero fields do not filter
+type GameHistoryFilter struct {
+	// From and To limit the games to those created within [From, To)
+	From *time.Time
+	To   *time.Time
+
+	Mode     string
+	Status   GameStatus
+	MinScore int
+}
+
+// GameSummary represents a game in a user's history
+type GameSummary struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	Mode      string     `json:"mode" db:"mode"`
+	Score     int        `json:"score" db:"score"`
+	BestTile  int        `json:"best_tile" db:"best_tile"`
+	GameOver  bool       `json:"game_over" db:"game_over"`
+	Victory   bool       `json:"victory" db:"victory"`
+	Status    GameStatus `json:"status" db:"-"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// TileCount counts the games that reached a best tile
+type TileCount struct {
+	Tile  int `json:"tile" db:"tile"`
+	Count int `json:"count" db:"count"`
+}
+
+// ScoreTrendPoint summarizes the games played on one day
+type ScoreTrendPoint struct {
+	Date         time.Time `json:"date" db:"date"`
+	Games        int       `json:"games" db:"games"`
+	AverageScore float64   `json:"average_score" db:"average_score"`
+	BestScore    int       `json:"best_score" db:"best_score"`
+}
+
+// GameStats summarizes a user's games
+type GameStats struct {
+	GamesPlayed  int               `json:"games_played"`
+	Wins         int               `json:"wins"`
+	WinRate      float64           `json:"win_rate"`
+	AverageScore float64           `json:"average_score"`
+	MedianScore  float64           `json:"median_score"`
+	BestScore    int               `json:"best_score"`
+	BestTiles    []TileCount       `json:"best_tiles"`
+	ScoreTrend   []ScoreTrendPoint `json:"score_trend"`
+}
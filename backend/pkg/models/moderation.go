@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ModerationStatus is the standing of a user set by moderators
+type ModerationStatus string
+
+const (
+	ModerationActive ModerationStatus = "active"
+	// Suspended users cannot sign in or play until their suspension ends
+	ModerationSuspended ModerationStatus = "suspended"
+	// Banned users cannot sign in or play, and are hidden from leaderboards and profiles
+	ModerationBanned ModerationStatus = "banned"
+	// Shadow-banned users play as usual but are hidden from leaderboards, without being told
+	ModerationShadowBanned ModerationStatus = "shadow_banned"
+)
+
+// AllModerationStatuses returns every status a moderator can set
+func AllModerationStatuses() []ModerationStatus {
+	return []ModerationStatus{ModerationActive, ModerationSuspended, ModerationBanned, ModerationShadowBanned}
+}
+
+// IsValid reports whether the status is one moderators can set
+func (s ModerationStatus) IsValid() bool {
+	for _, status := range AllModerationStatuses() {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Moderation returns the status of the user at a time, suspensions that have ended count as active
+func (u *User) Moderation(now time.Time) ModerationStatus {
+	switch u.ModerationStatus {
+	case "":
+		return ModerationActive
+	case ModerationSuspended:
+		if u.SuspendedUntil == nil || !now.Before(*u.SuspendedUntil) {
+			return ModerationActive
+		}
+	}
+	return u.ModerationStatus
+}
+
+// Blocked reports whether the user is kept from signing in and playing at a time
+func (u *User) Blocked(now time.Time) bool {
+	status := u.Moderation(now)
+	return status == ModerationSuspended || status == ModerationBanned
+}
+
+// Hidden reports whether the user is kept off leaderboards and announcements
+func (u *User) Hidden() bool {
+	return u.ModerationStatus == ModerationBanned || u.ModerationStatus == ModerationShadowBanned
+}
+
+// moderationSeverity orders statuses from least to most strict
+var moderationSeverity = map[ModerationStatus]int{
+	ModerationActive:       0,
+	ModerationSuspended:    1,
+	ModerationShadowBanned: 2,
+	ModerationBanned:       3,
+}
+
+// StricterModeration returns the stricter standing of two users at a time, for merging their accounts.
+// Bans are stricter than shadow bans, which are stricter than suspensions, of two suspensions the longer one is kept.
+func StricterModeration(a, b *User, now time.Time) (ModerationStatus, *time.Time) {
+	statusA, statusB := a.Moderation(now), b.Moderation(now)
+	switch {
+	case moderationSeverity[statusB] > moderationSeverity[statusA]:
+		a, statusA = b, statusB
+	case statusA == ModerationSuspended && statusB == ModerationSuspended && b.SuspendedUntil.After(*a.SuspendedUntil):
+		a = b
+	}
+
+	if statusA == ModerationSuspended {
+		return statusA, a.SuspendedUntil
+	}
+	return statusA, nil
+}
+
+// ModerationAction is an entry of the moderation audit trail, recording a status a moderator set and why
+type ModerationAction struct {
+	ID             uuid.UUID        `json:"id" db:"id"`
+	UserID         string           `json:"user_id" db:"user_id"`
+	ModeratorID    string           `json:"moderator_id" db:"moderator_id"`
+	Status         ModerationStatus `json:"status" db:"status"`
+	SuspendedUntil *time.Time       `json:"suspended_until,omitempty" db:"suspended_until"`
+	Reason         string           `json:"reason" db:"reason"`
+	CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+}
+
+// ModerateUserRequest represents a request to set the moderation status of a user
+type ModerateUserRequest struct {
+	Status ModerationStatus `json:"status" binding:"required"`
+	// End of a suspension, required when suspending
+	SuspendedUntil *time.Time `json:"suspended_until"`
+	Reason         string     `json:"reason" binding:"required,max=500"`
+}
+
+// UserModeration is the moderation status of a user with its audit trail, newest first
+type UserModeration struct {
+	UserID         string             `json:"user_id"`
+	UserName       string             `json:"user_name"`
+	Status         ModerationStatus   `json:"status"`
+	SuspendedUntil *time.Time         `json:"suspended_until,omitempty"`
+	Actions        []ModerationAction `json:"actions,omitempty"`
+}
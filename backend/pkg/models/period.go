@@ -0,0 +1,121 @@
+package models
+
+import "time"
+
+// LeaderboardPeriod represents the time range covered by a periodic leaderboard
+type LeaderboardPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// IsPeriodic reports whether the leaderboard type is bounded by a calendar period
+func (t LeaderboardType) IsPeriodic() bool {
+	return t == LeaderboardDaily || t == LeaderboardWeekly || t == LeaderboardMonthly
+}
+
+// LeaderboardQuery selects the games a leaderboard is ranked from
+type LeaderboardQuery struct {
+	Type LeaderboardType
+
+	// Period limits the leaderboard to games created within it, nil means all time
+	Period *LeaderboardPeriod
+
+	// SeasonID identifies the season of a season leaderboard
+	SeasonID string
+
+	// FriendsOf limits the leaderboard to the given user and the users they follow
+	FriendsOf string
+
+	// GroupID limits the leaderboard to members of the given group
+	GroupID string
+
+	// TournamentID and TournamentRound rank the attempts of a tournament round instead.
+	// Tournament attempts are left out of every other leaderboard.
+	TournamentID    string
+	TournamentRound int
+
+	// Viewer is a shadow-banned user who is ranked on the leaderboard they see, so they are not told about the ban
+	Viewer string
+}
+
+// IsFiltered reports whether the leaderboard is limited to a subset of users or ranks a shadow-banned viewer.
+// Filtered leaderboards depend on who is asking and are not cached.
+func (q LeaderboardQuery) IsFiltered() bool {
+	return q.FriendsOf != "" || q.GroupID != "" || q.Viewer != ""
+}
+
+// ViewedBy returns the leaderboard as the user sees it, shadow-banned users see themselves ranked
+func (q LeaderboardQuery) ViewedBy(user *User) LeaderboardQuery {
+	if user != nil && user.ModerationStatus == ModerationShadowBanned {
+		q.Viewer = user.ID
+	}
+	return q
+}
+
+// CacheKey returns a key identifying the leaderboard, distinct for every period
+func (q LeaderboardQuery) CacheKey() string {
+	if q.SeasonID != "" {
+		return string(q.Type) + ":" + q.SeasonID
+	}
+	if q.Period == nil {
+		return string(q.Type)
+	}
+	return string(q.Type) + ":" + q.Period.Start.Format("20060102")
+}
+
+// PeriodCalendar computes leaderboard period boundaries in a fixed timezone
+type PeriodCalendar struct {
+	Location  *time.Location
+	WeekStart time.Weekday
+}
+
+// PeriodContaining returns the period of the leaderboard type that contains the given time.
+// The all-time leaderboard has no period and returns false.
+func (c PeriodCalendar) PeriodContaining(leaderboardType LeaderboardType, at time.Time) (LeaderboardPeriod, bool) {
+	at = at.In(c.location())
+	year, month, day := at.Date()
+	loc := at.Location()
+
+	switch leaderboardType {
+	case LeaderboardDaily:
+		start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		return LeaderboardPeriod{Start: start, End: start.AddDate(0, 0, 1)}, true
+	case LeaderboardWeekly:
+		daysSinceWeekStart := (int(at.Weekday()) - int(c.WeekStart) + 7) % 7
+		start := time.Date(year, month, day-daysSinceWeekStart, 0, 0, 0, 0, loc)
+		return LeaderboardPeriod{Start: start, End: start.AddDate(0, 0, 7)}, true
+	case LeaderboardMonthly:
+		start := time.Date(year, month, 1, 0, 0, 0, 0, loc)
+		return LeaderboardPeriod{Start: start, End: start.AddDate(0, 1, 0)}, true
+	default:
+		return LeaderboardPeriod{}, false
+	}
+}
+
+// Previous returns the period of the same leaderboard type directly before the given one
+func (c PeriodCalendar) Previous(leaderboardType LeaderboardType, period LeaderboardPeriod) LeaderboardPeriod {
+	previous, _ := c.PeriodContaining(leaderboardType, period.Start.Add(-time.Nanosecond))
+	return previous
+}
+
+// Query returns the leaderboard query for the period of the leaderboard type that contains the given time
+func (c PeriodCalendar) Query(leaderboardType LeaderboardType, at time.Time) LeaderboardQuery {
+	query := LeaderboardQuery{Type: leaderboardType}
+	if period, ok := c.PeriodContaining(leaderboardType, at); ok {
+		query.Period = &period
+	}
+	return query
+}
+
+// Date returns midnight of a calendar date in the calendar's timezone
+func (c PeriodCalendar) Date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, c.location())
+}
+
+// location returns the calendar timezone, defaulting to UTC
+func (c PeriodCalendar) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
@@ -0,0 +1,28 @@
+package models
+
+// PlayerProfile represents the public profile of a player
+type PlayerProfile struct {
+	UserID      string             `json:"user_id"`
+	Name        string             `json:"name"`
+	Avatar      string             `json:"avatar"`
+	BestScores  []ProfileBestScore `json:"best_scores"`
+	HighestTile int                `json:"highest_tile"`
+	RecentGames []GameSummary      `json:"recent_games"`
+	Badges      []UserBadge        `json:"badges"`
+
+	// Hidden is set when the profile is hidden and shown to its owner only
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// ProfileBestScore represents a player's best score and rank on a current leaderboard
+type ProfileBestScore struct {
+	Type   LeaderboardType    `json:"type"`
+	Score  int                `json:"score"`
+	Rank   int                `json:"rank"`
+	Period *LeaderboardPeriod `json:"period,omitempty"`
+}
+
+// UserSettings represents the settings a user can change
+type UserSettings struct {
+	ProfileHidden bool `json:"profile_hidden"`
+}
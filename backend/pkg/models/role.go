@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Permission allows an action on the admin API, users get permissions through their roles
+type Permission string
+
+const (
+	// PermissionAll grants every permission, including ones added later
+	PermissionAll                Permission = "*"
+	PermissionManageLeaderboards Permission = "leaderboards:manage"
+	PermissionManageSeasons      Permission = "seasons:manage"
+	PermissionManageWebhooks     Permission = "webhooks:manage"
+	PermissionManageTournaments  Permission = "tournaments:manage"
+	PermissionManageUsers        Permission = "users:manage"
+	PermissionModerateUsers      Permission = "users:moderate"
+	PermissionManageRoles        Permission = "roles:manage"
+)
+
+// RoleAdmin is the built-in role with every permission, granted to the first admin by the admin command
+const RoleAdmin = "admin"
+
+// AllPermissions returns every permission a role can have
+func AllPermissions() []Permission {
+	return []Permission{
+		PermissionAll,
+		PermissionManageLeaderboards,
+		PermissionManageSeasons,
+		PermissionManageWebhooks,
+		PermissionManageTournaments,
+		PermissionManageUsers,
+		PermissionModerateUsers,
+		PermissionManageRoles,
+	}
+}
+
+// IsValid checks whether the permission is one of the known permissions
+func (p Permission) IsValid() bool {
+	for _, permission := range AllPermissions() {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission checks whether a set of permissions grants a permission
+func HasPermission(permissions []Permission, permission Permission) bool {
+	for _, p := range permissions {
+		if p == permission || p == PermissionAll {
+			return true
+		}
+	}
+	return false
+}
+
+// Role is a named set of permissions
+type Role struct {
+	Name        string       `json:"name" db:"name"`
+	Description string       `json:"description" db:"description"`
+	Permissions []Permission `json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// RoleRequest represents a request to create or change a role
+type RoleRequest struct {
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" binding:"required"`
+}
+
+// UserRole is a role granted to a user
+type UserRole struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	Role      string    `json:"role" db:"role"`
+	GrantedBy string    `json:"granted_by,omitempty" db:"granted_by"`
+	GrantedAt time.Time `json:"granted_at" db:"granted_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Season represents an admin-defined competition period with its own leaderboard
+type Season struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	StartAt    time.Time  `json:"start_at" db:"start_at"`
+	EndAt      time.Time  `json:"end_at" db:"end_at"`
+	RewardTopN int        `json:"reward_top_n" db:"reward_top_n"`
+	ClosedAt   *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Period returns the time range covered by the season
+func (s *Season) Period() LeaderboardPeriod {
+	return LeaderboardPeriod{Start: s.StartAt, End: s.EndAt}
+}
+
+// IsClosed reports whether the season's final standings have been archived
+func (s *Season) IsClosed() bool {
+	return s.ClosedAt != nil
+}
+
+// Query returns the leaderboard query for the season
+func (s *Season) Query() LeaderboardQuery {
+	period := s.Period()
+	return LeaderboardQuery{
+		Type:     LeaderboardSeason,
+		Period:   &period,
+		SeasonID: s.ID.String(),
+	}
+}
+
+// BadgeTitle returns the title of the badge awarded for finishing the season at the given rank
+func (s *Season) BadgeTitle(rank int) string {
+	return fmt.Sprintf("%s #%d", s.Name, rank)
+}
+
+// SeasonRequest represents an admin request to create or update a season
+type SeasonRequest struct {
+	Name       string    `json:"name"`
+	StartAt    time.Time `json:"start_at"`
+	EndAt      time.Time `json:"end_at"`
+	RewardTopN int       `json:"reward_top_n"`
+}
+
+// UserBadge represents a badge awarded to a user, such as a top season finish
+type UserBadge struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	SeasonID  uuid.UUID `json:"season_id" db:"season_id"`
+	Title     string    `json:"title" db:"title"`
+	Rank      int       `json:"rank" db:"rank"`
+	AwardedAt time.Time `json:"awarded_at" db:"awarded_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LeaderboardGroupFriends is the group parameter value selecting the friends leaderboard
+const LeaderboardGroupFriends = "friends"
+
+// Friend represents a user on the other side of a follow relationship
+type Friend struct {
+	UserID     string    `json:"user_id" db:"user_id"`
+	UserName   string    `json:"user_name" db:"user_name"`
+	UserAvatar string    `json:"user_avatar" db:"user_avatar"`
+	FollowedAt time.Time `json:"followed_at" db:"followed_at"`
+}
+
+// Group represents a named group of players, such as a team or a company, with its own leaderboard
+type Group struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	OwnerID     string    `json:"owner_id" db:"owner_id"`
+	InviteCode  string    `json:"invite_code,omitempty" db:"invite_code"`
+	MemberCount int       `json:"member_count" db:"member_count"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// GroupMember represents a member of a group
+type GroupMember struct {
+	UserID     string    `json:"user_id" db:"user_id"`
+	UserName   string    `json:"user_name" db:"user_name"`
+	UserAvatar string    `json:"user_avatar" db:"user_avatar"`
+	JoinedAt   time.Time `json:"joined_at" db:"joined_at"`
+}
+
+// GroupRequest represents a request to create or rename a group
+type GroupRequest struct {
+	Name string `json:"name"`
+}
+
+// JoinGroupRequest represents a request to join a group with its invite code
+type JoinGroupRequest struct {
+	InviteCode string `json:"invite_code"`
+}
@@ -0,0 +1,163 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GameModeTournament is the mode of tournament attempts, which are ranked on their round rather than on leaderboards
+const GameModeTournament = "tournament"
+
+// TournamentStatus is the stage a tournament is in
+type TournamentStatus string
+
+const (
+	// Upcoming tournaments have not started their first round, players may register until registration ends
+	TournamentUpcoming TournamentStatus = "upcoming"
+	TournamentRunning  TournamentStatus = "running"
+	TournamentFinished TournamentStatus = "finished"
+)
+
+// TournamentRoundStatus is the stage a tournament round is in
+type TournamentRoundStatus string
+
+const (
+	TournamentRoundScheduled TournamentRoundStatus = "scheduled"
+	TournamentRoundActive    TournamentRoundStatus = "active"
+	TournamentRoundFinished  TournamentRoundStatus = "finished"
+)
+
+// Tournament represents an admin-organized competition played over scheduled rounds.
+// Players register during the registration window, and after every round only the best
+// round scores advance to the next round.
+type Tournament struct {
+	ID                uuid.UUID         `json:"id" db:"id"`
+	Name              string            `json:"name" db:"name"`
+	Description       string            `json:"description" db:"description"`
+	RegistrationStart time.Time         `json:"registration_start" db:"registration_start"`
+	RegistrationEnd   time.Time         `json:"registration_end" db:"registration_end"`
+	MaxAttempts       int               `json:"max_attempts" db:"max_attempts"`
+	Status            TournamentStatus  `json:"status" db:"status"`
+	Rounds            []TournamentRound `json:"rounds"`
+	ParticipantCount  int               `json:"participant_count" db:"participant_count"`
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// RegistrationOpen reports whether players can register at the given time
+func (t *Tournament) RegistrationOpen(at time.Time) bool {
+	return t.Status == TournamentUpcoming && !at.Before(t.RegistrationStart) && at.Before(t.RegistrationEnd)
+}
+
+// Round returns the round with the given number, or nil if there is none
+func (t *Tournament) Round(number int) *TournamentRound {
+	for i := range t.Rounds {
+		if t.Rounds[i].Number == number {
+			return &t.Rounds[i]
+		}
+	}
+	return nil
+}
+
+// ActiveRound returns the round being played, or nil between rounds
+func (t *Tournament) ActiveRound() *TournamentRound {
+	for i := range t.Rounds {
+		if t.Rounds[i].Status == TournamentRoundActive {
+			return &t.Rounds[i]
+		}
+	}
+	return nil
+}
+
+// TournamentRound represents one scheduled round of a tournament.
+// Every attempt of a round is played with the round's seed, so all players get the same tiles for the same moves.
+type TournamentRound struct {
+	TournamentID uuid.UUID             `json:"tournament_id" db:"tournament_id"`
+	Number       int                   `json:"number" db:"number"`
+	StartAt      time.Time             `json:"start_at" db:"start_at"`
+	EndAt        time.Time             `json:"end_at" db:"end_at"`
+	Seed         int64                 `json:"-" db:"seed"`
+	Advance      int                   `json:"advance" db:"advance"`
+	Status       TournamentRoundStatus `json:"status" db:"status"`
+}
+
+// IsFinal reports whether the round decides the final ranking, no player advances from it
+func (r *TournamentRound) IsFinal() bool {
+	return r.Advance == 0
+}
+
+// Query returns the leaderboard query ranking the round by each player's best finished attempt
+func (r *TournamentRound) Query() LeaderboardQuery {
+	return LeaderboardQuery{
+		TournamentID:    r.TournamentID.String(),
+		TournamentRound: r.Number,
+	}
+}
+
+// TournamentParticipant represents a player registered for a tournament
+type TournamentParticipant struct {
+	TournamentID uuid.UUID `json:"tournament_id" db:"tournament_id"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	UserName     string    `json:"user_name" db:"user_name"`
+	UserAvatar   string    `json:"user_avatar" db:"user_avatar"`
+	RegisteredAt time.Time `json:"registered_at" db:"registered_at"`
+
+	// The round the player was knocked out in, nil while the player is still in the tournament
+	EliminatedRound *int `json:"eliminated_round,omitempty" db:"eliminated_round"`
+
+	// Rank in the final round, set when the tournament finishes
+	FinalRank *int `json:"final_rank,omitempty" db:"final_rank"`
+}
+
+// TournamentAttempt links a game to the tournament round it was played in
+type TournamentAttempt struct {
+	GameID       uuid.UUID `json:"game_id" db:"game_id"`
+	TournamentID uuid.UUID `json:"tournament_id" db:"tournament_id"`
+	Round        int       `json:"round" db:"round"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// TournamentStatusResponse is a player's standing in a tournament
+type TournamentStatusResponse struct {
+	Registered        bool                   `json:"registered"`
+	Participant       *TournamentParticipant `json:"participant,omitempty"`
+	Round             *TournamentRound       `json:"round,omitempty"`
+	AttemptsUsed      int                    `json:"attempts_used"`
+	AttemptsRemaining int                    `json:"attempts_remaining"`
+	RoundEntry        *LeaderboardEntry      `json:"round_entry,omitempty"`
+}
+
+// TournamentRoundMessage is sent over the WebSocket to participants when a round starts or ends
+type TournamentRoundMessage struct {
+	TournamentID   uuid.UUID `json:"tournament_id"`
+	TournamentName string    `json:"tournament_name"`
+	Round          int       `json:"round"`
+	StartAt        time.Time `json:"start_at"`
+	EndAt          time.Time `json:"end_at"`
+
+	// Set when the round ended: the player's round rank, if they finished an attempt, and whether they advanced
+	Rank     *int  `json:"rank,omitempty"`
+	Advanced *bool `json:"advanced,omitempty"`
+	Final    bool  `json:"final"`
+}
+
+// TournamentRoundRequest describes a round of a tournament being created
+type TournamentRoundRequest struct {
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+
+	// Number of players advancing to the next round, 0 for the final round
+	Advance int `json:"advance"`
+}
+
+// TournamentRequest represents a request to create a tournament
+type TournamentRequest struct {
+	Name              string                   `json:"name"`
+	Description       string                   `json:"description"`
+	RegistrationStart time.Time                `json:"registration_start"`
+	RegistrationEnd   time.Time                `json:"registration_end"`
+	MaxAttempts       int                      `json:"max_attempts"`
+	Rounds            []TournamentRoundRequest `json:"rounds"`
+}
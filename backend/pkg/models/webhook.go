@@ -0,0 +1,124 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEvent identifies the kind of event a webhook receives
+type WebhookEvent string
+
+const (
+	WebhookPersonalBest      WebhookEvent = "personal_best"
+	WebhookLeaderboardLeader WebhookEvent = "leaderboard_leader"
+	WebhookSeasonClosed      WebhookEvent = "season_closed"
+)
+
+// AllWebhookEvents returns every event webhooks can subscribe to
+func AllWebhookEvents() []WebhookEvent {
+	return []WebhookEvent{WebhookPersonalBest, WebhookLeaderboardLeader, WebhookSeasonClosed}
+}
+
+// IsValid reports whether the event is one webhooks can subscribe to
+func (e WebhookEvent) IsValid() bool {
+	for _, event := range AllWebhookEvents() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook represents an endpoint receiving signed event notifications.
+// The secret is only returned when the webhook is created.
+type Webhook struct {
+	ID        uuid.UUID      `json:"id" db:"id"`
+	URL       string         `json:"url" db:"url"`
+	Secret    string         `json:"secret,omitempty" db:"secret"`
+	Events    []WebhookEvent `json:"events" db:"events"`
+	Active    bool           `json:"active" db:"active"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// Subscribes reports whether the webhook receives the event
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// Dead deliveries ran out of attempts, they stay in the log until replayed
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+// WebhookDelivery represents one event sent, or to be sent, to a webhook
+type WebhookDelivery struct {
+	ID             uuid.UUID             `json:"id" db:"id"`
+	WebhookID      uuid.UUID             `json:"webhook_id" db:"webhook_id"`
+	Event          WebhookEvent          `json:"event" db:"event"`
+	Payload        json.RawMessage       `json:"payload" db:"payload"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	LastError      string                `json:"last_error,omitempty" db:"last_error"`
+	LastStatusCode int                   `json:"last_status_code,omitempty" db:"last_status_code"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// WebhookPayload is the JSON body posted to webhooks
+type WebhookPayload struct {
+	ID        uuid.UUID    `json:"id"`
+	Event     WebhookEvent `json:"event"`
+	CreatedAt time.Time    `json:"created_at"`
+	Data      interface{}  `json:"data"`
+}
+
+// PersonalBestData is the payload data of a personal_best event
+type PersonalBestData struct {
+	UserID       string    `json:"user_id"`
+	UserName     string    `json:"user_name"`
+	GameID       uuid.UUID `json:"game_id"`
+	Score        int       `json:"score"`
+	PreviousBest int       `json:"previous_best"`
+}
+
+// LeaderboardLeaderData is the payload data of a leaderboard_leader event, sent when a game takes the #1 spot
+type LeaderboardLeaderData struct {
+	Type     LeaderboardType    `json:"type"`
+	Period   *LeaderboardPeriod `json:"period,omitempty"`
+	SeasonID string             `json:"season_id,omitempty"`
+	Leader   LeaderboardEntry   `json:"leader"`
+	RunnerUp *LeaderboardEntry  `json:"runner_up,omitempty"`
+}
+
+// SeasonClosedData is the payload data of a season_closed event
+type SeasonClosedData struct {
+	Season    Season             `json:"season"`
+	Standings []LeaderboardEntry `json:"standings"`
+}
+
+// CreateWebhookRequest represents a request to register a webhook
+type CreateWebhookRequest struct {
+	URL    string         `json:"url" binding:"required"`
+	Events []WebhookEvent `json:"events" binding:"required,min=1"`
+}
+
+// UpdateWebhookRequest represents a request to change a webhook, omitted fields are left unchanged
+type UpdateWebhookRequest struct {
+	URL    *string        `json:"url"`
+	Events []WebhookEvent `json:"events"`
+	Active *bool          `json:"active"`
+}
@@ -61,10 +61,11 @@ fi
 
 # Build the binary
 print_status "Compiling binary..."
-CGO_ENABLED=0 GOOS=linux go build -a -installsuffix cgo -ldflags '-extldflags "-static"' -o bin/server cmd/server/main.go
+CGO_ENABLED=0 GOOS=linux go build -a -installsuffix cgo -ldflags '-extldflags "-static"' -o bin/server cmd/server/main.go && \
+CGO_ENABLED=0 GOOS=linux go build -a -installsuffix cgo -ldflags '-extldflags "-static"' -o bin/admin cmd/admin/main.go
 
 if [ $? -eq 0 ]; then
-    print_success "Binary built successfully: backend/bin/server"
+    print_success "Binaries built successfully: backend/bin/server, backend/bin/admin"
 else
     print_error "Failed to build binary"
     exit 1
@@ -81,6 +82,7 @@ print_status "Creating deployment package..."
 mkdir -p dist
 tar -czf dist/game2048-$(date +%Y%m%d-%H%M%S).tar.gz \
     backend/bin/server \
+    backend/bin/admin \
     docker/docker-compose.yml \
     docker/Dockerfile.backend \
     .env.example \